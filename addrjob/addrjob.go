@@ -0,0 +1,564 @@
+package addrjob
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/redis"
+	"github.com/group-coldwallet/trxsign/util"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StatusPending  = "pending" //排队中
+	StatusRunning  = "running"
+	StatusDone     = "done"
+	StatusFailed   = "failed"
+	StatusCanceled = "canceled"
+
+	DefaultChunkSize = 1000
+)
+
+var ErrNotFound = errors.New("job not found")
+
+// syncOwner 同步创建地址占用批次号时记录的占用者
+const syncOwner = "sync"
+
+// GenerateFunc 生成n个地址，返回生成的地址和失败重试的次数，ctx取消时返回错误
+type GenerateFunc func(ctx context.Context, n int) ([]util.AddrInfo, int, error)
+
+// PutFunc 保存一个分片的私钥，返回地址
+type PutFunc func(mchId, batchNo string, infos []util.AddrInfo) ([]string, error)
+
+/*
+Job 异步创建地址任务
+	按ChunkSize分片生成，每个分片保存为批次号BatchNo-分片序号的私钥文件，只有一个分片时为BatchNo
+	每个分片保存后记录进度，中断的任务重启后从下一个分片继续
+*/
+type Job struct {
+	Id         string `json:"jobId"`
+	CoinType   string `json:"coinType"`
+	Mch        string `json:"mch"`
+	BatchNo    string `json:"batchNo"`
+	Count      int    `json:"count"`
+	ChunkSize  int    `json:"chunkSize"`
+	Chunks     int    `json:"chunks"`    //已保存的分片数
+	Attempt    int    `json:"attempt"`   //重启次数，中断时未保存完的分片使用新的批次号重新生成
+	Generated  int    `json:"generated"` //已保存的地址数
+	Failed     int    `json:"failed"`    //生成失败并重试的次数
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	CreatedAt  int64  `json:"createdAt"`
+	UpdatedAt  int64  `json:"updatedAt"`
+	FinishedAt int64  `json:"finishedAt,omitempty"`
+}
+
+func (j *Job) totalChunks() int {
+	return (j.Count + j.ChunkSize - 1) / j.ChunkSize
+}
+
+// chunkBatchNo 分片的批次号，重启后的分片加上-r重启次数，避免与中断时写了一半的文件重名
+func (j *Job) chunkBatchNo(chunk int) string {
+	if j.totalChunks() == 1 && j.Attempt == 0 {
+		return j.BatchNo
+	}
+	batchNo := fmt.Sprintf("%s-%03d", j.BatchNo, chunk+1)
+	if j.Attempt > 0 {
+		batchNo = fmt.Sprintf("%s-r%d", batchNo, j.Attempt)
+	}
+	return batchNo
+}
+
+func (j *Job) finished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed || j.Status == StatusCanceled
+}
+
+// JobStatus 任务状态，Progress为百分比，Eta为预计剩余秒数
+type JobStatus struct {
+	Job
+	Progress float64 `json:"progress"`
+	Eta      int64   `json:"eta"`
+}
+
+type runState struct {
+	job     *Job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started time.Time //本次运行开始时间
+	base    int       //本次运行开始时已保存的地址数
+}
+
+/*
+Manager 币种的异步创建地址任务
+	任务文件保存在dir下：任务id.json为进度，任务id.txt为已生成的地址，每行一个
+	同一时间只运行一个任务，其他任务排队
+*/
+type Manager struct {
+	mu        sync.RWMutex
+	dir       string
+	coinType  string
+	chunkSize int
+	generate  GenerateFunc
+	put       PutFunc
+	jobs      map[string]*runState
+	reserved  map[string]string //本实例占用的商户/批次号：正在提交的任务和同步创建的批次
+	sem       chan struct{}
+}
+
+// Open 加载dir下的任务，未完成的任务需要调用Resume继续
+func Open(dir, coinType string, chunkSize int, generate GenerateFunc, put PutFunc) (*Manager, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create job dir error: %v", err)
+	}
+	m := &Manager{
+		dir:       dir,
+		coinType:  strings.ToLower(coinType),
+		chunkSize: chunkSize,
+		generate:  generate,
+		put:       put,
+		jobs:      make(map[string]*runState),
+		reserved:  make(map[string]string),
+		sem:       make(chan struct{}, 1),
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		job := new(Job)
+		if err = json.Unmarshal(data, job); err != nil {
+			return nil, fmt.Errorf("parse job file %s error: %v", file, err)
+		}
+		m.jobs[job.Id] = &runState{job: job}
+	}
+	return m, nil
+}
+
+func (m *Manager) jobFile(id string) string {
+	return filepath.Join(m.dir, id+".json")
+}
+
+func (m *Manager) addressFile(id string) string {
+	return filepath.Join(m.dir, id+".txt")
+}
+
+/*
+Submit 创建任务并在后台运行
+	批次号在启用redis时多实例之间唯一，否则在本实例内唯一
+*/
+func (m *Manager) Submit(mchId, batchNo string, count int) (*JobStatus, error) {
+	if mchId == "" || batchNo == "" || count <= 0 {
+		return nil, errors.New("empty params")
+	}
+	id, err := newJobId()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	job := &Job{
+		Id:        id,
+		CoinType:  m.coinType,
+		Mch:       mchId,
+		BatchNo:   batchNo,
+		Count:     count,
+		ChunkSize: m.chunkSize,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err = m.claim(mchId, batchNo, "job "+id); err != nil {
+		return nil, err
+	}
+	// 加入jobs后由jobs检查重复
+	defer m.unclaim(mchId, batchNo)
+
+	// 新任务还没有加入jobs，占用redis和保存时不需要持有锁
+	if err = m.reserve(mchId, batchNo, id); err != nil {
+		return nil, err
+	}
+	if err = m.save(job); err != nil {
+		m.release(mchId, batchNo)
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rs := &runState{job: job}
+	m.jobs[id] = rs
+	status := m.snapshot(rs)
+	m.start(rs)
+	return status, nil
+}
+
+/*
+Reserve 同步创建地址前占用批次号，与任务使用相同的检查
+	创建成功后保持占用，失败时调用Release释放
+*/
+func (m *Manager) Reserve(mchId, batchNo string) error {
+	if mchId == "" || batchNo == "" {
+		return errors.New("empty params")
+	}
+	if err := m.claim(mchId, batchNo, syncOwner); err != nil {
+		return err
+	}
+	if err := m.reserve(mchId, batchNo, syncOwner); err != nil {
+		m.unclaim(mchId, batchNo)
+		return err
+	}
+	return nil
+}
+
+// Release 释放Reserve占用的批次号
+func (m *Manager) Release(mchId, batchNo string) {
+	m.release(mchId, batchNo)
+	m.unclaim(mchId, batchNo)
+}
+
+// claim 在本实例内占用批次号，已有任务或被占用时返回错误
+func (m *Manager) claim(mchId, batchNo, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rs := range m.jobs {
+		if rs.job.Mch == mchId && rs.job.BatchNo == batchNo {
+			return fmt.Errorf("batchNo %s is used by job %s", batchNo, rs.job.Id)
+		}
+	}
+	key := mchId + "/" + batchNo
+	if used, ok := m.reserved[key]; ok {
+		return fmt.Errorf("batchNo %s is used by %s", batchNo, used)
+	}
+	m.reserved[key] = owner
+	return nil
+}
+
+func (m *Manager) unclaim(mchId, batchNo string) {
+	m.mu.Lock()
+	delete(m.reserved, mchId+"/"+batchNo)
+	m.mu.Unlock()
+}
+
+// reserve 使用redis SetNX占用批次号，不过期；owner为任务id或sync
+func (m *Manager) reserve(mchId, batchNo, owner string) error {
+	if redis.Client == nil {
+		return nil
+	}
+	key := redis.GetCreateAddrBatchKey(m.coinType, mchId, batchNo)
+	ok, err := redis.Client.SetNX(key, owner, 0)
+	if err != nil {
+		return fmt.Errorf("reserve batchNo %s error: %v", batchNo, err)
+	}
+	if !ok {
+		used, _ := redis.Client.Get(key)
+		return fmt.Errorf("batchNo %s is used by %s", batchNo, used)
+	}
+	return nil
+}
+
+// release 任务没有保存成功或同步创建失败时释放redis中占用的批次号
+func (m *Manager) release(mchId, batchNo string) {
+	if redis.Client == nil {
+		return
+	}
+	if err := redis.Client.Del(redis.GetCreateAddrBatchKey(m.coinType, mchId, batchNo)); err != nil {
+		log.Errorf("release batchNo %s error: %v", batchNo, err)
+	}
+}
+
+/*
+Resume 继续未完成的任务，服务启动时调用
+	先截掉地址文件中最后一个未记录进度的分片，该分片使用新的批次号重新生成
+*/
+func (m *Manager) Resume() int {
+	m.mu.Lock()
+	var list []*runState
+	for _, rs := range m.jobs {
+		if !rs.job.finished() {
+			list = append(list, rs)
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].job.CreatedAt < list[j].job.CreatedAt
+	})
+	resumed := 0
+	for _, rs := range list {
+		job := rs.job
+		if err := truncateLines(m.addressFile(job.Id), job.Generated); err != nil {
+			m.fail(rs, fmt.Errorf("truncate address file error: %v", err))
+			continue
+		}
+		m.mu.Lock()
+		if job.Status == StatusRunning {
+			job.Attempt++
+		}
+		job.Status = StatusPending
+		m.mu.Unlock()
+		if err := m.update(rs); err != nil {
+			log.Errorf("save job %s error: %v", job.Id, err)
+			continue
+		}
+		log.Infof("resume create address job %s,batchNo=%s,%d/%d chunks done", job.Id, job.BatchNo, job.Chunks, job.totalChunks())
+		m.mu.Lock()
+		m.start(rs)
+		m.mu.Unlock()
+		resumed++
+	}
+	return resumed
+}
+
+// start 在后台运行任务，调用时需持有锁
+func (m *Manager) start(rs *runState) {
+	rs.ctx, rs.cancel = context.WithCancel(context.Background())
+	go m.run(rs)
+}
+
+// Cancel 取消排队中或运行中的任务，正在生成的分片不保存，已保存的分片和地址保留
+func (m *Manager) Cancel(id string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rs, ok := m.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if rs.job.finished() || rs.cancel == nil {
+		return fmt.Errorf("job %s is %s", id, rs.job.Status)
+	}
+	rs.cancel()
+	return nil
+}
+
+func (m *Manager) run(rs *runState) {
+	defer rs.cancel()
+	select {
+	case m.sem <- struct{}{}:
+		defer func() { <-m.sem }()
+	case <-rs.ctx.Done():
+		m.finish(rs, StatusCanceled, nil)
+		return
+	}
+
+	m.mu.Lock()
+	job := rs.job
+	job.Status = StatusRunning
+	rs.started = time.Now()
+	rs.base = job.Generated
+	m.mu.Unlock()
+	if err := m.update(rs); err != nil {
+		m.fail(rs, err)
+		return
+	}
+	for chunk := job.Chunks; chunk < job.totalChunks(); chunk++ {
+		n := job.ChunkSize
+		if rest := job.Count - chunk*job.ChunkSize; rest < n {
+			n = rest
+		}
+		if rs.ctx.Err() != nil {
+			m.finish(rs, StatusCanceled, nil)
+			return
+		}
+		infos, failed, err := m.generate(rs.ctx, n)
+		if rs.ctx.Err() != nil {
+			m.finish(rs, StatusCanceled, nil)
+			return
+		}
+		if err != nil {
+			m.fail(rs, fmt.Errorf("generate chunk %d error: %v", chunk+1, err))
+			return
+		}
+		var addresses []string
+		if len(infos) > 0 {
+			addresses, err = m.put(job.Mch, job.chunkBatchNo(chunk), infos)
+			if err != nil {
+				m.fail(rs, fmt.Errorf("save chunk %d error: %v", chunk+1, err))
+				return
+			}
+		}
+		if err = appendLines(m.addressFile(job.Id), addresses); err != nil {
+			m.fail(rs, fmt.Errorf("write address file error: %v", err))
+			return
+		}
+		m.mu.Lock()
+		job.Chunks = chunk + 1
+		job.Generated += len(addresses)
+		job.Failed += failed
+		m.mu.Unlock()
+		if err = m.update(rs); err != nil {
+			m.fail(rs, err)
+			return
+		}
+	}
+	m.finish(rs, StatusDone, nil)
+}
+
+func (m *Manager) fail(rs *runState, err error) {
+	m.finish(rs, StatusFailed, err)
+}
+
+// finish 记录任务的最终状态
+func (m *Manager) finish(rs *runState, status string, err error) {
+	m.mu.Lock()
+	job := rs.job
+	job.Status = status
+	if err != nil {
+		job.Error = err.Error()
+	}
+	job.FinishedAt = time.Now().Unix()
+	generated, failed := job.Generated, job.Failed
+	m.mu.Unlock()
+	if err != nil {
+		log.Errorf("create address job %s failed: %v", job.Id, err)
+	} else {
+		log.Infof("create address job %s %s,generated=%d,failed=%d", job.Id, status, generated, failed)
+	}
+	if err = m.update(rs); err != nil {
+		log.Errorf("save job %s error: %v", job.Id, err)
+	}
+}
+
+func (m *Manager) update(rs *runState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rs.job.UpdatedAt = time.Now().Unix()
+	return m.save(rs.job)
+}
+
+// save 先写临时文件再重命名，已加入jobs的任务调用时需持有锁
+func (m *Manager) save(job *Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.jobFile(job.Id) + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write job file error: %v", err)
+	}
+	return os.Rename(tmp, m.jobFile(job.Id))
+}
+
+func (m *Manager) snapshot(rs *runState) *JobStatus {
+	job := rs.job
+	status := &JobStatus{Job: *job}
+	done := job.Generated
+	if job.Count > 0 {
+		status.Progress = float64(done*10000/job.Count) / 100
+	}
+	if job.Status == StatusRunning && done > rs.base {
+		elapsed := time.Since(rs.started)
+		status.Eta = int64(elapsed.Seconds() * float64(job.Count-done) / float64(done-rs.base))
+	}
+	return status
+}
+
+// Status 任务状态和进度
+func (m *Manager) Status(id string) (*JobStatus, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rs, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return m.snapshot(rs), nil
+}
+
+/*
+Addresses 分页读取任务已生成的地址，limit<=0时返回offset之后的所有地址
+	返回的total为已生成的地址数
+*/
+func (m *Manager) Addresses(id string, offset, limit int) ([]string, int, error) {
+	m.mu.RLock()
+	rs, ok := m.jobs[id]
+	var total int
+	if ok {
+		total = rs.job.Generated
+	}
+	m.mu.RUnlock()
+	if !ok {
+		return nil, 0, ErrNotFound
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	end := total
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	addresses := []string{}
+	if offset >= end {
+		return addresses, total, nil
+	}
+	file, err := os.Open(m.addressFile(id))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for line := 0; line < end && scanner.Scan(); line++ {
+		if line >= offset {
+			addresses = append(addresses, scanner.Text())
+		}
+	}
+	return addresses, total, scanner.Err()
+}
+
+func appendLines(path string, lines []string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if len(lines) > 0 {
+		if _, err = file.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// truncateLines 只保留前n行
+func truncateLines(path string, n int) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	size := 0
+	for line := 0; line < n; line++ {
+		i := bytes.IndexByte(data[size:], '\n')
+		if i < 0 {
+			return fmt.Errorf("address file has %d lines,expect %d", line, n)
+		}
+		size += i + 1
+	}
+	return os.Truncate(path, int64(size))
+}
+
+func newJobId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
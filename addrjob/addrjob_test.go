@@ -0,0 +1,193 @@
+package addrjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeKeys struct {
+	mu      sync.Mutex
+	next    int
+	batches []string
+}
+
+// generate 每个分片重试一次
+func (f *fakeKeys) generate(ctx context.Context, n int) ([]util.AddrInfo, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var infos []util.AddrInfo
+	for i := 0; i < n; i++ {
+		f.next++
+		infos = append(infos, util.AddrInfo{Address: fmt.Sprintf("addr%d", f.next), PrivKey: "priv"})
+	}
+	return infos, 1, nil
+}
+
+func (f *fakeKeys) put(mchId, batchNo string, infos []util.AddrInfo) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, batchNo)
+	var addresses []string
+	for _, info := range infos {
+		addresses = append(addresses, info.Address)
+	}
+	return addresses, nil
+}
+
+func waitJob(t *testing.T, m *Manager, id string) *JobStatus {
+	for i := 0; i < 200; i++ {
+		status, err := m.Status(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.finished() {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s is not finished", id)
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addrjob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keys := new(fakeKeys)
+	m, err := Open(dir, "DOT", 10, keys.generate, keys.put)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := m.Submit("mch1", "b1", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Submit("mch1", "b1", 5); err == nil {
+		t.Fatal("duplicate batchNo should be rejected")
+	}
+	status := waitJob(t, m, job.Id)
+	if status.Status != StatusDone || status.Generated != 25 || status.Failed != 3 || status.Progress != 100 {
+		t.Fatalf("job status error: %+v", status)
+	}
+	if fmt.Sprint(keys.batches) != "[b1-001 b1-002 b1-003]" {
+		t.Fatalf("chunk batchNo error: %v", keys.batches)
+	}
+	addresses, total, err := m.Addresses(job.Id, 20, 10)
+	if err != nil || total != 25 || fmt.Sprint(addresses) != "[addr21 addr22 addr23 addr24 addr25]" {
+		t.Fatalf("addresses page error: %v %d %v", addresses, total, err)
+	}
+
+	// 模拟第二个分片保存后、记录进度前中断
+	job, err = m.Submit("mch1", "b2", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitJob(t, m, job.Id)
+	m.mu.Lock()
+	crashed := m.jobs[job.Id].job
+	crashed.Status, crashed.Chunks, crashed.Generated, crashed.Failed, crashed.FinishedAt = StatusRunning, 1, 10, 1, 0
+	err = m.save(crashed)
+	m.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys.batches = nil
+	reopened, err := Open(dir, "dot", 10, keys.generate, keys.put)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := reopened.Resume(); n != 1 {
+		t.Fatalf("resume %d jobs,expect 1", n)
+	}
+	status = waitJob(t, reopened, job.Id)
+	if status.Status != StatusDone || status.Generated != 25 || status.Attempt != 1 {
+		t.Fatalf("resumed job status error: %+v", status)
+	}
+	if fmt.Sprint(keys.batches) != "[b2-002-r1 b2-003-r1]" {
+		t.Fatalf("resumed chunk batchNo error: %v", keys.batches)
+	}
+	addresses, total, err = reopened.Addresses(job.Id, 0, 0)
+	if err != nil || total != 25 || len(addresses) != 25 || addresses[9] != "addr35" || addresses[10] != "addr51" {
+		t.Fatalf("resumed addresses error: %v %d %v", addresses, total, err)
+	}
+
+	// 同一批次号并发提交只有一个成功
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted []string
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if status, err := reopened.Submit("mch1", "b3", 5); err == nil {
+				mu.Lock()
+				accepted = append(accepted, status.Id)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(accepted) != 1 {
+		t.Fatalf("concurrent submit accepted %d jobs,expect 1", len(accepted))
+	}
+	waitJob(t, reopened, accepted[0])
+
+	// 同步创建地址与任务共用批次号
+	if err = reopened.Reserve("mch1", "b3"); err == nil {
+		t.Fatal("batchNo used by job should not be reserved")
+	}
+	if err = reopened.Reserve("mch1", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = reopened.Submit("mch1", "s1", 5); err == nil {
+		t.Fatal("reserved batchNo should be rejected")
+	}
+	reopened.Release("mch1", "s1")
+	if err = reopened.Reserve("mch1", "s1"); err != nil {
+		t.Fatalf("released batchNo should be reserved again: %v", err)
+	}
+}
+
+func TestManagerCancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addrjob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	started := make(chan struct{})
+	generate := func(ctx context.Context, n int) ([]util.AddrInfo, int, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, 0, errors.New("canceled")
+	}
+	m, err := Open(dir, "dot", 10, generate, new(fakeKeys).put)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := m.Submit("mch1", "b1", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err = m.Cancel(job.Id); err != nil {
+		t.Fatal(err)
+	}
+	if status := waitJob(t, m, job.Id); status.Status != StatusCanceled || status.Generated != 0 {
+		t.Fatalf("canceled job status error: %+v", status)
+	}
+	if err = m.Cancel(job.Id); err == nil {
+		t.Fatal("finished job can not be canceled")
+	}
+}
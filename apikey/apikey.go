@@ -0,0 +1,282 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 接口权限
+const (
+	ScopeCreateAddr = "createAddr"
+	ScopeSign       = "sign"
+	ScopeTransfer   = "transfer"
+	ScopeGetBalance = "getBalance"
+	ScopeWatch      = "watch" //导入和查询观察地址
+)
+
+var AllScopes = []string{ScopeCreateAddr, ScopeSign, ScopeTransfer, ScopeGetBalance, ScopeWatch}
+
+const idPrefix = "ak_"
+
+/*
+Key 商户的api key
+	只保存secret的sha256，完整的key为 id.secret，只在创建时返回一次
+	Coins为空时不限制币种
+*/
+type Key struct {
+	Id        string   `json:"id"`
+	Hash      string   `json:"hash"`
+	MchId     string   `json:"mchId"`
+	Scopes    []string `json:"scopes"`
+	Coins     []string `json:"coins,omitempty"`
+	CreatedAt int64    `json:"createdAt"`
+	ExpiresAt int64    `json:"expiresAt,omitempty"` //轮换后旧key的过期时间，0为不过期
+	Revoked   bool     `json:"revoked,omitempty"`
+}
+
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *Key) AllowCoin(coinType string) bool {
+	if len(k.Coins) == 0 {
+		return true
+	}
+	for _, c := range k.Coins {
+		if strings.EqualFold(c, coinType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *Key) active(now time.Time) bool {
+	return !k.Revoked && (k.ExpiresAt == 0 || now.Unix() < k.ExpiresAt)
+}
+
+/*
+Store 保存在json文件中的api key
+	服务端每次校验前检查文件修改时间，命令行修改后无需重启
+*/
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	modTime time.Time
+	keys    map[string]*Key
+}
+
+// Open 加载key文件，文件不存在时为空
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, keys: make(map[string]*Key)}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) load() error {
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var list []*Key
+	if err = json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parse api key file %s error: %v", s.path, err)
+	}
+	keys := make(map[string]*Key, len(list))
+	for _, k := range list {
+		keys[k.Id] = k
+	}
+	s.keys = keys
+	s.modTime = info.ModTime()
+	return nil
+}
+
+func (s *Store) reloadIfChanged() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	s.mu.RLock()
+	changed := !info.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Save 先写临时文件再重命名，避免服务端读到写了一半的文件
+func (s *Store) Save() error {
+	s.mu.RLock()
+	data, err := json.MarshalIndent(s.list(), "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *Store) list() []*Key {
+	list := make([]*Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		list = append(list, k)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt < list[j].CreatedAt })
+	return list
+}
+
+func (s *Store) List() []*Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list()
+}
+
+// Create 创建key，返回的完整key只能在此时获取
+func (s *Store) Create(mchId string, scopes, coins []string) (*Key, string, error) {
+	if mchId == "" {
+		return nil, "", errors.New("mchId is empty")
+	}
+	if len(scopes) == 0 {
+		return nil, "", errors.New("scopes is empty")
+	}
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return nil, "", fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	k := &Key{
+		Id:        idPrefix + id,
+		Hash:      hashSecret(secret),
+		MchId:     mchId,
+		Scopes:    scopes,
+		Coins:     coins,
+		CreatedAt: time.Now().Unix(),
+	}
+	s.mu.Lock()
+	s.keys[k.Id] = k
+	s.mu.Unlock()
+	return k, k.Id + "." + secret, nil
+}
+
+/*
+Rotate 轮换key
+	新key继承商户、权限和币种，旧key在grace后失效，grace为0时立即失效
+*/
+func (s *Store) Rotate(id string, grace time.Duration) (*Key, string, error) {
+	s.mu.RLock()
+	old, ok := s.keys[id]
+	s.mu.RUnlock()
+	if !ok || !old.active(time.Now()) {
+		return nil, "", fmt.Errorf("api key %s is not found or inactive", id)
+	}
+	k, full, err := s.Create(old.MchId, old.Scopes, old.Coins)
+	if err != nil {
+		return nil, "", err
+	}
+	s.mu.Lock()
+	if grace <= 0 {
+		old.Revoked = true
+	} else {
+		old.ExpiresAt = time.Now().Add(grace).Unix()
+	}
+	s.mu.Unlock()
+	return k, full, nil
+}
+
+func (s *Store) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return fmt.Errorf("api key %s is not found", id)
+	}
+	k.Revoked = true
+	return nil
+}
+
+// Authenticate 校验完整的key(id.secret)，返回对应的Key
+func (s *Store) Authenticate(apiKey string) (*Key, error) {
+	if err := s.reloadIfChanged(); err != nil {
+		return nil, fmt.Errorf("reload api key file error: %v", err)
+	}
+	idx := strings.IndexByte(apiKey, '.')
+	if idx <= 0 {
+		return nil, errors.New("api key format is invalid")
+	}
+	s.mu.RLock()
+	k, ok := s.keys[apiKey[:idx]]
+	s.mu.RUnlock()
+	if !ok || subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hashSecret(apiKey[idx+1:]))) != 1 {
+		return nil, errors.New("api key is invalid")
+	}
+	if !k.active(time.Now()) {
+		return nil, errors.New("api key is revoked or expired")
+	}
+	return k, nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func hashSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
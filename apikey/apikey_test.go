@@ -0,0 +1,96 @@
+package apikey
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "apikey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := Open(filepath.Join(dir, "apikeys.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, func() { os.RemoveAll(dir) }
+}
+
+func TestCreateAndAuthenticate(t *testing.T) {
+	store, clean := testStore(t)
+	defer clean()
+	if _, _, err := store.Create("hoo", []string{"withdraw"}, nil); err == nil {
+		t.Fatal("unknown scope should fail")
+	}
+	k, full, err := store.Create("hoo", []string{ScopeSign}, []string{"dot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// 重新打开，确认文件中只有hash
+	reopened, err := Open(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reopened.Authenticate(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Id != k.Id || got.MchId != "hoo" || got.Hash == full {
+		t.Fatalf("authenticate result error: %+v", got)
+	}
+	if !got.HasScope(ScopeSign) || got.HasScope(ScopeTransfer) {
+		t.Fatal("scope check error")
+	}
+	if !got.AllowCoin("DOT") || got.AllowCoin("ksm") {
+		t.Fatal("coin check error")
+	}
+	if _, err = reopened.Authenticate(k.Id + ".wrong"); err == nil {
+		t.Fatal("wrong secret should fail")
+	}
+}
+
+func TestRotateAndRevoke(t *testing.T) {
+	store, clean := testStore(t)
+	defer clean()
+	old, oldFull, err := store.Create("hoo", []string{ScopeTransfer}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, full, err := store.Rotate(old.Id, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.MchId != old.MchId || !k.HasScope(ScopeTransfer) {
+		t.Fatal("rotated key should inherit mchId and scopes")
+	}
+	// grace期内新旧key都可用
+	if _, err = store.Authenticate(oldFull); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Authenticate(full); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Revoke(old.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Authenticate(oldFull); err == nil {
+		t.Fatal("revoked key should fail")
+	}
+	_, full2, err := store.Rotate(k.Id, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Authenticate(full); err == nil {
+		t.Fatal("key rotated without grace should fail")
+	}
+	if _, err = store.Authenticate(full2); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+/*
+api key管理工具
+	apikey [-file ./conf/apikeys.json] create -mch hoo -scopes sign,transfer [-coins dot,ksm]
+	apikey rotate -id ak_xxx [-grace 24h]
+	apikey revoke -id ak_xxx
+	apikey list
+*/
+
+import (
+	"flag"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/apikey"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	file := flag.String("file", "./conf/apikeys.json", "api key file path")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	store, err := apikey.Open(*file)
+	if err != nil {
+		fatal(err)
+	}
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	switch cmd {
+	case "create":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		mch := fs.String("mch", "", "merchant id")
+		scopes := fs.String("scopes", "", "comma separated scopes: "+strings.Join(apikey.AllScopes, ","))
+		coins := fs.String("coins", "", "comma separated coin types,empty means all coins")
+		fs.Parse(args)
+		k, full, err := store.Create(*mch, splitList(*scopes), splitList(*coins))
+		if err != nil {
+			fatal(err)
+		}
+		save(store)
+		printKey(k)
+		fmt.Printf("api key: %s\n", full)
+		fmt.Println("请妥善保存，api key只显示一次")
+	case "rotate":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		id := fs.String("id", "", "api key id")
+		grace := fs.Duration("grace", 0, "old key stays valid for this duration,0 means revoke immediately")
+		fs.Parse(args)
+		k, full, err := store.Rotate(*id, *grace)
+		if err != nil {
+			fatal(err)
+		}
+		save(store)
+		printKey(k)
+		fmt.Printf("api key: %s\n", full)
+		fmt.Println("请妥善保存，api key只显示一次")
+	case "revoke":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		id := fs.String("id", "", "api key id")
+		fs.Parse(args)
+		if err := store.Revoke(*id); err != nil {
+			fatal(err)
+		}
+		save(store)
+		fmt.Printf("api key %s revoked\n", *id)
+	case "list":
+		for _, k := range store.List() {
+			printKey(k)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func printKey(k *apikey.Key) {
+	status := "active"
+	if k.Revoked {
+		status = "revoked"
+	} else if k.ExpiresAt > 0 {
+		status = "expires at " + time.Unix(k.ExpiresAt, 0).Format("2006-01-02 15:04:05")
+	}
+	coins := strings.Join(k.Coins, ",")
+	if coins == "" {
+		coins = "*"
+	}
+	fmt.Printf("id=%s mch=%s scopes=%s coins=%s status=%s\n", k.Id, k.MchId, strings.Join(k.Scopes, ","), coins, status)
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func save(store *apikey.Store) {
+	if err := store.Save(); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: apikey [-file path] <command> [args]
+commands:
+  create -mch <mchId> -scopes <scopes> [-coins <coins>]
+  rotate -id <keyId> [-grace 24h]
+  revoke -id <keyId>
+  list`)
+}
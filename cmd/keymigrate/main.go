@@ -0,0 +1,187 @@
+package main
+
+/*
+私钥文件迁移工具
+	将目录下v1格式的_a_/_b_文件重新加密为v2格式(AES-256-GCM)，已是v2的文件跳过
+	指定-wrap时迁移为v3格式，数据密钥由主密钥加密；主密钥参数文件不存在时使用输入的口令初始化
+	指定-bolt时迁移后再将私钥导入bolt文件，用于切换到[keyStore] type = "bolt"
+	keymigrate -path ./file [-backup] [-wrap [-masterKeyFile file] [-source prompt|env|fd]] [-bolt ./file/keys.db]
+*/
+
+import (
+	"flag"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/keystore"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	path := flag.String("path", "./file", "private key file directory")
+	backup := flag.Bool("backup", false, "keep old files as .bak after migration")
+	wrap := flag.Bool("wrap", false, "wrap data keys with the master key (v3 format)")
+	masterKeyFile := flag.String("masterKeyFile", "", "master key params file,default is <path>/masterkey.json")
+	source := flag.String("source", util.PassphraseSourcePrompt, "passphrase source: prompt,env or fd")
+	env := flag.String("env", "TRXSIGN_MASTER_KEY", "passphrase environment variable")
+	fd := flag.Int("fd", 3, "passphrase file descriptor")
+	boltFile := flag.String("bolt", "", "import migrated keys into this bolt file")
+	flag.Parse()
+
+	var mk *util.MasterKey
+	if *wrap {
+		file := *masterKeyFile
+		if file == "" {
+			file = filepath.Join(*path, "masterkey.json")
+		}
+		var err error
+		if mk, err = openMasterKey(file, *source, *env, *fd); err != nil {
+			fatal(err)
+		}
+		services.RequireMasterKey()
+		services.SetMasterKey(mk)
+	}
+
+	var pairs []string
+	err := filepath.Walk(*path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(p, ".csv") && strings.Contains(filepath.Base(p), "_a_") {
+			pairs = append(pairs, p)
+		}
+		return nil
+	})
+	if err != nil {
+		fatal(err)
+	}
+	total := 0
+	for _, aPath := range pairs {
+		bPath := util.KeyFilePairPath(aPath)
+		n, err := util.MigrateKeyFilePair(aPath, bPath, mk, *backup)
+		if err != nil {
+			fatal(fmt.Errorf("migrate %s error: %v", aPath, err))
+		}
+		if n == 0 {
+			fmt.Printf("skip %s,already migrated\n", aPath)
+			continue
+		}
+		fmt.Printf("migrated %s,%d keys\n", aPath, n)
+		total += n
+	}
+	// 使用服务的加载逻辑再校验一遍，确认所有私钥都能解密
+	srv := services.NewWithFilePath(*path)
+	failed := verifyKeyStore(srv.KeyStore())
+	if failed > 0 {
+		os.Exit(1)
+	}
+	if *boltFile != "" {
+		if err = importBolt(*boltFile, pairs, mk); err != nil {
+			fatal(err)
+		}
+	}
+	fmt.Printf("migrated %d keys\n", total)
+}
+
+// verifyKeyStore 确认所有私钥都能解密，返回失败的数量
+func verifyKeyStore(store keystore.KeyStore) int {
+	keys, err := store.List("")
+	if err != nil {
+		fatal(err)
+	}
+	failed := 0
+	for _, key := range keys {
+		buf, err := store.Get(key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "verify %s error: %v\n", key, err)
+			failed++
+			continue
+		}
+		buf.Destroy()
+	}
+	fmt.Printf("verified %d keys,%d failed\n", len(keys), failed)
+	return failed
+}
+
+/*
+importBolt 将a、b文件中的私钥原样导入bolt文件
+	文件名格式为 币种_a_usb_批次号.csv，商户为所在目录名；已导入的地址会导致该文件导入失败
+*/
+func importBolt(file string, pairs []string, mk *util.MasterKey) error {
+	store, err := keystore.NewBoltStore(keystore.Options{
+		BoltFile:  file,
+		MasterKey: func() *util.MasterKey { return mk },
+	})
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	for _, aPath := range pairs {
+		name := strings.TrimSuffix(filepath.Base(aPath), ".csv")
+		parts := strings.SplitN(name, "_a_usb_", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("unknown key file name: %s", aPath)
+		}
+		version, aRecords, err := util.ReadKeyFile(aPath)
+		if err != nil {
+			return err
+		}
+		_, bRecords, err := util.ReadKeyFile(util.KeyFilePairPath(aPath))
+		if err != nil {
+			return err
+		}
+		aesKeys := make(map[string]string, len(bRecords))
+		for _, r := range bRecords {
+			aesKeys[r[0]] = r[1]
+		}
+		records := make(map[string]*keystore.BoltRecord, len(aRecords))
+		for _, r := range aRecords {
+			records[r[0]] = &keystore.BoltRecord{
+				MchId:      filepath.Base(filepath.Dir(aPath)),
+				CoinType:   parts[0],
+				BatchNo:    parts[1],
+				Version:    version,
+				AesKey:     aesKeys[r[0]],
+				Ciphertext: r[1],
+			}
+		}
+		if err = store.PutRecords(records); err != nil {
+			return fmt.Errorf("import %s error: %v", aPath, err)
+		}
+		fmt.Printf("imported %s,%d keys\n", aPath, len(records))
+	}
+	if failed := verifyKeyStore(store); failed > 0 {
+		return fmt.Errorf("%d keys in %s can not be decrypted", failed, file)
+	}
+	return nil
+}
+
+// openMasterKey 参数文件存在时解锁，否则输入两次口令初始化
+func openMasterKey(file, source, env string, fd int) (*util.MasterKey, error) {
+	passphrase, err := util.ReadPassphrase(source, env, fd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = os.Stat(file); err == nil {
+		return util.UnlockMasterKey(file, passphrase)
+	}
+	if source == util.PassphraseSourcePrompt {
+		fmt.Fprintln(os.Stderr, "master key is not initialized,please confirm the passphrase")
+		confirm, err := util.ReadPassphrase(source, env, fd)
+		if err != nil {
+			return nil, err
+		}
+		if string(confirm) != string(passphrase) {
+			return nil, fmt.Errorf("passphrase does not match")
+		}
+	}
+	fmt.Printf("init master key %s\n", file)
+	return util.InitMasterKey(file, passphrase)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
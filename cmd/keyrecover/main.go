@@ -0,0 +1,131 @@
+package main
+
+/*
+Shamir份额恢复工具
+	合并任意threshold个份额文件，恢复c文件内容，与d文件的地址逐行比对后重新生成a、b文件(v2格式)
+	启用了信封加密时，恢复后使用keymigrate -wrap迁移为v3
+	keyrecover -d ./file/hoo/dot_d_usb_1.csv [-out ./file/hoo] [-plain] share1.json share2.json ...
+*/
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/shamir"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	dPath := flag.String("d", "", "address file (_d_) of the batch,used to verify recovered keys")
+	out := flag.String("out", "", "output directory,default is the directory of the address file")
+	plain := flag.Bool("plain", false, "also write the plaintext c file")
+	flag.Parse()
+	if *dPath == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: keyrecover -d <address file> [-out dir] [-plain] <share file>...")
+		os.Exit(2)
+	}
+	if *out == "" {
+		*out = filepath.Dir(*dPath)
+	}
+
+	var files []*shamir.ShareFile
+	for _, path := range flag.Args() {
+		f, err := shamir.ReadShareFile(path)
+		if err != nil {
+			fatal(err)
+		}
+		files = append(files, f)
+	}
+	meta, secret, err := shamir.CombineShareFiles(files)
+	if err != nil {
+		fatal(err)
+	}
+	defer func() {
+		for i := range secret {
+			secret[i] = 0
+		}
+	}()
+	dName := fmt.Sprintf("%s_d_usb_%s.csv", meta.CoinType, meta.BatchNo)
+	if filepath.Base(*dPath) != dName {
+		fatal(fmt.Errorf("shares are for %s,not %s", dName, filepath.Base(*dPath)))
+	}
+
+	infos, err := parsePlain(secret)
+	if err != nil {
+		fatal(err)
+	}
+	if err = verifyAddresses(*dPath, infos, meta.Addresses); err != nil {
+		fatal(err)
+	}
+	aPath := filepath.Join(*out, fmt.Sprintf("%s_a_usb_%s.csv", meta.CoinType, meta.BatchNo))
+	if err = os.MkdirAll(*out, 0700); err != nil {
+		fatal(err)
+	}
+	if err = util.WriteKeyFilePair(aPath, infos, nil); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("recovered %d keys of mch %s,wrote %s and %s\n", len(infos), meta.MchId, aPath, util.KeyFilePairPath(aPath))
+	if *plain {
+		cPath := filepath.Join(*out, fmt.Sprintf("%s_c_usb_%s.csv", meta.CoinType, meta.BatchNo))
+		file, err := os.OpenFile(cPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			fatal(err)
+		}
+		_, err = file.Write(secret)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("wrote plaintext %s\n", cPath)
+	}
+}
+
+// parsePlain c文件每行为 地址,私钥[,助记词]
+func parsePlain(secret []byte) ([]util.AddrInfo, error) {
+	reader := csv.NewReader(bytes.NewReader(secret))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse recovered keys error: %v", err)
+	}
+	infos := make([]util.AddrInfo, 0, len(records))
+	for i, r := range records {
+		if len(r) < 2 || r[0] == "" || r[1] == "" {
+			return nil, fmt.Errorf("recovered line %d is invalid", i+1)
+		}
+		info := util.AddrInfo{Address: r[0], PrivKey: r[1]}
+		if len(r) > 2 {
+			info.Mnemonic = r[2]
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// verifyAddresses 恢复的地址必须与d文件逐行一致
+func verifyAddresses(dPath string, infos []util.AddrInfo, count int) error {
+	addresses, err := util.ReadCsv(dPath, 0)
+	if err != nil {
+		return err
+	}
+	if len(addresses) != len(infos) || len(infos) != count {
+		return fmt.Errorf("address count mismatch: d=%d,recovered=%d,share=%d", len(addresses), len(infos), count)
+	}
+	for i, address := range addresses {
+		if strings.TrimSpace(address) != infos[i].Address {
+			return fmt.Errorf("address mismatch at line %d: d=%s,recovered=%s", i+1, address, infos[i].Address)
+		}
+	}
+	return nil
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -11,6 +11,7 @@ import (
 
 	//log "github.com/sirupsen/logrus"
 	"path/filepath"
+	"strings"
 	"sync"
 )
 
@@ -41,6 +42,18 @@ func InitConfig() {
 				panic(fmt.Sprintf("decode auth info error,Err=%v", err))
 			}
 		}
+		if Config.SignAuthCfg.Encrypt {
+			err = decryptSignAuthConfig(Config)
+			if err != nil {
+				panic(fmt.Sprintf("decode sign auth secrets error,Err=%v", err))
+			}
+		}
+		if cfg := Config.ShareCfg; cfg.Enable && (cfg.Threshold < 2 || cfg.Threshold > cfg.Total) {
+			panic(fmt.Sprintf("invalid share config,threshold=%d,total=%d", cfg.Threshold, cfg.Total))
+		}
+		if cfg := Config.ShareCfg; cfg.Enable && len(cfg.Dirs) != cfg.Total {
+			panic(fmt.Sprintf("invalid share config,%d dirs for %d shares", len(cfg.Dirs), cfg.Total))
+		}
 	})
 }
 
@@ -58,17 +71,75 @@ func decryptAuthConfig(cfg *tomlConfig) error {
 	return nil
 }
 
+func decryptSignAuthConfig(cfg *tomlConfig) error {
+	for mchId, secret := range cfg.SignAuthCfg.Secrets {
+		plain, err := util.AesBase64Crypt([]byte(secret), []byte(aesKey), false)
+		if err != nil {
+			return fmt.Errorf("mchId %s: %v", mchId, err)
+		}
+		cfg.SignAuthCfg.Secrets[mchId] = string(plain)
+	}
+	return nil
+}
+
+// GetCoinTypes 启用的币种，配置了coinTypes时使用coinTypes，否则为coinType
+func (c *tomlConfig) GetCoinTypes() []string {
+	if len(c.CoinTypes) > 0 {
+		return c.CoinTypes
+	}
+	return []string{c.CoinType}
+}
+
+/*
+GetKeyFilePath 币种的私钥文件目录
+	只配置coinType时为filePath，兼容原有目录结构；配置了coinTypes时为filePath/币种
+*/
+func (c *tomlConfig) GetKeyFilePath(coinType string) string {
+	if len(c.CoinTypes) == 0 {
+		return c.FilePath
+	}
+	return filepath.Join(c.FilePath, strings.ToLower(coinType))
+}
+
+// GetMasterKeyFile 主密钥派生参数文件，多币种时所有币种共用
+func (c *tomlConfig) GetMasterKeyFile() string {
+	if c.MasterKeyCfg.File != "" {
+		return c.MasterKeyCfg.File
+	}
+	return filepath.Join(c.FilePath, "masterkey.json")
+}
+
+// GetBoltFile 币种的bolt私钥库文件，默认为私钥文件目录下的keys.db
+func (c *tomlConfig) GetBoltFile(coinType string) string {
+	if c.KeyStoreCfg.BoltFile != "" && len(c.CoinTypes) == 0 {
+		return c.KeyStoreCfg.BoltFile
+	}
+	return filepath.Join(c.GetKeyFilePath(coinType), "keys.db")
+}
+
+// GetAddrJobDir 币种的异步创建地址任务目录，默认为私钥文件目录下的jobs
+func (c *tomlConfig) GetAddrJobDir(coinType string) string {
+	if c.AddrJobCfg.Dir == "" {
+		return filepath.Join(c.GetKeyFilePath(coinType), "jobs")
+	}
+	if len(c.CoinTypes) == 0 {
+		return c.AddrJobCfg.Dir
+	}
+	return filepath.Join(c.AddrJobCfg.Dir, strings.ToLower(coinType))
+}
+
 type tomlConfig struct {
-	Debug         bool   `toml:"debug"`
-	Port          string `toml:"port"`
-	CoinType      string `toml:"coinType"`
-	WalletType    string `toml:"walletType"` //hot or cold wallet
-	FilePath      string `toml:"filePath"`
-	IsStartThread bool   `toml:"isStartThread"`
-	MchId         string `toml:"mchId"`
-	OrderId       string `toml:"orderId"`
-	Version       string `toml:"version"`
-	NodeUrl       string `toml:"nodeUrl"`
+	Debug         bool     `toml:"debug"`
+	Port          string   `toml:"port"`
+	CoinType      string   `toml:"coinType"`
+	CoinTypes     []string `toml:"coinTypes"`  //同一进程启用多个币种，配置后忽略coinType，各币种私钥位于filePath/币种目录下
+	WalletType    string   `toml:"walletType"` //hot or cold wallet
+	FilePath      string   `toml:"filePath"`
+	IsStartThread bool     `toml:"isStartThread"`
+	MchId         string   `toml:"mchId"`
+	OrderId       string   `toml:"orderId"`
+	Version       string   `toml:"version"`
+	NodeUrl       string   `toml:"nodeUrl"`
 	AuthCfg       struct {
 		Encrypt  bool   `toml:"encrypt"`
 		Enable   bool   `toml:"enable"`
@@ -76,6 +147,49 @@ type tomlConfig struct {
 		Password string `toml:"password"`
 	} `toml:"auth"`
 
+	ApiKeyCfg struct {
+		Enable bool   `toml:"enable"`
+		File   string `toml:"file"` //api key文件，使用cmd/apikey管理，默认./conf/apikeys.json
+	} `toml:"apiKey"`
+	AdminCfg struct {
+		Token string `toml:"token"` //管理接口的令牌，请求头X-Admin-Token，为空时不注册管理接口
+	} `toml:"admin"`
+	KeyStoreCfg struct {
+		Type     string `toml:"type"`     //csv(默认)或bolt
+		Watch    bool   `toml:"watch"`    //csv时监听私钥目录，自动加载新增或修改的文件
+		BoltFile string `toml:"boltFile"` //只配置coinType时有效，默认filePath/keys.db
+	} `toml:"keyStore"`
+	ShareCfg struct {
+		Enable    bool     `toml:"enable"`
+		Threshold int      `toml:"threshold"` //恢复需要的份额数M
+		Total     int      `toml:"total"`     //份额总数N
+		Dirs      []string `toml:"dirs"`      //每个份额的写入目录，数量等于total，不能相同也不能在私钥文件目录下
+	} `toml:"share"`
+	MasterKeyCfg struct {
+		Enable bool   `toml:"enable"`
+		Source string `toml:"source"` //口令来源：prompt(终端输入)、env(环境变量)、fd(文件描述符)
+		Env    string `toml:"env"`    //source为env时的环境变量名，默认TRXSIGN_MASTER_KEY
+		Fd     int    `toml:"fd"`     //source为fd时的文件描述符，默认3
+		File   string `toml:"file"`   //主密钥派生参数文件，默认filePath/masterkey.json
+	} `toml:"masterKey"`
+	SignAuthCfg struct {
+		Enable  bool              `toml:"enable"`
+		Encrypt bool              `toml:"encrypt"` //secrets是否使用aesKey加密
+		MaxSkew int64             `toml:"maxSkew"` //createTime与服务器时间允许的偏差(秒)，默认300
+		Secrets map[string]string `toml:"secrets"` //mchId对应的hmac-sha256密钥
+	} `toml:"signAuth"`
+	VerifyCfg struct {
+		Secret string `toml:"secret"` //核对报告的hmac-sha256签名密钥
+	} `toml:"verify"`
+	GenerateCfg struct {
+		Workers     int `toml:"workers"`     //生成地址的协程数，默认cpu数
+		MaxFailures int `toml:"maxFailures"` //一批地址允许失败重试的次数，默认为数量的1%+10
+	} `toml:"generate"`
+	AddrJobCfg struct {
+		Dir       string `toml:"dir"`       //任务目录，多币种时为dir/币种
+		ChunkSize int    `toml:"chunkSize"` //每个分片的地址数，默认1000
+	} `toml:"addrJob"`
+
 	RedisConfig struct {
 		Cluster bool   `toml:"cluster"`
 		Addr    string `toml:"addr"`
@@ -91,8 +205,10 @@ type tomlConfig struct {
 		NodeUrl string `toml:"nodeUrl"`
 	} `toml:"ar"`
 	HntCfg struct {
-		NodeUrl  string `toml:"nodeUrl"`
-		LockTime int64  `toml:"lockTime"`
+		NodeUrl       string `toml:"nodeUrl"`
+		LockTime      int64  `toml:"lockTime"`      //热钱包出账后地址锁定时间(秒)，锁定期间该地址不能再次出账
+		Testnet       bool   `toml:"testnet"`       //是否为测试网地址
+		FeeMultiplier uint64 `toml:"feeMultiplier"` //离线签名使用的txn_fee_multiplier，默认5000
 	} `toml:"hnt"`
 	CdsCfg struct {
 		NodeUrl   string `toml:"nodeUrl"`
@@ -101,41 +217,30 @@ type tomlConfig struct {
 		GasPrice  int64  `toml:"gasPrice"`
 		NetWorkId int    `toml:"networkid"`
 	} `toml:"cds"`
-	KsmCfg struct {
-		NodeUrl string `toml:"nodeUrl"`
-	} `toml:"ksm"`
-	CringCfg struct {
-		NodeUrl string `toml:"nodeUrl"`
-	} `toml:"cring"`
 	NearCfg struct {
-		NodeUrl string `toml:"nodeUrl"`
+		NodeUrl       string `toml:"nodeUrl"`
+		AccountSuffix string `toml:"accountSuffix"` //命名账户后缀，主网near，测试网testnet
+		FtGas         uint64 `toml:"ftGas"`         //ft_transfer和storage_deposit的gas，默认30Tgas
 	} `toml:"near"`
 	CocosCfg struct {
 		NodeUrl string `toml:"nodeUrl"`
+		ChainId string `toml:"chainId"` //为空时热钱包从节点获取，离线签名未传chain_id时使用
 	} `toml:"cocos"`
 	FioCfg struct {
 		NodeUrl string `toml:"nodeUrl"`
+		ChainId string `toml:"chainId"`
+		MaxFee  int64  `toml:"maxFee"` //离线签名未传max_fee时使用，单位SUF
+		Tpid    string `toml:"tpid"`   //technology provider的fio地址，可为空
 	} `toml:"fio"`
-	DotCfg struct {
-		NodeUrl string `toml:"nodeUrl"`
-	} `toml:"dot"`
-	FisCfg struct {
-		NodeUrl string `toml:"nodeUrl"`
-	} `toml:"fis"`
-	OriCfg struct {
-		NodeUrl string `toml:"nodeUrl"`
-	} `toml:"ori"`
 	TkmCfg struct {
 		NodeUrl string `toml:"nodeUrl"`
 	} `toml:"tkm"`
 	SolCfg struct {
-		NodeUrl  string `toml:"nodeUrl"`
-		User     string `toml:"user"`
-		Password string `toml:"password"`
+		NodeUrl        string `toml:"nodeUrl"`
+		User           string `toml:"user"`
+		Password       string `toml:"password"`
+		ConfirmTimeout int64  `toml:"confirmTimeout"` //热钱包等待交易确认的超时时间（秒）
 	} `toml:"sol"`
-	PcxCfg struct {
-		NodeUrl string `toml:"nodeUrl"`
-	} `toml:"pcx"`
 	BscCfg struct {
 		NodeUrl   string `toml:"nodeUrl"`
 		User      string `toml:"user"`
@@ -167,10 +272,20 @@ type tomlConfig struct {
 	} `toml:"trx"`
 	DipCfg struct {
 		NodeUrl  string `toml:"nodeUrl"`
-		ApiUrl   string `toml:"apiUrl"`
+		ApiUrl   string `toml:"apiUrl"` //lcd地址，用于查询账户和广播交易
 		User     string `toml:"user"`
 		Password string `toml:"password"`
+		ChainId  string `toml:"chainId"` //为空时热钱包从lcd的node_info获取
+		Gas      uint64 `toml:"gas"`     //默认200000
+		Fee      int64  `toml:"fee"`     //默认500000pdip
 	} `toml:"dip"`
+	BncCfg struct {
+		ApiUrl  string `toml:"apiUrl"`  //binance chain的api地址，如https://dex.binance.org
+		ChainId string `toml:"chainId"` //为空时热钱包从node-info获取
+		Testnet bool   `toml:"testnet"` //测试网地址前缀为tbnb
+		Source  int64  `toml:"source"`  //交易来源标识，默认0
+		Fee     int64  `toml:"fee"`     //转账手续费，用于余额校验，默认37500(0.000375BNB)
+	} `toml:"bnc"`
 	XtzCfg struct {
 		NodeUrl  string `toml:"nodeUrl"`
 		User     string `toml:"user"`
@@ -183,4 +298,34 @@ type tomlConfig struct {
 		GasPrice int64  `toml:"gasPrice"`
 		GasLimit int64  `toml:"gasLimit"`
 	} `toml:"egld"`
+	// substrate系列链(dot/ksm/cring/fis/ori/pcx...)，新增链只需增加[substrate.币种]配置
+	SubstrateCfg map[string]*SubstrateChainCfg `toml:"substrate"`
+	// evm系列链，新增链只需增加[evm.币种]配置，bsc/heco/cph/cds未配置时使用各自原有的配置
+	EvmCfg map[string]*EvmChainCfg `toml:"evm"`
+}
+
+type EvmChainCfg struct {
+	NodeUrl        string `toml:"nodeUrl"`
+	User           string `toml:"user"`
+	Password       string `toml:"password"`
+	NetWorkId      int64  `toml:"networkid"`      //chain id
+	GasPrice       int64  `toml:"gasPrice"`       //单位wei，为0时热钱包从节点获取；eip1559时为max fee上限
+	GasLimit       int64  `toml:"gasLimit"`       //主币转账gas，默认21000
+	TokenGasLimit  int64  `toml:"tokenGasLimit"`  //代币转账gas，为0时热钱包通过节点估算
+	Eip1559        bool   `toml:"eip1559"`        //是否使用EIP-1559交易
+	MaxPriorityFee int64  `toml:"maxPriorityFee"` //eip1559小费，单位wei，为0时热钱包从节点获取
+}
+
+type SubstrateChainCfg struct {
+	NodeUrl           string `toml:"nodeUrl"`
+	User              string `toml:"user"`
+	Password          string `toml:"password"`
+	Ss58Prefix        uint16 `toml:"ss58Prefix"`        //地址网络前缀，dot=0 ksm=2
+	KeyType           string `toml:"keyType"`           //sr25519 or ed25519
+	CallIndex         string `toml:"callIndex"`         //balances.transferKeepAlive的下标，如dot为"0503"
+	AddressType       string `toml:"addressType"`       //multiAddress(默认) accountId lookup
+	CheckMetadataHash bool   `toml:"checkMetadataHash"` //runtime是否包含CheckMetadataHash签名扩展
+	EraPeriod         uint64 `toml:"eraPeriod"`         //交易有效区块数，0为永久有效
+	Tip               string `toml:"tip"`
+	AccountRefCounts  int    `toml:"accountRefCounts"` //AccountInfo中nonce后引用计数字段个数，默认3
 }
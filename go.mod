@@ -21,8 +21,18 @@ require (
 )
 
 require (
+	filippo.io/edwards25519 v1.0.0
+	github.com/ChainSafe/go-schnorrkel v1.0.0
+	github.com/Dipper-Labs/Dipper-Protocol v0.0.0-20201103114409-9e306c5ed78f
 	github.com/ElrondNetwork/elrond-go-crypto v1.0.1
 	github.com/ElrondNetwork/elrond-sdk-erdgo v1.0.22
+	github.com/btcsuite/btcd v0.22.0-beta
+	github.com/fsnotify/fsnotify v1.4.9
+	github.com/tendermint/tendermint v0.32.13
+	github.com/tyler-smith/go-bip39 v1.1.0
+	go.etcd.io/bbolt v1.3.6
+	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97
+	golang.org/x/sys v0.0.0-20210816183151-1e6c022a8912
 )
 
 replace github.com/ElrondNetwork/arwen-wasm-vm/v1_2 v1.2.35 => github.com/ElrondNetwork/arwen-wasm-vm v1.2.35
@@ -0,0 +1,224 @@
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var (
+	bucketKeys = []byte("keys")
+	bucketMch  = []byte("mch") //每个商户一个子bucket，key为地址
+)
+
+// BoltRecord 单个私钥，加密方式与csv文件v2、v3格式相同
+type BoltRecord struct {
+	MchId      string `json:"mchId"`
+	CoinType   string `json:"coinType"`
+	BatchNo    string `json:"batchNo"`
+	Version    int    `json:"version"`
+	AesKey     string `json:"aesKey"`
+	Ciphertext string `json:"ciphertext"`
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+/*
+BoltStore 使用bbolt单文件保存私钥
+	单个私钥的增删是事务性的，不会出现a、b文件只写了一半的情况
+*/
+type BoltStore struct {
+	opts Options
+	db   *bolt.DB
+}
+
+func NewBoltStore(opts Options) (*BoltStore, error) {
+	if opts.BoltFile == "" {
+		return nil, errors.New("bolt file is empty")
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.BoltFile), 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(opts.BoltFile, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open bolt file %s error: %v", opts.BoltFile, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(bucketKeys); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(bucketMch)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{opts: opts, db: db}, nil
+}
+
+func (s *BoltStore) Put(mchId, batchNo, coinType string, infos []util.AddrInfo) ([]string, error) {
+	if mchId == "" || batchNo == "" || coinType == "" {
+		return nil, errors.New("empty params")
+	}
+	if len(infos) == 0 {
+		return nil, errors.New("empty addrs")
+	}
+	if s.opts.locked() {
+		return nil, ErrLocked
+	}
+	mk := s.opts.masterKey()
+	now := time.Now().Unix()
+	records := make(map[string]*BoltRecord, len(infos))
+	addresses := make([]string, 0, len(infos))
+	for _, info := range infos {
+		aesKey, ciphertext, err := util.EncryptPrivateKey(info.Address, []byte(info.PrivKey))
+		if err != nil {
+			return nil, err
+		}
+		version := util.KeyFileVersion2
+		if mk != nil {
+			if aesKey, err = mk.WrapDataKey(info.Address, aesKey); err != nil {
+				return nil, err
+			}
+			version = util.KeyFileVersion3
+		}
+		records[info.Address] = &BoltRecord{
+			MchId:      mchId,
+			CoinType:   coinType,
+			BatchNo:    batchNo,
+			Version:    version,
+			AesKey:     aesKey,
+			Ciphertext: ciphertext,
+			CreatedAt:  now,
+		}
+		addresses = append(addresses, info.Address)
+	}
+	if err := s.PutRecords(records); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// PutRecords 写入已加密的私钥，用于从csv文件导入；同一批次在一个事务中，地址已存在时整批失败
+func (s *BoltStore) PutRecords(records map[string]*BoltRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		keys := tx.Bucket(bucketKeys)
+		for key, r := range records {
+			if keys.Get([]byte(key)) != nil {
+				return fmt.Errorf("key %s already exists", key)
+			}
+			data, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if err = keys.Put([]byte(key), data); err != nil {
+				return err
+			}
+			mch, err := tx.Bucket(bucketMch).CreateBucketIfNotExists([]byte(r.MchId))
+			if err != nil {
+				return err
+			}
+			if err = mch.Put([]byte(key), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) record(key string) (*BoltRecord, error) {
+	var r *BoltRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketKeys).Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		r = new(BoltRecord)
+		return json.Unmarshal(data, r)
+	})
+	return r, err
+}
+
+func (s *BoltStore) Get(key string) (*secmem.Buffer, error) {
+	if s.opts.locked() {
+		return nil, ErrLocked
+	}
+	r, err := s.record(key)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(r.Version, key, r.AesKey, r.Ciphertext, s.opts.masterKey())
+}
+
+func (s *BoltStore) Has(key string) bool {
+	_, err := s.record(key)
+	return err == nil
+}
+
+func (s *BoltStore) MchId(key string) (string, bool) {
+	r, err := s.record(key)
+	if err != nil {
+		return "", false
+	}
+	return r.MchId, true
+}
+
+func (s *BoltStore) List(mchId string) ([]string, error) {
+	var keys []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketKeys)
+		if mchId != "" {
+			b = tx.Bucket(bucketMch).Bucket([]byte(mchId))
+			if b == nil {
+				return nil
+			}
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	sort.Strings(keys)
+	return keys, err
+}
+
+func (s *BoltStore) Delete(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		keys := tx.Bucket(bucketKeys)
+		data := keys.Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		var r BoltRecord
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+		if mch := tx.Bucket(bucketMch).Bucket([]byte(r.MchId)); mch != nil {
+			if err := mch.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return keys.Delete([]byte(key))
+	})
+}
+
+// Reload 所有修改都经过db，无需重新加载，只返回私钥数量
+func (s *BoltStore) Reload() (ReloadStats, error) {
+	stats := ReloadStats{Files: 1}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		stats.Keys = tx.Bucket(bucketKeys).Stats().KeyN
+		return nil
+	})
+	return stats, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,336 @@
+package keystore
+
+import (
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type csvEntry struct {
+	mchId      string
+	file       string //a文件路径
+	aesKey     []byte //b文件中的密钥，使用secmem.Seal加密后保存
+	ciphertext string
+	aesVer     int
+	cipherVer  int
+}
+
+// csvFile 已加载的文件，大小和修改时间不变时不再重新读取
+type csvFile struct {
+	size    int64
+	modTime time.Time
+	keys    []string
+}
+
+/*
+CsvStore 原有的csv私钥文件
+	目录结构为 path/商户/币种_a_usb_批次号.csv，a文件为密文，b文件为密钥
+	按文件增量加载：Reload只重新读取新增或大小、修改时间变化的文件，并移除已删除文件中的私钥
+*/
+type CsvStore struct {
+	opts    Options
+	loadMu  sync.Mutex //同一时间只有一个加载过程
+	mu      sync.RWMutex
+	entries map[string]*csvEntry
+	files   map[string]*csvFile
+
+	watchMu sync.Mutex
+	watcher *csvWatcher
+}
+
+func NewCsvStore(opts Options) *CsvStore {
+	s := &CsvStore{
+		opts:    opts,
+		entries: make(map[string]*csvEntry),
+		files:   make(map[string]*csvFile),
+	}
+	if _, err := s.Reload(); err != nil {
+		log.Errorf("load csv key store %s error: %v", opts.Path, err)
+	}
+	return s
+}
+
+// isKeyFile a、b文件，c、d文件为导出备份，不加载
+func isKeyFile(path string) bool {
+	name := filepath.Base(path)
+	return filepath.Ext(name) == ".csv" && (strings.Contains(name, "_a_") || strings.Contains(name, "_b_"))
+}
+
+func (s *CsvStore) Reload() (ReloadStats, error) {
+	var stats ReloadStats
+	if s.opts.locked() {
+		log.Warnf("服务未解锁，不加载%s下的私钥", s.opts.Path)
+		return stats, nil
+	}
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	found := make(map[string]bool)
+	err := filepath.Walk(s.opts.Path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// 目录不存在时视为没有私钥
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !isKeyFile(path) {
+			return nil
+		}
+		found[path] = true
+		if s.loadFile(path, info) {
+			stats.Loaded++
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	for _, path := range s.loadedFiles() {
+		if !found[path] {
+			s.removeFile(path)
+			stats.Removed++
+		}
+	}
+	s.fillStats(&stats)
+	return stats, nil
+}
+
+// ReloadFiles 只重新加载指定的文件，文件不存在时移除其中的私钥
+func (s *CsvStore) ReloadFiles(paths ...string) (ReloadStats, error) {
+	var stats ReloadStats
+	if s.opts.locked() {
+		return stats, nil
+	}
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	for _, path := range paths {
+		if !isKeyFile(path) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			if s.removeFile(path) {
+				stats.Removed++
+			}
+			continue
+		}
+		if err != nil {
+			return stats, err
+		}
+		if s.loadFile(path, info) {
+			stats.Loaded++
+		}
+	}
+	s.fillStats(&stats)
+	return stats, nil
+}
+
+func (s *CsvStore) fillStats(stats *ReloadStats) {
+	s.mu.RLock()
+	stats.Files = len(s.files)
+	stats.Keys = len(s.entries)
+	s.mu.RUnlock()
+}
+
+func (s *CsvStore) loadedFiles() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	paths := make([]string, 0, len(s.files))
+	for path := range s.files {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// loadFile 文件未变化时返回false；读取失败的文件保留之前加载的内容
+func (s *CsvStore) loadFile(path string, info os.FileInfo) bool {
+	s.mu.RLock()
+	old, ok := s.files[path]
+	s.mu.RUnlock()
+	if ok && old.size == info.Size() && old.modTime.Equal(info.ModTime()) {
+		return false
+	}
+	version, records, err := util.ReadKeyFile(path)
+	if err != nil {
+		// 单个文件错误不影响其他文件，文件可能正在写入，下次变化时重试
+		log.Error(err)
+		return false
+	}
+	if version < util.KeyFileVersion3 && s.opts.masterKey() != nil {
+		log.Warnf("%s 未使用主密钥加密，请使用keymigrate -wrap迁移", path)
+	}
+	name := filepath.Base(path)
+	isA := strings.Contains(name, "_a_")
+	aPath := path
+	if !isA {
+		aPath = filepath.Join(filepath.Dir(path), strings.Replace(name, "_b_", "_a_", 1))
+	}
+	mchId := filepath.Base(filepath.Dir(path))
+	f := &csvFile{size: info.Size(), modTime: info.ModTime()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ok {
+		s.clearFile(path, old)
+	}
+	for _, r := range records {
+		if len(r) < 2 {
+			continue
+		}
+		e, ok := s.entries[r[0]]
+		if !ok {
+			e = &csvEntry{mchId: mchId, file: aPath}
+			s.entries[r[0]] = e
+		}
+		// 读请求持有的是旧entry，这里整体替换而不是修改
+		ne := *e
+		if isA {
+			ne.ciphertext, ne.cipherVer = r[1], version
+		} else {
+			sealed, err := secmem.Seal([]byte(r[1]))
+			if err != nil {
+				log.Errorf("seal aes key of %s error: %v", r[0], err)
+				continue
+			}
+			ne.aesKey, ne.aesVer = sealed, version
+		}
+		s.entries[r[0]] = &ne
+		f.keys = append(f.keys, r[0])
+	}
+	s.files[path] = f
+	return true
+}
+
+func (s *CsvStore) removeFile(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[path]
+	if !ok {
+		return false
+	}
+	s.clearFile(path, f)
+	delete(s.files, path)
+	return true
+}
+
+// clearFile 移除文件提供的密文或密钥，a、b都已移除时删除该私钥，调用方持有写锁
+func (s *CsvStore) clearFile(path string, f *csvFile) {
+	isA := strings.Contains(filepath.Base(path), "_a_")
+	for _, key := range f.keys {
+		e, ok := s.entries[key]
+		if !ok {
+			continue
+		}
+		ne := *e
+		if isA {
+			ne.ciphertext, ne.cipherVer = "", 0
+		} else {
+			ne.aesKey, ne.aesVer = nil, 0
+		}
+		if ne.ciphertext == "" && ne.aesKey == nil {
+			delete(s.entries, key)
+			continue
+		}
+		s.entries[key] = &ne
+	}
+}
+
+func (s *CsvStore) Put(mchId, batchNo, coinType string, infos []util.AddrInfo) ([]string, error) {
+	// 未解锁时无法用主密钥加密数据密钥
+	if s.opts.locked() {
+		return nil, ErrLocked
+	}
+	addresses, err := util.CreateAddrCsvWithOptions(s.opts.Path, mchId, batchNo, coinType, infos, util.CsvOptions{
+		MasterKey: s.opts.masterKey(),
+		Shares:    s.opts.Shares,
+	})
+	if err != nil {
+		return nil, err
+	}
+	aPath := filepath.Join(s.opts.Path, mchId, fmt.Sprintf("%s_a_usb_%s.csv", coinType, batchNo))
+	_, err = s.ReloadFiles(aPath, util.KeyFilePairPath(aPath))
+	return addresses, err
+}
+
+func (s *CsvStore) entry(key string) (*csvEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[key]
+	return e, ok
+}
+
+func (s *CsvStore) Get(key string) (*secmem.Buffer, error) {
+	if s.opts.locked() {
+		return nil, ErrLocked
+	}
+	e, ok := s.entry(key)
+	if !ok || e.aesKey == nil || e.ciphertext == "" {
+		return nil, fmt.Errorf("Load aes key or encrypt key is null,Address=[%s]", key)
+	}
+	if e.aesVer != e.cipherVer {
+		return nil, fmt.Errorf("key file version mismatch,a=v%d,b=v%d,Address=[%s]", e.cipherVer, e.aesVer, key)
+	}
+	aesKey, err := secmem.Open(e.aesKey)
+	if err != nil {
+		return nil, fmt.Errorf("open aes key of %s error: %v", key, err)
+	}
+	defer aesKey.Destroy()
+	return decrypt(e.cipherVer, key, secmem.String(aesKey.Bytes()), e.ciphertext, s.opts.masterKey())
+}
+
+func (s *CsvStore) Has(key string) bool {
+	_, ok := s.entry(key)
+	return ok
+}
+
+func (s *CsvStore) MchId(key string) (string, bool) {
+	e, ok := s.entry(key)
+	if !ok {
+		return "", false
+	}
+	return e.mchId, true
+}
+
+func (s *CsvStore) List(mchId string) ([]string, error) {
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.entries))
+	for key, e := range s.entries {
+		if mchId == "" || e.mchId == mchId {
+			keys = append(keys, key)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Delete 从a、b文件中删除该行，c、d文件为导出备份，不做修改
+func (s *CsvStore) Delete(key string) error {
+	e, ok := s.entry(key)
+	if !ok {
+		return ErrNotFound
+	}
+	if err := util.RemoveKeyFileRows(e.file, key); err != nil {
+		return err
+	}
+	_, err := s.ReloadFiles(e.file, util.KeyFilePairPath(e.file))
+	return err
+}
+
+func (s *CsvStore) Close() error {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.watcher == nil {
+		return nil
+	}
+	err := s.watcher.close()
+	s.watcher = nil
+	return err
+}
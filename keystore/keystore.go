@@ -0,0 +1,100 @@
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+)
+
+// 存储类型
+const (
+	TypeCsv  = "csv"
+	TypeBolt = "bolt"
+)
+
+var (
+	ErrNotFound = errors.New("private key is not found")
+	ErrLocked   = errors.New("key store is locked,master key is required")
+)
+
+/*
+KeyStore 私钥存储
+	key为地址，部分币种(gxc、fio、cocos等)为公钥
+	Get返回受保护内存中的私钥，调用方使用后Destroy
+*/
+type KeyStore interface {
+	// Put 保存一批新生成的私钥，返回保存的地址
+	Put(mchId, batchNo, coinType string, infos []util.AddrInfo) ([]string, error)
+	Get(key string) (*secmem.Buffer, error)
+	Has(key string) bool
+	// MchId key所属的商户
+	MchId(key string) (string, bool)
+	// List mchId为空时返回全部key
+	List(mchId string) ([]string, error)
+	Delete(key string) error
+	// Reload 重新加载外部的修改，如新生成的csv文件
+	Reload() (ReloadStats, error)
+	Close() error
+}
+
+// ReloadStats Reload的结果，Loaded、Removed为本次新加载和移除的文件数
+type ReloadStats struct {
+	Files   int `json:"files"`
+	Loaded  int `json:"loaded"`
+	Removed int `json:"removed"`
+	Keys    int `json:"keys"`
+}
+
+// Watcher 监听外部修改并自动加载，如手动拷贝进来的csv文件
+type Watcher interface {
+	Watch() error
+}
+
+type Options struct {
+	Type     string
+	Path     string //csv私钥文件目录
+	BoltFile string
+	// 启用信封加密时返回主密钥，未启用或未解锁时返回nil
+	MasterKey func() *util.MasterKey
+	// 启用了信封加密但尚未解锁
+	Locked func() bool
+	// csv不写明文c文件，改为Shamir份额文件
+	Shares *util.ShareOptions
+}
+
+func (o *Options) masterKey() *util.MasterKey {
+	if o.MasterKey == nil {
+		return nil
+	}
+	return o.MasterKey()
+}
+
+func (o *Options) locked() bool {
+	return o.Locked != nil && o.Locked()
+}
+
+// decrypt 解密到受保护内存，中间结果清零
+func decrypt(version int, key, aesKey, ciphertext string, mk *util.MasterKey) (*secmem.Buffer, error) {
+	plain, err := util.DecryptPrivateKey(version, key, aesKey, ciphertext, mk)
+	if err != nil {
+		return nil, err
+	}
+	return secmem.FromBytes(plain)
+}
+
+// Open 按类型创建存储，类型为空时使用csv
+func Open(opts Options) (KeyStore, error) {
+	switch opts.Type {
+	case TypeCsv, "":
+		return NewCsvStore(opts), nil
+	case TypeBolt:
+		s, err := NewBoltStore(opts)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported key store type: %s", opts.Type)
+	}
+}
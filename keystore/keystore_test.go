@@ -0,0 +1,179 @@
+package keystore
+
+import (
+	"github.com/group-coldwallet/trxsign/util"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testInfos = []util.AddrInfo{
+	{Address: "addr1", PrivKey: "priv1"},
+	{Address: "addr2", PrivKey: "priv2"},
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// getKey 测试中比较私钥，转为string后立即销毁
+func getKey(s KeyStore, key string) (string, error) {
+	buf, err := s.Get(key)
+	if err != nil {
+		return "", err
+	}
+	defer buf.Destroy()
+	return string(buf.Bytes()), nil
+}
+
+// testStore 各实现共用的读写、按商户列出和删除
+func testStore(t *testing.T, s KeyStore) {
+	if _, err := s.Put("mch1", "1", "dot", testInfos[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put("mch2", "2", "dot", testInfos[1:]); err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range testInfos {
+		priv, err := getKey(s, info.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv != info.PrivKey {
+			t.Fatalf("get %s error: %s", info.Address, priv)
+		}
+	}
+	if mchId, ok := s.MchId("addr2"); !ok || mchId != "mch2" {
+		t.Fatalf("mch of addr2 error: %s", mchId)
+	}
+	keys, err := s.List("mch1")
+	if err != nil || len(keys) != 1 || keys[0] != "addr1" {
+		t.Fatalf("list mch1 error: %v %v", keys, err)
+	}
+	if err = s.Delete("addr1"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Has("addr1") || !s.Has("addr2") {
+		t.Fatal("delete error")
+	}
+	if err = s.Delete("addr1"); err != ErrNotFound {
+		t.Fatalf("delete missing key should return ErrNotFound: %v", err)
+	}
+	keys, _ = s.List("")
+	if len(keys) != 1 || keys[0] != "addr2" {
+		t.Fatalf("list all error: %v", keys)
+	}
+}
+
+func TestCsvStore(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	s := NewCsvStore(Options{Path: dir})
+	testStore(t, s)
+	// 重新打开后从文件加载
+	s = NewCsvStore(Options{Path: dir})
+	if priv, err := getKey(s, "addr2"); err != nil || priv != "priv2" {
+		t.Fatalf("reload error: %s %v", priv, err)
+	}
+}
+
+func TestCsvStoreLocked(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	locked := false
+	s := NewCsvStore(Options{Path: dir, Locked: func() bool { return locked }})
+	if _, err := s.Put("mch1", "1", "dot", testInfos); err != nil {
+		t.Fatal(err)
+	}
+	locked = true
+	if _, err := s.Get("addr1"); err != ErrLocked {
+		t.Fatalf("get while locked should return ErrLocked: %v", err)
+	}
+	if _, err := s.Put("mch1", "2", "dot", testInfos); err != ErrLocked {
+		t.Fatalf("put while locked should return ErrLocked: %v", err)
+	}
+}
+
+func TestBoltStore(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	mk, err := util.InitMasterKey(filepath.Join(dir, "masterkey.json"), []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewBoltStore(Options{
+		BoltFile:  filepath.Join(dir, "keys.db"),
+		MasterKey: func() *util.MasterKey { return mk },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	testStore(t, s)
+	if _, err = s.Put("mch2", "3", "dot", testInfos[1:]); err == nil {
+		t.Fatal("put duplicate key should fail")
+	}
+	r, err := s.record("addr2")
+	if err != nil || r.Version != util.KeyFileVersion3 {
+		t.Fatalf("record should be wrapped by master key: %+v %v", r, err)
+	}
+}
+
+func TestCsvStoreReload(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	s := NewCsvStore(Options{Path: dir})
+	if _, err := s.Put("mch1", "1", "dot", testInfos[:1]); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := s.Reload()
+	if err != nil || stats.Loaded != 0 || stats.Files != 2 || stats.Keys != 1 {
+		t.Fatalf("reload unchanged files error: %+v %v", stats, err)
+	}
+	// 其他进程生成的文件，只加载新增的两个文件
+	other := NewCsvStore(Options{Path: dir})
+	if _, err = other.Put("mch2", "2", "dot", testInfos[1:]); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = s.Reload()
+	if err != nil || stats.Loaded != 2 || stats.Keys != 2 {
+		t.Fatalf("reload new files error: %+v %v", stats, err)
+	}
+	os.Remove(filepath.Join(dir, "mch2", "dot_a_usb_2.csv"))
+	os.Remove(filepath.Join(dir, "mch2", "dot_b_usb_2.csv"))
+	stats, err = s.Reload()
+	if err != nil || stats.Removed != 2 || s.Has("addr2") {
+		t.Fatalf("reload removed files error: %+v %v", stats, err)
+	}
+}
+
+func TestCsvStoreWatch(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	s := NewCsvStore(Options{Path: dir})
+	if err := s.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	// 新建商户目录并写入文件，模拟手动拷贝
+	other := NewCsvStore(Options{Path: dir})
+	if _, err := other.Put("mch1", "1", "dot", testInfos); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.Has("addr2") {
+		if time.Now().After(deadline) {
+			t.Fatal("watcher did not load new files")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if priv, err := getKey(s, "addr2"); err != nil || priv != "priv2" {
+		t.Fatalf("get watched key error: %s %v", priv, err)
+	}
+}
@@ -0,0 +1,149 @@
+package keystore
+
+import (
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 文件写入过程中会产生多次事件，停止变化后再加载
+const watchDebounce = 500 * time.Millisecond
+
+type csvWatcher struct {
+	store   *CsvStore
+	fs      *fsnotify.Watcher
+	done    chan struct{}
+	mu      sync.Mutex
+	pending map[string]bool
+	timer   *time.Timer
+}
+
+/*
+Watch 监听私钥目录，新增、修改或删除a、b文件后只加载变化的文件
+	新建的商户目录会自动加入监听；重复调用无效
+*/
+func (s *CsvStore) Watch() error {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.watcher != nil {
+		return nil
+	}
+	if err := os.MkdirAll(s.opts.Path, 0700); err != nil {
+		return err
+	}
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	w := &csvWatcher{
+		store:   s,
+		fs:      fs,
+		done:    make(chan struct{}),
+		pending: make(map[string]bool),
+	}
+	if err = w.addDir(s.opts.Path); err != nil {
+		fs.Close()
+		return err
+	}
+	go w.run()
+	s.watcher = w
+	log.Infof("watch key files in %s", s.opts.Path)
+	return nil
+}
+
+// addDir 监听目录及子目录，返回目录中已有的a、b文件
+func (w *csvWatcher) addDir(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return w.fs.Add(path)
+		}
+		// 监听之前已经拷贝进来的文件
+		if isKeyFile(path) {
+			w.schedule(path)
+		}
+		return nil
+	})
+}
+
+func (w *csvWatcher) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			log.Errorf("watch key files error: %v", err)
+		}
+	}
+}
+
+func (w *csvWatcher) handle(event fsnotify.Event) {
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err = w.addDir(event.Name); err != nil {
+				log.Errorf("watch %s error: %v", event.Name, err)
+			}
+			return
+		}
+	}
+	if isKeyFile(event.Name) && event.Op&fsnotify.Chmod != event.Op {
+		w.schedule(event.Name)
+	}
+}
+
+func (w *csvWatcher) schedule(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending[path] = true
+	if w.timer == nil {
+		w.timer = time.AfterFunc(watchDebounce, w.flush)
+	} else {
+		w.timer.Reset(watchDebounce)
+	}
+}
+
+func (w *csvWatcher) flush() {
+	w.mu.Lock()
+	paths := make([]string, 0, len(w.pending))
+	for path := range w.pending {
+		paths = append(paths, path)
+	}
+	w.pending = make(map[string]bool)
+	w.mu.Unlock()
+	select {
+	case <-w.done:
+		return
+	default:
+	}
+	stats, err := w.store.ReloadFiles(paths...)
+	if err != nil {
+		log.Errorf("reload key files error: %v", err)
+		return
+	}
+	if stats.Loaded > 0 || stats.Removed > 0 {
+		log.Infof("reload key files: loaded %d,removed %d,total %d keys", stats.Loaded, stats.Removed, stats.Keys)
+	}
+}
+
+func (w *csvWatcher) close() error {
+	close(w.done)
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+	return w.fs.Close()
+}
@@ -1,57 +1,245 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
 	"github.com/group-coldwallet/trxsign/redis"
 	"github.com/group-coldwallet/trxsign/routers"
+	"github.com/group-coldwallet/trxsign/services"
 	v1 "github.com/group-coldwallet/trxsign/services/v1"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/watch"
 	log "github.com/sirupsen/logrus"
+	"os"
 	"strings"
 )
 
 var (
-	offline bool
-	nums    int
+	offline     bool
+	nums        int
+	verify      bool
+	mchId       string
+	watchImport string
+	coin        string
 )
 
 func init() {
 	flag.BoolVar(&offline, "o", false, "this server is offline generate key,default is [false]")
 	flag.IntVar(&nums, "n", 10, "generate key numbers,default is [0]")
+	flag.BoolVar(&verify, "verify", false, "verify key files and print a signed report,default is [false]")
+	flag.StringVar(&mchId, "mch", "", "mchId of -verify (empty is all) and -watchImport")
+	flag.StringVar(&watchImport, "watchImport", "", "import watch-only addresses from csv file,header is address,label and tag columns")
+	flag.StringVar(&coin, "coin", "", "coin type of -watchImport,can be empty when only one coin is configured")
 }
 func main() {
 	// 设置日志格式为json
 	log.SetFormatter(&log.TextFormatter{})
+	// 日志输出前脱敏私钥、助记词等内容
+	log.AddHook(util.NewRedactHook())
 	// 初始化配置文件
 	conf.InitConfig()
 	redis.InitRedis(conf.Config.RedisConfig.Addr, conf.Config.RedisConfig.Pwd, conf.Config.RedisConfig.Cluster)
 	flag.Parse()
+	// 启用信封加密时先解锁主密钥，再加载私钥
+	if conf.Config.MasterKeyCfg.Enable {
+		unlockMasterKey()
+	}
+	// 不支持的币种在启动时直接退出
+	registry, err := v1.NewRegistry(conf.Config.GetCoinTypes())
+	if err != nil {
+		log.Fatalf("init sign service error,Err=[%v]", err)
+	}
 	if offline {
 		if nums <= 0 {
 			log.Errorf("generate key numbers is less than zero")
 			return
 		}
-		srv := v1.GetIService()
-		//fmt.Println(conf.Config.CoinType,conf.Config.MchId,conf.Config.OrderId)
-		err := srv.MultiThreadCreateAddrService(nums, conf.Config.CoinType, conf.Config.MchId, conf.Config.OrderId)
-		if err != nil {
-			log.Errorf("generate key error,Err=[%v]", err)
+		for _, coinType := range registry.CoinTypes() {
+			srv, _ := registry.Get(coinType)
+			err := srv.MultiThreadCreateAddrService(nums, coinType, conf.Config.MchId, conf.Config.OrderId)
+			if err != nil {
+				log.Errorf("generate %s key error,Err=[%v]", coinType, err)
+			}
+		}
+		return
+	}
+	if verify {
+		if !verifyKeys(registry) {
+			os.Exit(1)
 		}
 		return
 	}
-	log.Infof("start %s wallet sign service", conf.Config.CoinType)
+	if watchImport != "" {
+		if !importWatchAddresses(registry) {
+			os.Exit(1)
+		}
+		return
+	}
+	if conf.Config.KeyStoreCfg.Watch {
+		watchKeys(registry)
+	}
+	resumeAddressJobs(registry)
+	log.Infof("start %s wallet sign service", strings.Join(registry.CoinTypes(), ","))
 	if !conf.Config.Debug {
 		//gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.Default()
-	path := fmt.Sprintf("%s/%s", strings.ToLower(conf.Config.Version), strings.ToLower(conf.Config.CoinType))
-	group := r.Group(path)
-	// 初始化路由
-	routers.InitRouters(group)
+	for _, coinType := range registry.CoinTypes() {
+		srv, _ := registry.Get(coinType)
+		path := fmt.Sprintf("%s/%s", strings.ToLower(conf.Config.Version), coinType)
+		group := r.Group(path)
+		// 初始化路由
+		routers.InitRouters(group, coinType, srv)
+	}
 	// 启动
 	r.Run(":" + conf.Config.Port)
 }
 
+// watchKeys 监听各币种的私钥目录，失败时仍可通过/admin/reloadKeys加载
+func watchKeys(registry *v1.Registry) {
+	for _, coinType := range registry.CoinTypes() {
+		srv, _ := registry.Get(coinType)
+		w, ok := srv.(interface{ WatchKeys() error })
+		if !ok {
+			continue
+		}
+		if err := w.WatchKeys(); err != nil {
+			log.Errorf("watch %s key files error,Err=[%v]", coinType, err)
+		}
+	}
+}
+
+// resumeAddressJobs 继续上次中断的异步创建地址任务，锁定时需要解锁后重启
+func resumeAddressJobs(registry *v1.Registry) {
+	if services.Locked() {
+		log.Warnf("service is locked,unfinished address jobs will not be resumed")
+		return
+	}
+	for _, coinType := range registry.CoinTypes() {
+		srv, _ := registry.Get(coinType)
+		r, ok := srv.(interface{ ResumeAddressJobs() int })
+		if !ok {
+			continue
+		}
+		if n := r.ResumeAddressJobs(); n > 0 {
+			log.Infof("resume %d %s address jobs", n, coinType)
+		}
+	}
+}
+
+// verifyKeys 核对各币种的私钥文件，报告输出到标准输出，全部通过时返回true
+func verifyKeys(registry *v1.Registry) bool {
+	var reports []*util.VerifyReport
+	ok := true
+	for _, coinType := range registry.CoinTypes() {
+		srv, _ := registry.Get(coinType)
+		v, isVerifier := srv.(interface {
+			VerifyKeys(mchId string) (*util.VerifyReport, error)
+		})
+		if !isVerifier {
+			continue
+		}
+		report, err := v.VerifyKeys(mchId)
+		if err != nil {
+			log.Errorf("verify %s keys error,Err=[%v]", coinType, err)
+			ok = false
+			continue
+		}
+		ok = ok && report.OK()
+		reports = append(reports, report)
+	}
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		log.Errorf("marshal verify report error,Err=[%v]", err)
+		return false
+	}
+	fmt.Println(string(data))
+	return ok
+}
+
+/*
+importWatchAddresses 从csv文件导入观察地址，结果输出到标准输出，全部成功时返回true
+	服务已启动时需调用/admin/reloadKeys或重启后生效
+*/
+func importWatchAddresses(registry *v1.Registry) bool {
+	if mchId == "" {
+		log.Errorf("-mch is required when import watch addresses")
+		return false
+	}
+	coinType := coin
+	if coinType == "" {
+		if coinTypes := registry.CoinTypes(); len(coinTypes) == 1 {
+			coinType = coinTypes[0]
+		} else {
+			log.Errorf("-coin is required when more than one coin is configured")
+			return false
+		}
+	}
+	srv, ok := registry.Get(coinType)
+	if !ok {
+		log.Errorf("coin %s is not configured", coinType)
+		return false
+	}
+	importer, ok := srv.(interface {
+		ImportWatchAddresses(mchId string, params []model.WatchAddressParams) (*watch.ImportResult, error)
+	})
+	if !ok {
+		log.Errorf("%s does not support watch addresses", coinType)
+		return false
+	}
+	list, err := watch.ReadImportFile(watchImport)
+	if err != nil {
+		log.Errorf("read watch import file error,Err=[%v]", err)
+		return false
+	}
+	params := make([]model.WatchAddressParams, 0, len(list))
+	for _, a := range list {
+		params = append(params, model.WatchAddressParams{Address: a.Address, Label: a.Label, Tags: a.Tags})
+	}
+	result, err := importer.ImportWatchAddresses(mchId, params)
+	if err != nil {
+		log.Errorf("import %s watch addresses error,Err=[%v]", coinType, err)
+		return false
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		log.Errorf("marshal import result error,Err=[%v]", err)
+		return false
+	}
+	fmt.Println(string(data))
+	log.Infof("watch addresses are saved,call /%s/%s/admin/reloadKeys or restart the service to use them", strings.ToLower(conf.Config.Version), coinType)
+	return len(result.Failures) == 0
+}
+
+/*
+unlockMasterKey 读取口令并解锁主密钥
+	运行中无法再解锁，读取口令或解锁失败时直接退出
+*/
+func unlockMasterKey() {
+	cfg := conf.Config.MasterKeyCfg
+	services.RequireMasterKey()
+	env := cfg.Env
+	if env == "" {
+		env = "TRXSIGN_MASTER_KEY"
+	}
+	fd := cfg.Fd
+	if fd == 0 {
+		fd = 3
+	}
+	passphrase, err := util.ReadPassphrase(cfg.Source, env, fd)
+	if err != nil {
+		log.Fatalf("read master key passphrase error,Err=[%v]", err)
+	}
+	mk, err := util.UnlockMasterKey(conf.Config.GetMasterKeyFile(), passphrase)
+	if err != nil {
+		log.Fatalf("unlock master key error,Err=[%v]", err)
+	}
+	services.SetMasterKey(mk)
+	log.Info("master key unlocked")
+}
+
 // CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -ldflags "-s -w" -o=dot-sign
@@ -1,11 +1,11 @@
 package model
 
 type ARSignParams struct {
-	LastTx      string `json:"last_tx"`
+	LastTx      string `json:"last_tx"` //tx_anchor或账户的last_tx
 	FromAddress string `json:"from_address"`
 	ToAddress   string `json:"to_address"`
-	Fee         string `json:"fee"`
-	Amount      string `json:"amount"`
+	Fee         string `json:"fee"`    //winston
+	Amount      string `json:"amount"` //winston
 	//Memo 				string			`json:"memo"`
 }
 
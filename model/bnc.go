@@ -4,9 +4,11 @@ type BncTransferParams struct {
 	ReqBaseParams
 	FromAddress string `json:"from_address"`
 	ToAddress   string `json:"to_address"`
-	Amount      string `json:"amount"`
+	Amount      string `json:"amount"` //单位为最小单位，1 BNB = 100000000
+	Denom       string `json:"denom"`  //BEP2资产symbol，如BUSD-BD1，为空时为BNB
+	Memo        string `json:"memo"`   //交易所充值地址通常需要memo
 	//cold sign need
-	Nonce              uint64 `json:"nonce"`
-	SpecVersion        uint32 `json:"spec_version"`
-	TransactionVersion uint32 `json:"transaction_version"`
+	ChainId       string `json:"chain_id"` //为空时使用配置的chainId
+	AccountNumber int64  `json:"account_number"`
+	Sequence      int64  `json:"sequence"`
 }
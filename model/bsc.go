@@ -1,23 +1,8 @@
 package model
 
-type BscTransferParams struct {
-	FromAddress     string `json:"from_address"`
-	ToAddress       string `json:"to_address"`
-	Amount          string `json:"amount"`
-	IsCollect       int    `json:"is_collect"`
-	Token           string `json:"token"`
-	ContractAddress string `json:"contract_address"`
-}
-
-type BscSignParams struct {
-	FromAddress     string `json:"from_address"`
-	ToAddress       string `json:"to_address"`
-	Amount          string `json:"amount"`
-	ContractAddress string `json:"contract_address"`
-	Nonce           int64  `json:"nonce"`
-}
-
-type BscNonceData struct {
-	Txid  string `json:"txid"`
-	Nonce int64  `json:"nonce"`
-}
+// bsc使用evm通用服务
+type (
+	BscTransferParams = EvmTransferParams
+	BscSignParams     = EvmSignParams
+	BscNonceData      = EvmNonceData
+)
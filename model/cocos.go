@@ -13,3 +13,23 @@ type CocosTransferParams struct {
 	AssetId      string `json:"asset_id"` // asset_id
 	AssetDecimal int32  `json:"asset_decimal"`
 }
+
+/*
+CocosSignParams 离线签名参数
+	from/to为账户id(1.2.x)，public_key为from账户的active公钥
+	to_memo_key不为空时使用memo_key对应的私钥加密memo，否则为明文memo
+*/
+type CocosSignParams struct {
+	FromAccountId   string          `json:"from_account_id"`
+	ToAccountId     string          `json:"to_account_id"`
+	PublicKey       string          `json:"public_key"`
+	Amount          decimal.Decimal `json:"amount"`
+	AssetId         string          `json:"asset_id"`
+	AssetDecimal    int32           `json:"asset_decimal"`
+	Memo            string          `json:"memo"`
+	MemoKey         string          `json:"memo_key"`
+	ToMemoKey       string          `json:"to_memo_key"`
+	HeadBlockNumber uint32          `json:"head_block_number"`
+	HeadBlockId     string          `json:"head_block_id"`
+	ChainId         string          `json:"chain_id"`
+}
@@ -25,11 +25,16 @@ type DipTransferParams struct {
 
 type DipSignParams struct {
 	ReqBaseParams
-	FromAddress string          `json:"fromaddress"` //发送地址
-	ToAddress   string          `json:"toaddress"`   //接收地址
-	ToAmount    decimal.Decimal `json:"toamount"`    //接收金额
-	Memo        string          `json:"memo"`        //memo
-	ChainID     string          `json:"chain_id"`
+	FromAddress   string          `json:"fromaddress"`    //发送地址
+	ToAddress     string          `json:"toaddress"`      //接收地址
+	ToAmount      decimal.Decimal `json:"toamount"`       //接收金额，单位为denom的最小单位
+	Memo          string          `json:"memo"`           //memo
+	ChainID       string          `json:"chain_id"`       //为空时使用配置的chainId
+	AccountNumber uint64          `json:"account_number"` //离线签名需要传入
+	Sequence      uint64          `json:"sequence"`       //离线签名需要传入
+	Denom         string          `json:"denom"`          //为空时为pdip
+	Gas           uint64          `json:"gas"`            //为空时使用配置
+	Fee           int64           `json:"fee"`            //为空时使用配置，单位为pdip
 }
 
 type (
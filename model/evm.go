@@ -0,0 +1,35 @@
+package model
+
+/*
+EvmTransferParams evm系列链热钱包出账参数
+	contract_address不为空时为代币转账，amount均为最小单位
+	is_collect为1时为归集，主币转出余额扣除手续费后的全部金额，代币转出全部余额
+*/
+type EvmTransferParams struct {
+	FromAddress     string `json:"from_address"`
+	ToAddress       string `json:"to_address"`
+	Amount          string `json:"amount"`
+	IsCollect       int    `json:"is_collect"`
+	Token           string `json:"token"`
+	ContractAddress string `json:"contract_address"`
+}
+
+/*
+EvmSignParams evm系列链离线签名参数
+	gas_price为空时使用配置，eip1559交易中gas_price为max fee，max_priority_fee为小费
+*/
+type EvmSignParams struct {
+	FromAddress     string `json:"from_address"`
+	ToAddress       string `json:"to_address"`
+	Amount          string `json:"amount"`
+	ContractAddress string `json:"contract_address"`
+	Nonce           int64  `json:"nonce"`
+	GasPrice        string `json:"gas_price"`
+	GasLimit        int64  `json:"gas_limit"`
+	MaxPriorityFee  string `json:"max_priority_fee"`
+}
+
+type EvmNonceData struct {
+	Txid  string `json:"txid"`
+	Nonce int64  `json:"nonce"`
+}
@@ -1,16 +1,27 @@
 package model
 
+/*
+FioTransferParams 热钱包转账参数
+	from_address为转出公钥，to_address可以是FIO公钥或fio地址(如alice@wallet)
+	amount和max_fee单位为SUF，max_fee为空时从节点获取
+*/
 type FioTransferParams struct {
 	FromAddress string `json:"from_address"`
 	ToAddress   string `json:"to_address"`
 	Amount      string `json:"amount"`
+	MaxFee      string `json:"max_fee"`
 	//Memo 		string `json:"memo"`
 }
 
+/*
+FioSignParams 离线签名参数
+	head_block_id用于计算TAPOS，chain_id为空时使用配置，max_fee为空时使用配置
+*/
 type FioSignParams struct {
 	FromAddress string `json:"from_address"`
 	ToAddress   string `json:"to_address"`
 	Amount      string `json:"amount"`
+	MaxFee      string `json:"max_fee"`
 	HeadBlockId string `json:"head_block_id"`
 	ChainId     string `json:"chain_id"`
 }
@@ -30,6 +30,9 @@ type ReqCreateAddressParamsV2 struct {
 	Count int `json:"count,omitempty"`
 	// 本次生成地址对应的编号，如：trx_usb_20220601001
 	BatchNo string `json:"batchNo,omitempty"`
+	// 开启请求签名时必传，batchNo作为防重放的nonce
+	Sign       string `json:"sign,omitempty"`
+	CreateTime string `json:"createTime,omitempty"`
 }
 
 type RespCreateAddressParams struct {
@@ -43,6 +46,15 @@ type RespCreateAddressParams struct {
 	Address []string `json:"address"`
 }
 
+// ReqAddrJobParams 查询异步创建地址任务，Limit默认且最多为10000，Stream为true时按行输出全部地址
+type ReqAddrJobParams struct {
+	Mch    string `json:"mch"`
+	JobId  string `json:"jobId"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	Stream bool   `json:"stream"`
+}
+
 //==========================================================//
 
 type ReqSignParams struct {
@@ -64,6 +76,43 @@ type ReqGetBalanceParams struct {
 	Token           string      `json:"token"`            // 	token的名字
 	ContractAddress string      `json:"contract_address"` //合约地址
 	Params          interface{} `json:"params"`           //特殊参数（如果有特殊参数，传入到这里面）
+	// 批量查询：addresses和mch_id的观察地址(可按tags过滤)合并查询
+	Addresses []string          `json:"addresses"`
+	MchId     string            `json:"mch_id"`
+	Tags      map[string]string `json:"tags"`
+}
+
+// BalanceResult 批量查询余额的单个结果，查询失败时Error不为空
+type BalanceResult struct {
+	Address string            `json:"address"`
+	Label   string            `json:"label,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	Balance interface{}       `json:"balance,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+//------------------watch address-------------------
+type WatchAddressParams struct {
+	Address string            `json:"address"`
+	Label   string            `json:"label"`
+	Tags    map[string]string `json:"tags"` //如customerId、purpose
+}
+
+type ReqWatchImportParams struct {
+	MchId     string               `json:"mchId"`
+	Addresses []WatchAddressParams `json:"addresses"`
+	// 请求签名，nonce防重放
+	Nonce      string `json:"nonce"`
+	CreateTime string `json:"createTime"`
+	Sign       string `json:"sign"`
+}
+
+type ReqWatchListParams struct {
+	MchId      string            `json:"mchId"`
+	Tags       map[string]string `json:"tags"`
+	Nonce      string            `json:"nonce"`
+	CreateTime string            `json:"createTime"`
+	Sign       string            `json:"sign"`
 }
 
 //------------------valid address-------------------
@@ -2,7 +2,19 @@ package model
 
 type NearTransferParams struct {
 	ReqBaseParams
-	FromAddress string `json:"from_address"`
-	ToAddress   string `json:"to_address"`
-	Amount      string `json:"amount"`
+	FromAddress     string `json:"from_address"`
+	ToAddress       string `json:"to_address"`
+	Amount          string `json:"amount"`
+	ContractAddress string `json:"contract_address"` //NEP-141 token合约账户，为空时为near转账
+}
+
+type NearSignParams struct {
+	ReqBaseParams
+	FromAddress     string `json:"from_address"`
+	ToAddress       string `json:"to_address"`
+	Amount          string `json:"amount"`
+	ContractAddress string `json:"contract_address"`
+	Nonce           uint64 `json:"nonce"`           //access key当前nonce+1
+	BlockHash       string `json:"block_hash"`      //base58编码的近期区块hash
+	StorageDeposit  string `json:"storage_deposit"` //接收账户未在token合约注册时传入注册押金(yoctoNEAR)，为空则不注册
 }
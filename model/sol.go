@@ -7,9 +7,12 @@ import (
 )
 
 type SolTransferParams struct {
-	FromAddress string `json:"from_address"`
-	ToAddress   string `json:"to_address"`
-	Amount      string `json:"amount"`
+	FromAddress     string `json:"from_address"`
+	ToAddress       string `json:"to_address"`
+	Amount          string `json:"amount"`
+	NonceAccount    string `json:"nonce_account"`    //durable nonce账户，为空时使用最新区块hash
+	ContractAddress string `json:"contract_address"` //spl token的mint地址，为空时为sol转账
+	CreateAta       bool   `json:"create_ata"`       //接收地址的关联token账户不存在时是否在同一笔交易中创建
 }
 
 type SolSignParams struct {
@@ -17,7 +20,12 @@ type SolSignParams struct {
 	FromAddress     string `json:"from_address"`
 	ToAddress       string `json:"to_address"`
 	Amount          string `json:"amount"`
-	RecentBlockHash string `json:"recent_block_hash"`
+	RecentBlockHash string `json:"recent_block_hash"` //使用durable nonce时传nonce账户中的nonce值
+	NonceAccount    string `json:"nonce_account"`
+	NonceAuthority  string `json:"nonce_authority"`  //为空时默认为from_address
+	ContractAddress string `json:"contract_address"` //spl token的mint地址，为空时为sol转账
+	Decimals        *uint8 `json:"decimals"`         //mint精度，token转账必传，可以为0
+	CreateAta       bool   `json:"create_ata"`
 }
 
 type SolRecentBlockHash struct {
@@ -38,3 +46,53 @@ func DecodeSolRecentBlockHash(data []byte) (string, error) {
 	}
 	return sbh.Value.RecentBlockHash, nil
 }
+
+type SolBalance struct {
+	Value uint64 `json:"value"`
+}
+
+type SolAccountInfo struct {
+	Value *SolAccountInfoValue `json:"value"`
+}
+type SolAccountInfoValue struct {
+	Data     []string `json:"data"` // [data,encoding]
+	Owner    string   `json:"owner"`
+	Lamports uint64   `json:"lamports"`
+}
+
+type SolSignatureStatuses struct {
+	Value []*SolSignatureStatus `json:"value"`
+}
+type SolSignatureStatus struct {
+	Slot               uint64      `json:"slot"`
+	Confirmations      *uint64     `json:"confirmations"`
+	Err                interface{} `json:"err"`
+	ConfirmationStatus string      `json:"confirmationStatus"`
+}
+
+type SolTokenAmount struct {
+	Amount         string `json:"amount"`
+	Decimals       uint8  `json:"decimals"`
+	UiAmountString string `json:"uiAmountString"`
+}
+
+type SolTokenAccountBalance struct {
+	Value SolTokenAmount `json:"value"`
+}
+
+type SolTokenAccounts struct {
+	Value []struct {
+		Pubkey  string `json:"pubkey"`
+		Account struct {
+			Data struct {
+				Parsed struct {
+					Info struct {
+						Mint        string         `json:"mint"`
+						Owner       string         `json:"owner"`
+						TokenAmount SolTokenAmount `json:"tokenAmount"`
+					} `json:"info"`
+				} `json:"parsed"`
+			} `json:"data"`
+		} `json:"account"`
+	} `json:"value"`
+}
@@ -0,0 +1,28 @@
+package model
+
+/*
+substrate系列链通用参数
+	与Dot/Ksm/Cring/Fis/Ori/Pcx各自的TransferParams、ColdParams字段兼容
+*/
+type SubstrateTransferParams struct {
+	ReqBaseParams
+	FromAddress string `json:"from_address"`
+	ToAddress   string `json:"to_address"`
+	Amount      string `json:"amount"`
+	Tip         string `json:"tip"`
+}
+
+type SubstrateColdParams struct {
+	ReqBaseParams
+	FromAddress        string `json:"from_address"`
+	ToAddress          string `json:"to_address"`
+	Amount             string `json:"amount"`
+	Tip                string `json:"tip"`
+	Nonce              uint64 `json:"nonce"`
+	SpecVersion        uint32 `json:"spec_version"`
+	TransactionVersion uint32 `json:"transaction_version"`
+	GenesisHash        string `json:"genesis_hash"`
+	BlockHash          string `json:"block_hash"`   //mortal era时为block_number对应的区块hash，为空则使用immortal era
+	BlockNumber        uint64 `json:"block_number"` //mortal era的出生区块高度
+	EraPeriod          uint64 `json:"era_period"`   //为0时使用配置中的eraPeriod
+}
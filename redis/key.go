@@ -6,8 +6,23 @@ import (
 
 const (
 	BroadcastOuterOrderNoKey = "broadcast_order"
+	HntAddressLockKey        = "hnt_address_lock"
+	RequestNonceKey          = "request_nonce"
+	CreateAddrBatchKey       = "create_addr_batch"
 )
 
 func GetBroadcastOuterOrderNoKey(outerOrderNo string) string {
 	return fmt.Sprintf("%s_%s", BroadcastOuterOrderNoKey, outerOrderNo)
 }
+
+func GetHntAddressLockKey(address string) string {
+	return fmt.Sprintf("%s_%s", HntAddressLockKey, address)
+}
+
+func GetRequestNonceKey(mchId, nonce string) string {
+	return fmt.Sprintf("%s_%s_%s", RequestNonceKey, mchId, nonce)
+}
+
+func GetCreateAddrBatchKey(coinType, mchId, batchNo string) string {
+	return fmt.Sprintf("%s_%s_%s_%s", CreateAddrBatchKey, coinType, mchId, batchNo)
+}
@@ -0,0 +1,132 @@
+package routers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/group-coldwallet/trxsign/addrjob"
+	"github.com/group-coldwallet/trxsign/model"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+// addressJobService 异步创建地址，由v1.BaseService实现
+type addressJobService interface {
+	SubmitAddressJob(req *model.ReqCreateAddressParamsV2) (*addrjob.JobStatus, error)
+	AddressJobStatus(mchId, jobId string) (*addrjob.JobStatus, error)
+	AddressJobAddresses(mchId, jobId string, offset, limit int) ([]string, int, error)
+	CancelAddressJob(mchId, jobId string) error
+}
+
+const (
+	// 单个任务的最大地址数，与/createAddr一致
+	maxAddressJobCount = 50000
+	// 分页下载时每页的最大地址数
+	maxAddressJobPage = 10000
+)
+
+// SubmitAddressJob 提交创建地址任务，立即返回jobId
+func SubmitAddressJob(srv addressJobService, coinType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.ReqCreateAddressParamsV2
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(200, gin.H{"code": 1, "message": "Parse create address job post data error"})
+			return
+		}
+		if req.Mch == "" {
+			c.JSON(200, gin.H{"code": 1, "message": "Mch id is null"})
+			return
+		}
+		if req.Count < 0 || req.Count > maxAddressJobCount {
+			c.JSON(200, gin.H{"code": 1, "message": fmt.Sprintf("Create address nums must be less than %d,Num=%d", maxAddressJobCount, req.Count)})
+			return
+		}
+		if !strings.EqualFold(req.CoinCode, coinType) {
+			c.JSON(200, gin.H{"code": 1, "message": fmt.Sprintf("Coin name is not %s", coinType)})
+			return
+		}
+		status, err := srv.SubmitAddressJob(&req)
+		if err != nil {
+			log.Errorf("submit create address job error: %v", err)
+			c.JSON(200, gin.H{"code": 1, "message": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"code": 0, "message": "success", "data": status})
+	}
+}
+
+// AddressJobStatus 任务进度、预计剩余时间和失败数
+func AddressJobStatus(srv addressJobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.ReqAddrJobParams
+		if err := c.BindJSON(&req); err != nil || req.Mch == "" || req.JobId == "" {
+			c.JSON(200, gin.H{"code": 1, "message": "mch or jobId is null"})
+			return
+		}
+		status, err := srv.AddressJobStatus(req.Mch, req.JobId)
+		if err != nil {
+			c.JSON(200, gin.H{"code": 1, "message": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"code": 0, "message": "success", "data": status})
+	}
+}
+
+// CancelAddressJob 取消任务，已保存的地址仍可下载
+func CancelAddressJob(srv addressJobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.ReqAddrJobParams
+		if err := c.BindJSON(&req); err != nil || req.Mch == "" || req.JobId == "" {
+			c.JSON(200, gin.H{"code": 1, "message": "mch or jobId is null"})
+			return
+		}
+		if err := srv.CancelAddressJob(req.Mch, req.JobId); err != nil {
+			c.JSON(200, gin.H{"code": 1, "message": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"code": 0, "message": "success"})
+	}
+}
+
+/*
+AddressJobAddresses 下载任务已生成的地址
+	按offset、limit分页，返回total为已生成的地址数；stream为true时每行一个地址输出全部地址
+*/
+func AddressJobAddresses(srv addressJobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.ReqAddrJobParams
+		if err := c.BindJSON(&req); err != nil || req.Mch == "" || req.JobId == "" {
+			c.JSON(200, gin.H{"code": 1, "message": "mch or jobId is null"})
+			return
+		}
+		if req.Stream {
+			addresses, _, err := srv.AddressJobAddresses(req.Mch, req.JobId, 0, 0)
+			if err != nil {
+				c.JSON(200, gin.H{"code": 1, "message": err.Error()})
+				return
+			}
+			c.Header("Content-Type", "text/plain; charset=utf-8")
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.txt", req.JobId))
+			c.Status(200)
+			for _, address := range addresses {
+				if _, err = c.Writer.WriteString(address + "\n"); err != nil {
+					return
+				}
+			}
+			return
+		}
+		if req.Limit <= 0 || req.Limit > maxAddressJobPage {
+			req.Limit = maxAddressJobPage
+		}
+		addresses, total, err := srv.AddressJobAddresses(req.Mch, req.JobId, req.Offset, req.Limit)
+		if err != nil {
+			c.JSON(200, gin.H{"code": 1, "message": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"code": 0, "message": "success", "data": gin.H{
+			"jobId":     req.JobId,
+			"offset":    req.Offset,
+			"total":     total,
+			"addresses": addresses,
+		}})
+	}
+}
@@ -0,0 +1,84 @@
+package routers
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/keystore"
+	"github.com/group-coldwallet/trxsign/util"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	adminTokenHeader    = "X-Admin-Token"
+	adminTokenMinLength = 16
+)
+
+// adminEnabled 配置了管理令牌时才注册管理接口，令牌过短时直接退出
+func adminEnabled() bool {
+	token := conf.Config.AdminCfg.Token
+	if token == "" {
+		return false
+	}
+	if len(token) < adminTokenMinLength {
+		log.Fatalf("admin token must be at least %d characters", adminTokenMinLength)
+	}
+	return true
+}
+
+/*
+AdminAuth 管理接口校验
+	使用[admin]token，不依赖商户api key和basic auth的开关，未配置时拒绝所有请求
+*/
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := conf.Config.AdminCfg.Token
+		got := c.GetHeader(adminTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			log.Errorf("admin token验证不通过: %s", c.Request.URL.Path)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": "401", "message": "Unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// keyReloader 重新加载私钥，由services.Service实现
+type keyReloader interface {
+	ReloadKeys() (keystore.ReloadStats, error)
+}
+
+// ReloadKeys 增量加载新增或修改的私钥文件，返回文件数和私钥数
+func ReloadKeys(reloader keyReloader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats, err := reloader.ReloadKeys()
+		if err != nil {
+			log.Errorf("reload keys error: %v", err)
+			c.JSON(200, gin.H{"code": 1, "message": err.Error()})
+			return
+		}
+		log.Infof("reload keys: loaded %d,removed %d,total %d keys", stats.Loaded, stats.Removed, stats.Keys)
+		c.JSON(200, gin.H{"code": 0, "message": "success", "data": stats})
+	}
+}
+
+// keyVerifier 核对私钥文件，由v1.BaseService实现
+type keyVerifier interface {
+	VerifyKeys(mchId string) (*util.VerifyReport, error)
+}
+
+// VerifyKeys 核对私钥文件，mchId为空时核对所有商户，返回签名后的报告
+func VerifyKeys(verifier keyVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := verifier.VerifyKeys(c.Query("mchId"))
+		if err != nil {
+			log.Errorf("verify keys error: %v", err)
+			c.JSON(200, gin.H{"code": 1, "message": err.Error()})
+			return
+		}
+		log.Infof("verify keys: %d batches,%d addresses,%d verified,%d failed,%d duplicates",
+			len(report.Batches), report.Addresses, report.Verified, report.Failed, len(report.Duplicates))
+		c.JSON(200, gin.H{"code": 0, "message": "success", "data": report})
+	}
+}
@@ -0,0 +1,151 @@
+package routers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/group-coldwallet/trxsign/apikey"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/services"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	apiKeyHeader          = "X-Api-Key"
+	apiKeyDefaultFile     = "./conf/apikeys.json"
+	apiKeyMchIdContextKey = "apiKeyMchId"
+)
+
+var (
+	apiKeyOnce  sync.Once
+	apiKeyStore *apikey.Store
+)
+
+// keyOwner 查询地址或公钥所属的商户，由services.Service实现
+type keyOwner interface {
+	GetMchIdByKey(key string) (string, bool)
+}
+
+// initApiKeyStore 启动时加载key文件，加载失败直接退出
+func initApiKeyStore() {
+	apiKeyOnce.Do(func() {
+		file := conf.Config.ApiKeyCfg.File
+		if file == "" {
+			file = apiKeyDefaultFile
+		}
+		store, err := apikey.Open(file)
+		if err != nil {
+			log.Fatalf("load api key file error,Err=[%v]", err)
+		}
+		apiKeyStore = store
+	})
+}
+
+/*
+ApiKeyAuth 商户api key校验
+	key需要有接口对应的scope和币种权限，请求中的mchId(创建地址为mch)必须为key绑定的商户
+	签名和出账时，请求中出现的本服务管理的地址或公钥必须属于该商户(接收方字段除外)
+*/
+func ApiKeyAuth(scope, coinType string, owner keyOwner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !conf.Config.ApiKeyCfg.Enable {
+			c.Next()
+			return
+		}
+		key, err := apiKeyStore.Authenticate(c.GetHeader(apiKeyHeader))
+		if err != nil {
+			apiKeyFail(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if !key.HasScope(scope) {
+			apiKeyFail(c, http.StatusForbidden, fmt.Sprintf("api key %s has no %s permission", key.Id, scope))
+			return
+		}
+		if !key.AllowCoin(coinType) {
+			apiKeyFail(c, http.StatusForbidden, fmt.Sprintf("api key %s has no %s permission", key.Id, coinType))
+			return
+		}
+		if scope != apikey.ScopeGetBalance {
+			_, params, err := requestParams(c)
+			if err != nil {
+				apiKeyFail(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			if mchId := stringParam(params, "mchId", "mch"); mchId != key.MchId {
+				apiKeyFail(c, http.StatusForbidden, fmt.Sprintf("api key %s can not be used for mchId %s", key.Id, mchId))
+				return
+			}
+			if scope == apikey.ScopeSign || scope == apikey.ScopeTransfer {
+				if err = checkKeyOwner(owner, key.MchId, params); err != nil {
+					apiKeyFail(c, http.StatusForbidden, err.Error())
+					return
+				}
+			}
+		} else {
+			// 批量查询观察地址时只能查询key绑定的商户
+			_, params, err := requestParams(c)
+			if err != nil {
+				apiKeyFail(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			if mchId := stringParam(params, "mch_id"); mchId != "" && mchId != key.MchId {
+				apiKeyFail(c, http.StatusForbidden, fmt.Sprintf("api key %s can not be used for mchId %s", key.Id, mchId))
+				return
+			}
+		}
+		c.Set(apiKeyMchIdContextKey, key.MchId)
+		c.Next()
+	}
+}
+
+// receiverFields 接收方字段(小写)，与model中出账参数的json字段一致，不检查归属
+var receiverFields = map[string]bool{
+	"to":            true,
+	"to_address":    true,
+	"toaddress":     true, //toAddress、toaddress
+	"toaddr":        true,
+	"toaccount":     true,
+	"to_account_id": true,
+	"to_memo_key":   true,
+}
+
+/*
+checkKeyOwner 递归检查请求中的字符串
+	按币种服务的KeyNormalizer转换后查询，与签名时取私钥的方式一致
+	是本服务管理的地址或公钥时必须属于mchId；receiverFields中的接收方字段不检查
+*/
+func checkKeyOwner(owner keyOwner, mchId string, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for field, item := range v {
+			if receiverFields[strings.ToLower(field)] {
+				continue
+			}
+			if err := checkKeyOwner(owner, mchId, item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := checkKeyOwner(owner, mchId, item); err != nil {
+				return err
+			}
+		}
+	case string:
+		key := v
+		if n, ok := owner.(services.KeyNormalizer); ok {
+			key = n.NormalizeKey(v)
+		}
+		if keyMchId, ok := owner.GetMchIdByKey(key); ok && keyMchId != mchId {
+			return fmt.Errorf("%s does not belong to mchId %s", v, mchId)
+		}
+	}
+	return nil
+}
+
+func apiKeyFail(c *gin.Context, status int, message string) {
+	log.Errorf("api key验证不通过: %s", message)
+	c.AbortWithStatusJSON(status, gin.H{"code": fmt.Sprintf("%d", status), "message": message})
+}
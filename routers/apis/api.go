@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/group-coldwallet/trxsign/conf"
 	v1 "github.com/group-coldwallet/trxsign/routers/apis/v1"
+	"github.com/group-coldwallet/trxsign/services"
 )
 
 type Apis interface {
@@ -14,19 +15,19 @@ type Apis interface {
 	ValidAddress(c *gin.Context)
 }
 
-func CreateApis() Apis {
+func CreateApis(coinType string, srv services.IService) Apis {
 	var apis Apis
 	switch conf.Config.Version {
 	case "v1":
-		if conf.Config.CoinType == "gxc" {
+		if coinType == "gxc" {
 			// 由于之前版本gxc已经上线，所以单独抽离出来
-			apis = v1.NewGxcApi()
+			apis = v1.NewGxcApi(srv)
 		} else {
-			apis = v1.NewBaseApi()
+			apis = v1.NewBaseApi(coinType, srv)
 		}
 	default:
 		//默认使用v1版本
-		apis = v1.NewBaseApi()
+		apis = v1.NewBaseApi(coinType, srv)
 	}
 	return apis
 }
@@ -3,16 +3,16 @@ package v1
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/group-coldwallet/trxsign/conf"
 	"github.com/group-coldwallet/trxsign/model"
 	"github.com/group-coldwallet/trxsign/services"
-	v1 "github.com/group-coldwallet/trxsign/services/v1"
+	"github.com/group-coldwallet/trxsign/watch"
 	log "github.com/sirupsen/logrus"
 	"strings"
 )
 
 type BaseApi struct {
-	Srv services.IService
+	Srv      services.IService
+	CoinType string
 }
 
 func (ba *BaseApi) ValidAddress(c *gin.Context) {
@@ -66,8 +66,12 @@ func (ba *BaseApi) GetBalance(c *gin.Context) {
 		respFailDataReturn(c, "coin name  is null")
 		return
 	}
-	if strings.ToLower(req.CoinName) != strings.ToLower(conf.Config.CoinType) {
-		respFailDataReturn(c, fmt.Sprintf("Coin name is not %s", strings.ToLower(conf.Config.CoinType)))
+	if strings.ToLower(req.CoinName) != strings.ToLower(ba.CoinType) {
+		respFailDataReturn(c, fmt.Sprintf("Coin name is not %s", strings.ToLower(ba.CoinType)))
+		return
+	}
+	if len(req.Addresses) > 0 || req.MchId != "" {
+		ba.getBalances(c, &req)
 		return
 	}
 	if req.Address == "" {
@@ -88,9 +92,69 @@ func (ba *BaseApi) GetBalance(c *gin.Context) {
 	})
 }
 
-func NewBaseApi() *BaseApi {
+// 批量查询余额的最大地址数
+const maxBalanceBatch = 500
+
+// watchLister 观察地址，由v1.BaseService实现
+type watchLister interface {
+	ListWatchAddresses(mchId string, tags map[string]string) []*watch.Address
+	WatchStore() *watch.Store
+}
+
+/*
+getBalances 批量查询余额
+	查询addresses和mch_id下的观察地址，观察地址返回label和tags；单个地址失败不影响其他地址
+*/
+func (ba *BaseApi) getBalances(c *gin.Context, req *model.ReqGetBalanceParams) {
+	wl, _ := ba.Srv.(watchLister)
+	var results []*model.BalanceResult
+	seen := make(map[string]bool)
+	add := func(address string) {
+		if address == "" || seen[address] {
+			return
+		}
+		seen[address] = true
+		r := &model.BalanceResult{Address: address}
+		if wl != nil && wl.WatchStore() != nil {
+			if a, ok := wl.WatchStore().Get(address); ok {
+				r.Label, r.Tags = a.Label, a.Tags
+			}
+		}
+		results = append(results, r)
+	}
+	for _, address := range req.Addresses {
+		add(address)
+	}
+	if req.MchId != "" && wl != nil {
+		for _, a := range wl.ListWatchAddresses(req.MchId, req.Tags) {
+			add(a.Address)
+		}
+	}
+	if len(results) > maxBalanceBatch {
+		respFailDataReturn(c, fmt.Sprintf("get balance addresses must be less than %d,Num=%d", maxBalanceBatch, len(results)))
+		return
+	}
+	for _, r := range results {
+		single := *req
+		single.Address, single.Addresses, single.MchId, single.Tags = r.Address, nil, "", nil
+		balance, err := ba.Srv.GetBalance(&single)
+		if err != nil {
+			r.Error = err.Error()
+			continue
+		}
+		r.Balance = balance
+	}
+	c.JSON(200, gin.H{
+		"code":    0,
+		"message": "ok",
+		"data":    results,
+	})
+}
+
+func NewBaseApi(coinType string, srv services.IService) *BaseApi {
 	ba := new(BaseApi)
-	ba.Srv = v1.GetIService()
+	ba.Srv = srv
+	ba.CoinType = coinType
 	return ba
 }
 func (ba *BaseApi) CreateAddress(c *gin.Context) {
@@ -121,8 +185,8 @@ func (ba *BaseApi) CreateAddress(c *gin.Context) {
 		return
 	}
 
-	if strings.ToLower(req.CoinCode) != strings.ToLower(conf.Config.CoinType) {
-		respFailDataReturn(c, fmt.Sprintf("Coin name is not %s", strings.ToLower(conf.Config.CoinType)))
+	if strings.ToLower(req.CoinCode) != strings.ToLower(ba.CoinType) {
+		respFailDataReturn(c, fmt.Sprintf("Coin name is not %s", strings.ToLower(ba.CoinType)))
 		return
 	}
 
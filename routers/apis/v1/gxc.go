@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
 )
 
 type GxcApi struct {
@@ -11,12 +12,12 @@ type GxcApi struct {
 }
 
 func (ga *GxcApi) GetBalance(c *gin.Context) {
-	panic("implement me")
+	ga.BaseApi.GetBalance(c)
 }
 
-func NewGxcApi() *GxcApi {
+func NewGxcApi(srv services.IService) *GxcApi {
 	ga := new(GxcApi)
-	ga.BaseApi = NewBaseApi()
+	ga.BaseApi = NewBaseApi("gxc", srv)
 	return ga
 }
 
@@ -0,0 +1,55 @@
+package routers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+)
+
+const (
+	requestBodyKey   = "requestBody"
+	requestParamsKey = "requestParams"
+)
+
+/*
+requestParams 读取并解析json请求体
+	结果缓存在context中，多个中间件只解析一次；body重新放回Request，后续handler可以再次读取
+*/
+func requestParams(c *gin.Context) ([]byte, map[string]interface{}, error) {
+	if params, ok := c.Get(requestParamsKey); ok {
+		body, _ := c.Get(requestBodyKey)
+		return body.([]byte), params.(map[string]interface{}), nil
+	}
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read request body error: %v", err)
+	}
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var params map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err = decoder.Decode(&params); err != nil {
+		return nil, nil, fmt.Errorf("parse request body error")
+	}
+	c.Set(requestBodyKey, body)
+	c.Set(requestParamsKey, params)
+	return body, params, nil
+}
+
+// stringParam 按顺序取第一个非空字段，数字转为字符串
+func stringParam(params map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := params[key].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case json.Number:
+			return v.String()
+		}
+	}
+	return ""
+}
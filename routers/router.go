@@ -3,17 +3,26 @@ package routers
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/group-coldwallet/trxsign/apikey"
 	"github.com/group-coldwallet/trxsign/conf"
 	"github.com/group-coldwallet/trxsign/routers/apis"
+	"github.com/group-coldwallet/trxsign/services"
 	"github.com/group-coldwallet/trxsign/util"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"strings"
 )
 
-func InitRouters(group *gin.RouterGroup) {
+func InitRouters(group *gin.RouterGroup, coinType string, srv services.IService) {
 
-	api := apis.CreateApis()
+	api := apis.CreateApis(coinType, srv)
+	owner, ok := srv.(keyOwner)
+	if conf.Config.ApiKeyCfg.Enable {
+		initApiKeyStore()
+		if !ok {
+			log.Fatalf("%s service can not check address owner,api key is not supported", coinType)
+		}
+	}
 
 	group.Use(BasicAuth())
 	{
@@ -22,22 +31,43 @@ func InitRouters(group *gin.RouterGroup) {
 				"code":    0,
 				"message": "success",
 				"data":    "online",
+				"locked":  services.Locked(),
 			})
 		})
 		group.GET("/test", func(c *gin.Context) {
 			c.JSON(200, gin.H{
 				"code":    0,
 				"message": "success",
-				"data":    fmt.Sprintf("start %s sign service", conf.Config.CoinType),
+				"data":    fmt.Sprintf("start %s sign service", coinType),
 			})
 		})
 
-		group.POST("/createAddr", api.CreateAddress)
-		group.POST("/getBalance", api.GetBalance)
+		signAuth := SignAuth()
+		group.POST("/createAddr", ApiKeyAuth(apikey.ScopeCreateAddr, coinType, owner), signAuth, api.CreateAddress)
+		if js, ok := srv.(addressJobService); ok {
+			group.POST("/createAddrJob", ApiKeyAuth(apikey.ScopeCreateAddr, coinType, owner), signAuth, SubmitAddressJob(js, coinType))
+			group.POST("/createAddrJob/status", ApiKeyAuth(apikey.ScopeCreateAddr, coinType, owner), AddressJobStatus(js))
+			group.POST("/createAddrJob/addresses", ApiKeyAuth(apikey.ScopeCreateAddr, coinType, owner), AddressJobAddresses(js))
+			group.POST("/createAddrJob/cancel", ApiKeyAuth(apikey.ScopeCreateAddr, coinType, owner), CancelAddressJob(js))
+		}
+		group.POST("/getBalance", ApiKeyAuth(apikey.ScopeGetBalance, coinType, owner), api.GetBalance)
 		group.POST("/validAddress", api.ValidAddress)
-		group.POST("/sign", api.Sign)
+		group.POST("/sign", ApiKeyAuth(apikey.ScopeSign, coinType, owner), signAuth, api.Sign)
 		if conf.Config.WalletType == "hot" {
-			group.POST("/transfer", api.Transfer)
+			group.POST("/transfer", ApiKeyAuth(apikey.ScopeTransfer, coinType, owner), signAuth, api.Transfer)
+		}
+		if reloader, ok := srv.(keyReloader); ok && adminEnabled() {
+			group.POST("/admin/reloadKeys", AdminAuth(), ReloadKeys(reloader))
+		}
+		// 观察地址写入持久存储且按商户查询，api key和请求签名都未启用时不注册
+		if ws, ok := srv.(watchService); ok && (conf.Config.ApiKeyCfg.Enable || conf.Config.SignAuthCfg.Enable) {
+			group.POST("/watch/import", ApiKeyAuth(apikey.ScopeWatch, coinType, nil), signAuth, ImportWatchAddresses(ws))
+			group.POST("/watch/list", ApiKeyAuth(apikey.ScopeWatch, coinType, nil), signAuth, ListWatchAddresses(ws))
+		} else if ok {
+			log.Infof("api key and sign auth are disabled,%s watch apis are not registered", coinType)
+		}
+		if verifier, ok := srv.(keyVerifier); ok && adminEnabled() {
+			group.POST("/admin/verifyKeys", AdminAuth(), VerifyKeys(verifier))
 		}
 	}
 
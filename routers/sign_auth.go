@@ -0,0 +1,119 @@
+package routers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/redis"
+	"github.com/group-coldwallet/trxsign/util"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	signAuthDefaultMaxSkew = 300
+	// 内存nonce超过该数量时清理过期记录
+	nonceCacheCleanSize = 10000
+)
+
+// 多币种时各路由组共享
+var requestNonces = &nonceCache{seen: make(map[string]time.Time)}
+
+/*
+SignAuth 请求签名校验
+	按mchId(创建地址为mch)取密钥，校验sign = hex(hmac-sha256(secret, 去掉sign后按key排序的json))
+	createTime与服务器时间偏差不能超过maxSkew
+	同一商户的nonce(依次取nonce、orderId、batchNo)在2倍maxSkew内只能使用一次，启用redis时多实例共享
+*/
+func SignAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := conf.Config.SignAuthCfg
+		if !cfg.Enable {
+			c.Next()
+			return
+		}
+		body, params, err := requestParams(c)
+		if err != nil {
+			signAuthFail(c, err.Error())
+			return
+		}
+		mchId := stringParam(params, "mchId", "mch")
+		nonce := stringParam(params, "nonce", "orderId", "batchNo")
+		sign := stringParam(params, "sign")
+		if mchId == "" || nonce == "" || sign == "" {
+			signAuthFail(c, "mchId, orderId or sign is null")
+			return
+		}
+		secret, ok := cfg.Secrets[mchId]
+		if !ok || secret == "" {
+			signAuthFail(c, fmt.Sprintf("unknown mchId: %s", mchId))
+			return
+		}
+		maxSkew := cfg.MaxSkew
+		if maxSkew <= 0 {
+			maxSkew = signAuthDefaultMaxSkew
+		}
+		createTime, err := strconv.ParseInt(stringParam(params, "createTime"), 10, 64)
+		if err != nil {
+			signAuthFail(c, "createTime is invalid")
+			return
+		}
+		if skew := time.Now().Unix() - createTime; skew > maxSkew || skew < -maxSkew {
+			signAuthFail(c, fmt.Sprintf("createTime is out of range: %d", createTime))
+			return
+		}
+		if err = util.VerifyRequestSign(secret, body, sign); err != nil {
+			signAuthFail(c, err.Error())
+			return
+		}
+		// 签名通过后再记录nonce，避免伪造请求占用nonce
+		if err = requestNonces.use(mchId, nonce, time.Duration(2*maxSkew)*time.Second); err != nil {
+			signAuthFail(c, err.Error())
+			return
+		}
+		c.Next()
+	}
+}
+
+func signAuthFail(c *gin.Context, message string) {
+	log.Errorf("请求签名验证不通过: %s", message)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": "401", "message": message})
+}
+
+// nonceCache 未启用redis时的nonce记录
+type nonceCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func (n *nonceCache) use(mchId, nonce string, expiration time.Duration) error {
+	key := redis.GetRequestNonceKey(mchId, nonce)
+	if redis.Client != nil {
+		ok, err := redis.Client.SetNX(key, time.Now().Unix(), expiration)
+		if err != nil {
+			return fmt.Errorf("check nonce error: %v", err)
+		}
+		if !ok {
+			return fmt.Errorf("request is replayed: %s", nonce)
+		}
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := time.Now()
+	if until, ok := n.seen[key]; ok && now.Before(until) {
+		return fmt.Errorf("request is replayed: %s", nonce)
+	}
+	if len(n.seen) >= nonceCacheCleanSize {
+		for k, until := range n.seen {
+			if !now.Before(until) {
+				delete(n.seen, k)
+			}
+		}
+	}
+	n.seen[key] = now.Add(expiration)
+	return nil
+}
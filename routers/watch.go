@@ -0,0 +1,63 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/watch"
+	log "github.com/sirupsen/logrus"
+)
+
+// watchService 观察地址，由v1.BaseService实现
+type watchService interface {
+	ImportWatchAddresses(mchId string, params []model.WatchAddressParams) (*watch.ImportResult, error)
+	ListWatchAddresses(mchId string, tags map[string]string) []*watch.Address
+}
+
+// 单次导入的最大地址数
+const maxWatchImport = 50000
+
+// ImportWatchAddresses 导入观察地址，逐个校验，返回导入、更新的数量和失败的地址
+func ImportWatchAddresses(srv watchService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.ReqWatchImportParams
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(200, gin.H{"code": 1, "message": "Parse watch import post data error"})
+			return
+		}
+		if req.MchId == "" || len(req.Addresses) == 0 {
+			c.JSON(200, gin.H{"code": 1, "message": "mchId or addresses is null"})
+			return
+		}
+		if len(req.Addresses) > maxWatchImport {
+			c.JSON(200, gin.H{"code": 1, "message": "too many addresses"})
+			return
+		}
+		result, err := srv.ImportWatchAddresses(req.MchId, req.Addresses)
+		if err != nil {
+			log.Errorf("import watch addresses error: %v", err)
+			c.JSON(200, gin.H{"code": 1, "message": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"code": 0, "message": "success", "data": result})
+	}
+}
+
+// ListWatchAddresses 商户的观察地址，供充值扫描使用
+func ListWatchAddresses(srv watchService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.ReqWatchListParams
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(200, gin.H{"code": 1, "message": "Parse watch list post data error"})
+			return
+		}
+		if req.MchId == "" {
+			c.JSON(200, gin.H{"code": 1, "message": "mchId is null"})
+			return
+		}
+		list := srv.ListWatchAddresses(req.MchId, req.Tags)
+		if list == nil {
+			list = []*watch.Address{}
+		}
+		c.JSON(200, gin.H{"code": 0, "message": "success", "data": list})
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"github.com/group-coldwallet/trxsign/util"
+	"sync"
+)
+
+// 信封加密的主密钥，进程内所有币种共用
+var (
+	masterKeyMu       sync.RWMutex
+	masterKeyRequired bool
+	masterKey         *util.MasterKey
+)
+
+// RequireMasterKey 启用信封加密，解锁前不加载私钥
+func RequireMasterKey() {
+	masterKeyMu.Lock()
+	masterKeyRequired = true
+	masterKeyMu.Unlock()
+}
+
+func SetMasterKey(mk *util.MasterKey) {
+	masterKeyMu.Lock()
+	masterKey = mk
+	masterKeyMu.Unlock()
+}
+
+func GetMasterKey() *util.MasterKey {
+	masterKeyMu.RLock()
+	defer masterKeyMu.RUnlock()
+	return masterKey
+}
+
+// Locked 启用了信封加密但尚未解锁
+func Locked() bool {
+	masterKeyMu.RLock()
+	defer masterKeyMu.RUnlock()
+	return masterKeyRequired && masterKey == nil
+}
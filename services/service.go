@@ -1,16 +1,14 @@
 package services
 
 import (
-	"encoding/csv"
 	"fmt"
 	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/keystore"
 	"github.com/group-coldwallet/trxsign/model"
 	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/group-coldwallet/trxsign/watch"
 	log "github.com/sirupsen/logrus"
-	"io"
-	"os"
-	"path/filepath"
-	"strings"
 )
 
 type IService interface {
@@ -22,109 +20,136 @@ type IService interface {
 	ValidAddress(address string) error
 }
 
+// AddressDeriver 由解密后的私钥推导地址(或公钥)，与生成地址时的方式一致，用于核对私钥文件
+type AddressDeriver interface {
+	DeriveAddress(key []byte) (string, error)
+}
+
+// KeyNormalizer 转换为私钥存储中的格式，查询私钥和归属前调用，如evm地址转小写
+type KeyNormalizer interface {
+	NormalizeKey(key string) string
+}
+
 type Service struct {
-	aesKeyMap     map[string]string
-	encryptKeyMap map[string]string
+	filePath string
+	store    keystore.KeyStore
+	watch    *watch.Store //观察地址，只有按币种创建的服务才有
 }
 
 func New() *Service {
-	s := new(Service)
-	s.aesKeyMap = make(map[string]string)
-	s.encryptKeyMap = make(map[string]string)
-	s.InitKeyMap()
-	return s
+	return NewWithFilePath(conf.Config.FilePath)
 }
 
-func (s *Service) InitKeyMap() {
-	paths := s.loadKeyFilePath()
-	if paths == nil || len(paths) == 0 {
-		//log.Error("Load private key error,maybe is can find .csv,please check you path")
-		return
+// NewWithFilePath 从指定目录加载csv私钥文件
+func NewWithFilePath(filePath string) *Service {
+	return NewWithKeyStore(filePath, keystore.NewCsvStore(keyStoreOptions(keystore.TypeCsv, filePath)))
+}
+
+func NewWithKeyStore(filePath string, store keystore.KeyStore) *Service {
+	return &Service{filePath: filePath, store: store}
+}
+
+// NewWithCoinType 按[keyStore]配置打开币种的私钥存储
+func NewWithCoinType(coinType string) (*Service, error) {
+	filePath := conf.Config.GetKeyFilePath(coinType)
+	opts := keyStoreOptions(conf.Config.KeyStoreCfg.Type, filePath)
+	opts.BoltFile = conf.Config.GetBoltFile(coinType)
+	if cfg := conf.Config.ShareCfg; cfg.Enable {
+		opts.Shares = &util.ShareOptions{Threshold: cfg.Threshold, Total: cfg.Total, Dirs: cfg.Dirs}
 	}
-	err := s.loadKeyFile(paths)
+	store, err := keystore.Open(opts)
 	if err != nil {
-
+		return nil, err
+	}
+	srv := NewWithKeyStore(filePath, store)
+	if srv.watch, err = watch.Open(filePath, coinType); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("open watch addresses error: %v", err)
 	}
+	return srv, nil
+}
 
+func keyStoreOptions(typ, filePath string) keystore.Options {
+	return keystore.Options{
+		Type:      typ,
+		Path:      filePath,
+		MasterKey: GetMasterKey,
+		Locked:    Locked,
+	}
 }
 
-func (s *Service) loadKeyFile(paths []string) error {
+// InitKeyMap 重新加载私钥，csv只读取新增或变化的文件
+func (s *Service) InitKeyMap() {
+	if _, err := s.store.Reload(); err != nil {
+		log.Errorf("reload key store %s error: %v", s.filePath, err)
+	}
+}
 
-	defer func() {
-		if err := recover(); err != nil {
-			log.Error(err)
+// ReloadKeys 重新加载私钥和观察地址
+func (s *Service) ReloadKeys() (keystore.ReloadStats, error) {
+	if s.watch != nil {
+		if err := s.watch.Reload(); err != nil {
+			return keystore.ReloadStats{}, fmt.Errorf("reload watch addresses error: %v", err)
 		}
-	}()
+	}
+	return s.store.Reload()
+}
 
-	for _, path := range paths {
-		file, err := os.Open(path)
-		if err != nil {
-			return err
-		}
-		// 这个方法体执行完成后，关闭文件
-		defer file.Close()
-		reader := csv.NewReader(file)
-		//reader.FieldsPerRecord = -1
-		//idx:=1
-		for {
-			//log.Println(path,idx)
-			// Read返回的是一个数组，它已经帮我们分割了，
-			record, err := reader.Read()
-			// 如果读到文件的结尾，EOF的优先级比nil高！
-			if err == io.EOF {
-				break
-			} else if err != nil {
-				log.Println(path)
-				log.Println(reader.FieldsPerRecord)
-				log.Error("记录集错误:", err)
-				return nil
-			}
-			if strings.Contains(path, "_a_") {
-				s.encryptKeyMap[record[0]] = record[1]
-			} else {
-				s.aesKeyMap[record[0]] = record[1]
-			}
-			//idx++
-		}
+// WatchKeys 监听私钥文件的变化，不支持监听的存储直接返回
+func (s *Service) WatchKeys() error {
+	w, ok := s.store.(keystore.Watcher)
+	if !ok {
+		return nil
 	}
-	return nil
+	return w.Watch()
 }
-func (s *Service) loadKeyFilePath() []string {
-	var (
-		paths []string
-	)
 
-	filepath.Walk(conf.Config.FilePath, func(path string, info os.FileInfo, err error) error {
+// FilePath 私钥文件目录
+func (s *Service) FilePath() string {
+	return s.filePath
+}
 
-		if strings.Contains(path, ".csv") {
-			if strings.Contains(path, "_a_") || strings.Contains(path, "_b_") {
-				paths = append(paths, path)
-			}
-		}
-		return nil
-	})
-	return paths
+func (s *Service) KeyStore() keystore.KeyStore {
+	return s.store
 }
 
-func (s *Service) GetAesKeyMap() map[string]string {
-	return s.aesKeyMap
+// PutKeys 保存新生成的私钥
+func (s *Service) PutKeys(mchId, batchNo, coinType string, infos []util.AddrInfo) ([]string, error) {
+	return s.store.Put(mchId, batchNo, coinType, infos)
 }
 
-func (s *Service) GetEncryptKeyMap() map[string]string {
-	return s.encryptKeyMap
+// GetMchIdByKey 地址或公钥所属的商户
+func (s *Service) GetMchIdByKey(key string) (string, bool) {
+	return s.store.MchId(key)
 }
 
-func (s *Service) GetKeyByAddress(address string) (string, error) {
-	aesKey := s.aesKeyMap[address]
-	encryptKey := s.encryptKeyMap[address]
-	if aesKey == "" || encryptKey == "" {
-		return "", fmt.Errorf("Load aes key or encrypt key is null,AES=[%s],ENCRYPT=[%s],Address=[%s]", aesKey,
-			encryptKey, address)
+// GetKeyByAddress 返回受保护内存中的私钥，使用后需要Destroy；观察地址返回watch-only错误
+func (s *Service) GetKeyByAddress(address string) (*secmem.Buffer, error) {
+	if s.IsWatchOnly(address) {
+		return nil, fmt.Errorf("%s: %v", address, watch.ErrWatchOnly)
 	}
-	privateBytes, err := util.AesBase64Crypt([]byte(encryptKey), []byte(aesKey), false)
-	if err != nil {
-		return "", err
+	return s.store.Get(address)
+}
+
+func (s *Service) HasKey(key string) bool {
+	return s.store.Has(key)
+}
+
+// CheckKey 地址有私钥时返回nil
+func (s *Service) CheckKey(key string) error {
+	if s.IsWatchOnly(key) {
+		return fmt.Errorf("%s: %v", key, watch.ErrWatchOnly)
 	}
-	wif := string(privateBytes)
-	return wif, nil
+	if !s.store.Has(key) {
+		return fmt.Errorf("%s not found", key)
+	}
+	return nil
+}
+
+func (s *Service) WatchStore() *watch.Store {
+	return s.watch
+}
+
+func (s *Service) IsWatchOnly(address string) bool {
+	return s.watch != nil && s.watch.Has(address)
 }
@@ -0,0 +1,229 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/ar"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"math/big"
+)
+
+type ArService struct {
+	*BaseService
+	client *ar.Client
+}
+
+func init() {
+	registerCoinService("ar", func(bs *BaseService) services.IService { return bs.ARService() })
+}
+
+func (bs *BaseService) ARService() *ArService {
+	cs := new(ArService)
+	cs.BaseService = bs
+	cs.client = ar.NewClient(conf.Config.ARCfg.NodeUrl)
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *ArService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *ArService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create ar address")
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	私钥保存为JWK格式的json，可直接作为arweave keyfile导入钱包
+	注意：
+		RSA-4096生成较慢，大批量生成建议开启多线程
+*/
+func (cs *ArService) createAddressInfo() (util.AddrInfo, error) {
+	jwk, address, err := ar.GenerateWallet()
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: jwk,
+		Address: address,
+	}, nil
+}
+
+// DeriveAddress 由JWK私钥推导地址
+func (cs *ArService) DeriveAddress(key []byte) (string, error) {
+	wallet, err := ar.LoadWallet(secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer wallet.Wipe()
+	return wallet.Address(), nil
+}
+
+/*
+离线签名服务
+	last_tx和fee由调用方传入，返回已签名交易的json，可直接POST到节点的/tx接口
+*/
+func (cs *ArService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.ARSignParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" || tp.Fee == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s],fee=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount, tp.Fee)
+	}
+	amount, err := cs.parseAmount(tp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := cs.parseAmount(tp.Fee)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := cs.sign(tp.FromAddress, tp.ToAddress, tp.LastTx, amount, fee)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("ar sign txid is: %s", tx.ID)
+	return tx.Marshal()
+}
+
+/*
+热钱包出账服务
+	锚点和手续费从节点获取
+*/
+func (cs *ArService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.ARTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	if err := cs.ValidAddress(tp.ToAddress); err != nil {
+		return nil, err
+	}
+	amount, err := cs.parseAmount(tp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := cs.client.GetPrice(tp.ToAddress)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := cs.client.GetBalance(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	if new(big.Int).Add(amount, fee).Cmp(balance) > 0 {
+		return nil, fmt.Errorf("[%s] amount is not enough,transAmount=[%s],fee=[%s],chainAmount=[%s]", tp.FromAddress, amount.String(), fee.String(), balance.String())
+	}
+	anchor, err := cs.client.GetAnchor()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := cs.sign(tp.FromAddress, tp.ToAddress, anchor, amount, fee)
+	if err != nil {
+		return nil, err
+	}
+	rawTx, err := tx.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if err = cs.client.SendTransaction(rawTx); err != nil {
+		return nil, err
+	}
+	log.Infof("send txid is: %s", tx.ID)
+	return tx.ID, nil
+}
+
+func (cs *ArService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	if err := cs.ValidAddress(req.Address); err != nil {
+		return nil, err
+	}
+	balance, err := cs.client.GetBalance(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"coin":   req.CoinName,
+		"amount": balance.String(),
+	}, nil
+}
+
+func (cs *ArService) ValidAddress(address string) error {
+	return ar.ValidAddress(address)
+}
+
+func (cs *ArService) sign(from, to, anchor string, amount, fee *big.Int) (*ar.Transaction, error) {
+	var wallet *ar.Wallet
+	err := cs.BaseService.withPrivateKey(from, func(key []byte) (err error) {
+		wallet, err = ar.LoadWallet(secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get private key error,Err=%v", err)
+	}
+	defer wallet.Wipe()
+	if wallet.Address() != from {
+		return nil, fmt.Errorf("private key is not match address %s", from)
+	}
+	tx, err := ar.NewTransfer(wallet.Owner(), anchor, to, amount, fee)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Sign(wallet); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (cs *ArService) parseAmount(amount string) (*big.Int, error) {
+	a, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount error,err=%v", err)
+	}
+	if a.IsNegative() || !a.Equal(a.Truncate(0)) {
+		return nil, fmt.Errorf("amount must be a non-negative integer in winston: %s", amount)
+	}
+	return a.BigInt(), nil
+}
@@ -1,16 +1,17 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/group-coldwallet/trxsign/addrjob"
 	"github.com/group-coldwallet/trxsign/conf"
 	"github.com/group-coldwallet/trxsign/model"
 	"github.com/group-coldwallet/trxsign/services"
 	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/watch"
 	log "github.com/sirupsen/logrus"
-	"reflect"
-	"runtime"
 	"strings"
 )
 
@@ -18,36 +19,134 @@ type generateKeyAndAddress func() (util.AddrInfo, error) //用于生成地址方
 
 type BaseService struct {
 	*services.Service
+	coinType    string
+	srv         services.IService     //币种服务，创建后设置
+	generateKey generateKeyAndAddress //币种生成地址的方法，构造币种服务时设置
+	jobs        *addrjob.Manager      //异步创建地址任务，设置了generateKey时才有
 }
 
-func newBaseService() *BaseService {
+func newBaseService(coinType string) (*BaseService, error) {
+	srv, err := services.NewWithCoinType(coinType)
+	if err != nil {
+		return nil, fmt.Errorf("open %s key store error: %v", coinType, err)
+	}
 	bs := new(BaseService)
-	bs.Service = services.New()
-
-	return bs
+	bs.coinType = coinType
+	bs.Service = srv
+	return bs, nil
 }
 
 /*
 传入地址或者公钥获取私钥
+	私钥只在fn内有效，fn返回后清零；需要string的库使用secmem.String，不能保存
 */
-func (bs *BaseService) addressOrPublicKeyToPrivate(publicKey string) (string, error) {
-	return bs.GetKeyByAddress(publicKey)
+func (bs *BaseService) withPrivateKey(publicKey string, fn func(key []byte) error) error {
+	if n, ok := bs.srv.(services.KeyNormalizer); ok {
+		publicKey = n.NormalizeKey(publicKey)
+	}
+	key, err := bs.GetKeyByAddress(publicKey)
+	if err != nil {
+		return err
+	}
+	defer key.Destroy()
+	return fn(key.Bytes())
 }
 
-func (bs *BaseService) createAddress(req *model.ReqCreateAddressParamsV2, generateKey generateKeyAndAddress) (*model.RespCreateAddressParams, error) {
+/*
+VerifyKeys 核对私钥目录下该币种的a、b、d文件，返回签名后的报告
+	币种服务实现了AddressDeriver时，同时核对私钥推导出的地址
+*/
+func (bs *BaseService) VerifyKeys(mchId string) (*util.VerifyReport, error) {
+	secret := conf.Config.VerifyCfg.Secret
+	if secret == "" {
+		return nil, errors.New("verify secret is not configured")
+	}
+	// v3文件需要主密钥才能解密
+	if services.Locked() {
+		return nil, errors.New("service is locked,can not verify keys")
+	}
+	opts := util.VerifyOptions{CoinType: bs.coinType, MchId: mchId, MasterKey: services.GetMasterKey()}
+	if d, ok := bs.srv.(services.AddressDeriver); ok {
+		opts.Derive = d.DeriveAddress
+	}
+	report, err := util.VerifyKeyFiles(bs.FilePath(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("verify %s key files error: %v", bs.coinType, err)
+	}
+	if err = report.SignReport(secret); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
 
-	var addrInfos []util.AddrInfo
-	for i := 0; i < req.Count; i++ {
-		addrInfo, err := generateKey()
-		if err != nil {
-			return nil, err
+/*
+ImportWatchAddresses 导入商户的观察地址
+	逐个使用币种的ValidAddress校验，本服务已有私钥的地址不能作为观察地址
+*/
+func (bs *BaseService) ImportWatchAddresses(mchId string, params []model.WatchAddressParams) (*watch.ImportResult, error) {
+	if bs.WatchStore() == nil || bs.srv == nil {
+		return nil, errors.New("watch store is not opened")
+	}
+	var (
+		addrs    []*watch.Address
+		failures []watch.ImportFailure
+	)
+	for _, p := range params {
+		address := strings.TrimSpace(p.Address)
+		if address == "" {
+			failures = append(failures, watch.ImportFailure{Reason: "address is empty"})
+			continue
+		}
+		if err := bs.srv.ValidAddress(address); err != nil {
+			failures = append(failures, watch.ImportFailure{Address: address, Reason: fmt.Sprintf("invalid address: %v", err)})
+			continue
+		}
+		if bs.HasKey(address) {
+			failures = append(failures, watch.ImportFailure{Address: address, Reason: "address has private key in this service"})
+			continue
 		}
-		addrInfos = append(addrInfos, addrInfo)
+		addrs = append(addrs, &watch.Address{Address: address, Label: p.Label, Tags: p.Tags})
 	}
-	addresses, err := util.CreateAddrCsv(conf.Config.FilePath, req.Mch, req.BatchNo, req.CoinCode, addrInfos)
+	result, err := bs.WatchStore().Import(mchId, addrs)
 	if err != nil {
 		return nil, err
 	}
+	result.Failures = append(failures, result.Failures...)
+	log.Infof("import %s watch addresses of %s: %d imported,%d updated,%d failed",
+		bs.coinType, mchId, result.Imported, result.Updated, len(result.Failures))
+	return result, nil
+}
+
+// ListWatchAddresses 商户的观察地址，tags不为空时按标签过滤
+func (bs *BaseService) ListWatchAddresses(mchId string, tags map[string]string) []*watch.Address {
+	if bs.WatchStore() == nil {
+		return nil
+	}
+	return bs.WatchStore().List(mchId, tags)
+}
+
+func (bs *BaseService) createAddress(req *model.ReqCreateAddressParamsV2, generateKey generateKeyAndAddress) (*model.RespCreateAddressParams, error) {
+	// 未解锁时无法用主密钥加密数据密钥
+	if services.Locked() {
+		return nil, errors.New("service is locked,can not create address")
+	}
+
+	if err := bs.reserveBatch(req.Mch, req.BatchNo); err != nil {
+		return nil, err
+	}
+	// 未开启多线程时只使用一个协程，失败的地址同样重试
+	opts := bs.generateOptions()
+	opts.Workers = 1
+	addrInfos, _, err := bs.generateKeys(context.Background(), req.Count, opts, generateKey)
+	if err != nil {
+		bs.releaseBatch(req.Mch, req.BatchNo)
+		return nil, err
+	}
+	addresses, err := bs.PutKeys(req.Mch, req.BatchNo, req.CoinCode, addrInfos)
+	if err != nil {
+		bs.releaseBatch(req.Mch, req.BatchNo)
+		return nil, err
+	}
 	resp := new(model.RespCreateAddressParams)
 	resp.Address = addresses
 	resp.CoinCode = req.CoinCode
@@ -57,62 +156,21 @@ func (bs *BaseService) createAddress(req *model.ReqCreateAddressParamsV2, genera
 }
 
 func (bs *BaseService) multiThreadCreateAddress(number int, CoinCode, mch, batchNo string, generateKey generateKeyAndAddress) (*model.RespCreateAddressParams, error) {
-	numcpu := runtime.NumCPU()
-	buildnummap := []int{}
-	addressChan := make(chan util.AddrInfo, number)
-	if number <= numcpu {
-		numcpu = 1
-		buildnummap = append(buildnummap, number)
-	} else {
-		// 计算每个chan生成多少个
-		avg := number / numcpu
-		for j := 0; j < numcpu; j++ {
-			buildnummap = append(buildnummap, avg)
-		}
-		buildnummap[numcpu-1] += (number % numcpu)
-	}
-
-	for i := 0; i < numcpu; i++ {
-		buildnum := buildnummap[i]
-		go func(workId int) {
-			for index := 0; index < buildnum; index++ {
-				addressInfo, err := generateKey()
-				if err != nil {
-					addressChan <- util.AddrInfo{
-						Mnemonic: "",
-						Address:  "",
-						PrivKey:  "",
-					}
-					log.Errorf("work_id=[%d] create address error,Err=[%v]", workId, err)
-					continue
-				}
-				addressChan <- addressInfo
-			}
-		}(i)
-	}
-	var addrInfos []util.AddrInfo
-	total := 0
-	for {
-		select {
-		case addrchan := <-addressChan:
-			{
-				total++
-				if addrchan.Address == "" || addrchan.PrivKey == "" {
-					break
-				}
-				addrInfos = append(addrInfos, addrchan)
-			}
-		}
-		if total >= number {
-			break
-		}
+	if services.Locked() {
+		return nil, errors.New("service is locked,can not create address")
 	}
-	if addrInfos == nil {
-		return nil, errors.New("don`t have any address info")
+	if err := bs.reserveBatch(mch, batchNo); err != nil {
+		return nil, err
+	}
+	addrInfos, _, err := bs.generateKeys(context.Background(), number, bs.generateOptions(), generateKey)
+	if err != nil {
+		bs.releaseBatch(mch, batchNo)
+		return nil, err
 	}
 	log.Printf("Start write address to file,Create address number=[%d],Need address=[%d]", len(addrInfos), number)
-	addresses, err := util.CreateAddrCsv(conf.Config.FilePath, mch, batchNo, conf.Config.CoinType, addrInfos)
+	addresses, err := bs.PutKeys(mch, batchNo, bs.coinType, addrInfos)
 	if err != nil {
+		bs.releaseBatch(mch, batchNo)
 		return nil, err
 	}
 	resp := new(model.RespCreateAddressParams)
@@ -123,6 +181,104 @@ func (bs *BaseService) multiThreadCreateAddress(number int, CoinCode, mch, batch
 	return resp, nil
 }
 
+/*
+openAddressJobs 打开币种的异步创建地址任务
+	使用构造币种服务时设置的generateKey生成地址，分片保存到私钥存储
+*/
+func (bs *BaseService) openAddressJobs(generateKey generateKeyAndAddress) error {
+	generate := func(ctx context.Context, n int) ([]util.AddrInfo, int, error) {
+		if services.Locked() {
+			return nil, 0, errors.New("service is locked,can not create address")
+		}
+		return bs.generateKeys(ctx, n, bs.generateOptions(), generateKey)
+	}
+	put := func(mchId, batchNo string, infos []util.AddrInfo) ([]string, error) {
+		return bs.PutKeys(mchId, batchNo, bs.coinType, infos)
+	}
+	jobs, err := addrjob.Open(conf.Config.GetAddrJobDir(bs.coinType), bs.coinType, conf.Config.AddrJobCfg.ChunkSize, generate, put)
+	if err != nil {
+		return fmt.Errorf("open %s address jobs error: %v", bs.coinType, err)
+	}
+	bs.jobs = jobs
+	return nil
+}
+
+// reserveBatch 同步创建地址与异步任务共用批次号占用，避免同一批次号被重复使用
+func (bs *BaseService) reserveBatch(mchId, batchNo string) error {
+	if bs.jobs == nil {
+		return nil
+	}
+	return bs.jobs.Reserve(mchId, batchNo)
+}
+
+// releaseBatch 同步创建地址失败时释放批次号
+func (bs *BaseService) releaseBatch(mchId, batchNo string) {
+	if bs.jobs != nil {
+		bs.jobs.Release(mchId, batchNo)
+	}
+}
+
+// SubmitAddressJob 提交异步创建地址任务，count和batchNo的默认值与CreateAddressService一致
+func (bs *BaseService) SubmitAddressJob(req *model.ReqCreateAddressParamsV2) (*addrjob.JobStatus, error) {
+	if bs.jobs == nil {
+		return nil, errors.New("address job is not supported")
+	}
+	if services.Locked() {
+		return nil, errors.New("service is locked,can not create address")
+	}
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+	status, err := bs.jobs.Submit(req.Mch, req.BatchNo, req.Count)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("submit create %s address job %s,mch=%s,batchNo=%s,count=%d", bs.coinType, status.Id, req.Mch, req.BatchNo, req.Count)
+	return status, nil
+}
+
+// AddressJobStatus 任务进度，只能查询商户自己的任务
+func (bs *BaseService) AddressJobStatus(mchId, jobId string) (*addrjob.JobStatus, error) {
+	if bs.jobs == nil {
+		return nil, errors.New("address job is not supported")
+	}
+	status, err := bs.jobs.Status(jobId)
+	if err != nil {
+		return nil, err
+	}
+	if status.Mch != mchId {
+		return nil, addrjob.ErrNotFound
+	}
+	return status, nil
+}
+
+// AddressJobAddresses 分页读取任务已生成的地址，返回已生成的总数
+func (bs *BaseService) AddressJobAddresses(mchId, jobId string, offset, limit int) ([]string, int, error) {
+	if _, err := bs.AddressJobStatus(mchId, jobId); err != nil {
+		return nil, 0, err
+	}
+	return bs.jobs.Addresses(jobId, offset, limit)
+}
+
+// CancelAddressJob 取消排队中或运行中的任务，已保存的分片保留
+func (bs *BaseService) CancelAddressJob(mchId, jobId string) error {
+	if _, err := bs.AddressJobStatus(mchId, jobId); err != nil {
+		return err
+	}
+	return bs.jobs.Cancel(jobId)
+}
+
+// ResumeAddressJobs 继续上次中断的任务，返回继续的任务数
+func (bs *BaseService) ResumeAddressJobs() int {
+	if bs.jobs == nil {
+		return 0
+	}
+	return bs.jobs.Resume()
+}
+
 func (bs *BaseService) parseData(data, resp interface{}) error {
 	d, err := json.Marshal(data)
 	if err != nil {
@@ -134,9 +290,3 @@ func (bs *BaseService) parseData(data, resp interface{}) error {
 	}
 	return nil
 }
-
-func GetIService() services.IService {
-	bs := newBaseService()
-	name := fmt.Sprintf("%sService", strings.ToUpper(conf.Config.CoinType))
-	return reflect.ValueOf(bs).MethodByName(name).Call(nil)[0].Interface().(services.IService)
-}
@@ -0,0 +1,290 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/bnc"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+const (
+	bncDefaultChainId = "Binance-Chain-Tigris"
+	bncDefaultFee     = 37500
+)
+
+type BncService struct {
+	*BaseService
+	client *bnc.Client
+	hrp    string
+}
+
+func init() {
+	registerCoinService("bnc", func(bs *BaseService) services.IService { return bs.BNCService() })
+}
+
+func (bs *BaseService) BNCService() *BncService {
+	cs := new(BncService)
+	cs.BaseService = bs
+	cs.client = bnc.NewClient(conf.Config.BncCfg.ApiUrl)
+	cs.hrp = bnc.MainnetHrp
+	if conf.Config.BncCfg.Testnet {
+		cs.hrp = bnc.TestnetHrp
+	}
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *BncService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *BncService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create bnc address")
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	私钥保存为hex编码的32字节secp256k1私钥
+*/
+func (cs *BncService) createAddressInfo() (util.AddrInfo, error) {
+	priv, address, err := bnc.GenerateKey(cs.hrp)
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: priv,
+		Address: address,
+	}, nil
+}
+
+// DeriveAddress 由hex私钥推导地址
+func (cs *BncService) DeriveAddress(key []byte) (string, error) {
+	priv, err := bnc.PrivateKeyFromHex(secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	return bnc.Address(cs.hrp, priv.PubKey())
+}
+
+/*
+离线签名服务
+	account_number和sequence由调用方传入
+	返回hex编码的交易，可直接提交到api的broadcast接口
+*/
+func (cs *BncService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.BncTransferParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	if tp.AccountNumber < 0 || tp.Sequence < 0 {
+		return nil, fmt.Errorf("account_number and sequence must not be negative")
+	}
+	chainId := tp.ChainId
+	if chainId == "" {
+		chainId = conf.Config.BncCfg.ChainId
+	}
+	if chainId == "" {
+		chainId = bncDefaultChainId
+	}
+	tx, err := cs.buildTx(&tp, chainId, tp.AccountNumber, tp.Sequence)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tx.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	txid, _ := tx.Hash()
+	log.Infof("bnc sign txid is: %s", txid)
+	return fmt.Sprintf("%x", raw), nil
+}
+
+/*
+热钱包出账服务
+	account_number和sequence从api获取，转账前校验资产余额和BNB手续费
+*/
+func (cs *BncService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.BncTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	account, err := cs.client.GetAccount(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	chainId := conf.Config.BncCfg.ChainId
+	if chainId == "" {
+		if chainId, err = cs.client.GetChainId(); err != nil {
+			return nil, err
+		}
+	}
+	tx, err := cs.buildTx(&tp, chainId, account.AccountNumber, account.Sequence)
+	if err != nil {
+		return nil, err
+	}
+	if err = cs.checkBalance(account, tx.Coins[0]); err != nil {
+		return nil, err
+	}
+	raw, err := tx.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	txid, err := cs.client.Broadcast(raw)
+	if err != nil {
+		return nil, err
+	}
+	if local, _ := tx.Hash(); !strings.EqualFold(local, txid) {
+		log.Warnf("bnc broadcast hash %s is not equal to local txid %s", txid, local)
+	}
+	log.Infof("send txid is: %s", txid)
+	return txid, nil
+}
+
+func (cs *BncService) checkBalance(account *bnc.Account, coin bnc.Coin) error {
+	fee := conf.Config.BncCfg.Fee
+	if fee <= 0 {
+		fee = bncDefaultFee
+	}
+	need := coin.Amount
+	if coin.Denom != bnc.NativeDenom {
+		bnbFree, err := account.Free(bnc.NativeDenom)
+		if err != nil {
+			return err
+		}
+		if bnbFree < fee {
+			return fmt.Errorf("[%s] BNB is not enough for fee,fee=[%d],chainAmount=[%d]", account.Address, fee, bnbFree)
+		}
+	} else {
+		need += fee
+	}
+	free, err := account.Free(coin.Denom)
+	if err != nil {
+		return err
+	}
+	if free < need {
+		return fmt.Errorf("[%s] %s amount is not enough,transAmount=[%d],chainAmount=[%d]", account.Address, coin.Denom, need, free)
+	}
+	return nil
+}
+
+func (cs *BncService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	if err := cs.ValidAddress(req.Address); err != nil {
+		return nil, err
+	}
+	account, err := cs.client.GetAccount(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	denom := bnc.NativeDenom
+	if req.Token != "" {
+		denom = req.Token
+	}
+	free, err := account.Free(denom)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"coin":   req.CoinName,
+		"denom":  denom,
+		"amount": fmt.Sprintf("%d", free),
+	}, nil
+}
+
+func (cs *BncService) ValidAddress(address string) error {
+	_, err := bnc.DecodeAddress(cs.hrp, address)
+	return err
+}
+
+func (cs *BncService) buildTx(tp *model.BncTransferParams, chainId string, accountNumber, sequence int64) (*bnc.SendTx, error) {
+	amount, err := decimal.NewFromString(tp.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount error,err=%v", err)
+	}
+	if !amount.IsPositive() || !amount.Equal(amount.Truncate(0)) {
+		return nil, fmt.Errorf("amount must be a positive integer in 1e-8 unit: %s", tp.Amount)
+	}
+	if !amount.BigInt().IsInt64() {
+		return nil, fmt.Errorf("amount is out of range: %s", tp.Amount)
+	}
+	denom := tp.Denom
+	if denom == "" {
+		denom = bnc.NativeDenom
+	}
+	coins := []bnc.Coin{{Denom: denom, Amount: amount.IntPart()}}
+	tx, err := bnc.NewSendTx(cs.hrp, chainId, tp.FromAddress, tp.ToAddress, coins, tp.Memo)
+	if err != nil {
+		return nil, err
+	}
+	tx.AccountNumber = accountNumber
+	tx.Sequence = sequence
+	tx.Source = conf.Config.BncCfg.Source
+	priv, err := cs.privateKey(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	if err = tx.Sign(priv); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (cs *BncService) privateKey(address string) (*btcec.PrivateKey, error) {
+	var priv *btcec.PrivateKey
+	err := cs.BaseService.withPrivateKey(address, func(key []byte) (err error) {
+		priv, err = bnc.PrivateKeyFromHex(secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get private key error,Err=%v", err)
+	}
+	return priv, nil
+}
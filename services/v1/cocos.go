@@ -0,0 +1,370 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/cocos"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	cocosCoreAssetId        = "1.3.0"
+	cocosCorePrecision      = 5
+	cocosSignExpiration     = 10 * time.Minute
+	cocosTransferExpiration = 60 * time.Second
+)
+
+type CocosService struct {
+	*BaseService
+	client *cocos.Client
+}
+
+func init() {
+	registerCoinService("cocos", func(bs *BaseService) services.IService { return bs.COCOSService() })
+}
+
+func (bs *BaseService) COCOSService() *CocosService {
+	cs := new(CocosService)
+	cs.BaseService = bs
+	cs.client = cocos.NewClient(conf.Config.CocosCfg.NodeUrl, "", "")
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *CocosService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *CocosService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create cocos address")
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	cocos为账户模型，这里生成的是COCOS开头的公钥，由业务方使用公钥注册账户
+	私钥保存为wif格式
+*/
+func (cs *CocosService) createAddressInfo() (util.AddrInfo, error) {
+	wif, pub, err := cocos.GenerateKey()
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: wif,
+		Address: pub,
+	}, nil
+}
+
+// DeriveAddress 由wif私钥推导公钥，cocos的私钥文件以公钥为地址
+func (cs *CocosService) DeriveAddress(key []byte) (string, error) {
+	priv, err := cocos.WifToPrivateKey(secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	return cocos.PublicKeyToString(priv.PubKey()), nil
+}
+
+/*
+离线签名服务
+	账户id、区块信息由业务方传入，不需要联网
+	返回交易id和签名后的交易json，可直接调用broadcast_transaction广播
+*/
+func (cs *CocosService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.CocosSignParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if !cocos.IsAccountId(tp.FromAccountId) || !cocos.IsAccountId(tp.ToAccountId) {
+		return nil, fmt.Errorf("invalid account id,from=[%s],to=[%s]", tp.FromAccountId, tp.ToAccountId)
+	}
+	if tp.PublicKey == "" || tp.HeadBlockId == "" {
+		return nil, fmt.Errorf("params is null,publicKey=[%s],headBlockId=[%s]", tp.PublicKey, tp.HeadBlockId)
+	}
+	assetId, precision := tp.AssetId, tp.AssetDecimal
+	if assetId == "" {
+		assetId, precision = cocosCoreAssetId, cocosCorePrecision
+	}
+	amount, err := cs.toUnit(tp.Amount, precision)
+	if err != nil {
+		return nil, err
+	}
+	op := &cocos.TransferOperation{
+		From:   tp.FromAccountId,
+		To:     tp.ToAccountId,
+		Amount: cocos.Asset{Amount: amount, AssetId: assetId},
+	}
+	if tp.Memo != "" {
+		if tp.ToMemoKey != "" {
+			op.Memo, err = cs.encryptMemo(tp.MemoKey, tp.ToMemoKey, tp.Memo)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			op.Memo = cocos.NewPlainMemo(tp.Memo)
+		}
+	}
+	chainId := tp.ChainId
+	if chainId == "" {
+		chainId = conf.Config.CocosCfg.ChainId
+	}
+	tx, err := cocos.NewTransferTransaction(op, tp.HeadBlockNumber, tp.HeadBlockId, time.Now().Add(cocosSignExpiration))
+	if err != nil {
+		return nil, err
+	}
+	txid, err := cs.sign(tx, tp.PublicKey, chainId)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("cocos sign txid is: %s", txid)
+	return map[string]interface{}{
+		"txid":        txid,
+		"transaction": tx,
+	}, nil
+}
+
+/*
+热钱包出账服务
+	fromaddress/toaddress为账户名或账户id
+	asset_id为空时通过asset_symbol查询资产，二者都为空时转COCOS
+	from账户的memo私钥存在时加密memo，否则为明文memo
+*/
+func (cs *CocosService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.CocosTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || !tp.ToAmount.IsPositive() {
+		return nil, fmt.Errorf("params is error,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.ToAmount.String())
+	}
+	assetId, precision, err := cs.resolveAsset(&tp)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := cs.toUnit(tp.ToAmount, precision)
+	if err != nil {
+		return nil, err
+	}
+	from, err := cs.client.GetAccount(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	to, err := cs.client.GetAccount(tp.ToAddress)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := cs.activeKey(from)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := cs.client.GetBalance(from.Id, assetId)
+	if err != nil {
+		return nil, err
+	}
+	if amount > balance {
+		return nil, fmt.Errorf("[%s] amount is not enough,transAmount=[%d],chainAmount=[%d]", tp.FromAddress, amount, balance)
+	}
+	op := &cocos.TransferOperation{
+		From:   from.Id,
+		To:     to.Id,
+		Amount: cocos.Asset{Amount: amount, AssetId: assetId},
+	}
+	if tp.Memo != "" {
+		if op.Memo, err = cs.encryptMemo(from.Options.MemoKey, to.Options.MemoKey, tp.Memo); err != nil {
+			log.Warnf("encrypt memo error,send plain memo instead: %v", err)
+			op.Memo = cocos.NewPlainMemo(tp.Memo)
+		}
+	}
+	chainId := conf.Config.CocosCfg.ChainId
+	if chainId == "" {
+		if chainId, err = cs.client.GetChainId(); err != nil {
+			return nil, err
+		}
+	}
+	dgp, err := cs.client.GetDynamicGlobalProperties()
+	if err != nil {
+		return nil, err
+	}
+	headTime, err := dgp.HeadTime()
+	if err != nil {
+		return nil, fmt.Errorf("parse head block time error: %v", err)
+	}
+	tx, err := cocos.NewTransferTransaction(op, dgp.HeadBlockNumber, dgp.HeadBlockId, headTime.Add(cocosTransferExpiration))
+	if err != nil {
+		return nil, err
+	}
+	txid, err := cs.sign(tx, publicKey, chainId)
+	if err != nil {
+		return nil, err
+	}
+	if err = cs.client.BroadcastTransaction(tx); err != nil {
+		return nil, err
+	}
+	log.Infof("send txid is: %s", txid)
+	return txid, nil
+}
+
+func (cs *CocosService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	account, err := cs.client.GetAccount(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	assetId, precision := cocosCoreAssetId, int32(cocosCorePrecision)
+	if req.Token != "" {
+		asset, err := cs.client.LookupAssetSymbol(req.Token)
+		if err != nil {
+			return nil, err
+		}
+		assetId, precision = asset.Id, asset.Precision
+	}
+	balance, err := cs.client.GetBalance(account.Id, assetId)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"coin":   req.CoinName,
+		"amount": decimal.New(balance, -precision).String(),
+	}, nil
+}
+
+// ValidAddress 支持账户名、账户id和COCOS公钥
+func (cs *CocosService) ValidAddress(address string) error {
+	if cocos.IsAccountId(address) || cocos.ValidAccountName(address) == nil {
+		return nil
+	}
+	if _, err := cocos.ParsePublicKey(address); err != nil {
+		return fmt.Errorf("%s is neither account nor public key: %v", address, err)
+	}
+	return nil
+}
+
+/*
+resolveAsset 获取转账资产id和精度
+	传了asset_id时直接使用asset_decimal作为精度
+*/
+func (cs *CocosService) resolveAsset(tp *model.CocosTransferParams) (string, int32, error) {
+	if tp.AssetId != "" {
+		if !cocos.IsAssetId(tp.AssetId) {
+			return "", 0, fmt.Errorf("invalid asset id: %s", tp.AssetId)
+		}
+		return tp.AssetId, tp.AssetDecimal, nil
+	}
+	if tp.AssetSymbol == "" {
+		return cocosCoreAssetId, cocosCorePrecision, nil
+	}
+	asset, err := cs.client.LookupAssetSymbol(tp.AssetSymbol)
+	if err != nil {
+		return "", 0, err
+	}
+	if tp.AssetDecimal != 0 && tp.AssetDecimal != asset.Precision {
+		return "", 0, fmt.Errorf("asset %s precision is %d,not %d", tp.AssetSymbol, asset.Precision, tp.AssetDecimal)
+	}
+	return asset.Id, asset.Precision, nil
+}
+
+// activeKey 从账户的active公钥中找到本地有私钥的一个
+func (cs *CocosService) activeKey(account *cocos.Account) (string, error) {
+	for _, key := range account.ActiveKeys() {
+		if cs.HasKey(key) {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("no private key found for account %s active keys", account.Name)
+}
+
+func (cs *CocosService) encryptMemo(fromMemoKey, toMemoKey, memo string) (*cocos.Memo, error) {
+	priv, err := cs.privateKey(fromMemoKey)
+	if err != nil {
+		return nil, fmt.Errorf("get memo private key error: %v", err)
+	}
+	defer secmem.WipeBigInt(priv.D)
+	toPub, err := cocos.ParsePublicKey(toMemoKey)
+	if err != nil {
+		return nil, fmt.Errorf("parse to memo key error: %v", err)
+	}
+	return cocos.EncryptMemo(priv, toPub, memo)
+}
+
+func (cs *CocosService) sign(tx *cocos.Transaction, publicKey, chainId string) (string, error) {
+	priv, err := cs.privateKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	stx, err := tx.Serialize()
+	if err != nil {
+		return "", err
+	}
+	sig, err := cocos.SignTransaction(priv, chainId, stx)
+	if err != nil {
+		return "", err
+	}
+	tx.AddSignature(sig)
+	return tx.Id()
+}
+
+func (cs *CocosService) privateKey(publicKey string) (*btcec.PrivateKey, error) {
+	var priv *btcec.PrivateKey
+	err := cs.BaseService.withPrivateKey(publicKey, func(key []byte) (err error) {
+		priv, err = cocos.WifToPrivateKey(secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get private key error,Err=%v", err)
+	}
+	if cocos.PublicKeyToString(priv.PubKey()) != publicKey {
+		return nil, errors.New("private key is not match public key " + publicKey)
+	}
+	return priv, nil
+}
+
+// toUnit 按资产精度转为链上整数金额
+func (cs *CocosService) toUnit(amount decimal.Decimal, precision int32) (int64, error) {
+	a := amount.Shift(precision)
+	if !a.IsPositive() || !a.Equal(a.Truncate(0)) || !a.BigInt().IsInt64() {
+		return 0, fmt.Errorf("invalid amount %s for precision %d", amount.String(), precision)
+	}
+	return a.IntPart(), nil
+}
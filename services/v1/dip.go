@@ -0,0 +1,282 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/dip"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	"math/big"
+)
+
+type DipService struct {
+	*BaseService
+	client *dip.Client
+}
+
+func init() {
+	registerCoinService("dip", func(bs *BaseService) services.IService { return bs.DIPService() })
+}
+
+func (bs *BaseService) DIPService() *DipService {
+	cs := new(DipService)
+	cs.BaseService = bs
+	cs.client = dip.NewClient(conf.Config.DipCfg.ApiUrl)
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *DipService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *DipService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create dip address")
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	secp256k1私钥保存为hex，地址为dip开头的bech32地址
+*/
+func (cs *DipService) createAddressInfo() (util.AddrInfo, error) {
+	priv, address, err := dip.GenerateKey()
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: priv,
+		Address: address,
+	}, nil
+}
+
+// DeriveAddress 由hex私钥推导dip地址
+func (cs *DipService) DeriveAddress(key []byte) (string, error) {
+	priv, err := dip.PrivateKeyFromHex(secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer secmem.Wipe(priv[:])
+	return dip.Address(priv), nil
+}
+
+/*
+离线签名服务
+	account_number和sequence由调用方传入，不需要联网
+	返回交易hash和amino json格式的StdTx
+*/
+func (cs *DipService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.DipSignParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s]", tp.FromAddress, tp.ToAddress)
+	}
+	amount, err := cs.parseAmount(tp.ToAmount)
+	if err != nil {
+		return nil, err
+	}
+	chainId := tp.ChainID
+	if chainId == "" {
+		chainId = conf.Config.DipCfg.ChainId
+	}
+	priv, err := cs.privateKey(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.Wipe(priv[:])
+	tx, hash, err := dip.SignTransfer(priv, &dip.TransferParams{
+		ChainId:       chainId,
+		AccountNumber: tp.AccountNumber,
+		Sequence:      tp.Sequence,
+		From:          tp.FromAddress,
+		To:            tp.ToAddress,
+		Amount:        amount,
+		Denom:         tp.Denom,
+		Gas:           cs.gas(tp.Gas),
+		Fee:           cs.fee(tp.Fee),
+		Memo:          tp.Memo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("dip sign txid is: %s", hash)
+	return map[string]interface{}{
+		"txid": hash,
+		"tx":   tx,
+	}, nil
+}
+
+/*
+热钱包出账服务
+	account_number和sequence从lcd获取
+*/
+func (cs *DipService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.DipTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	a, err := decimal.NewFromString(tp.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount error,err=%v", err)
+	}
+	amount, err := cs.parseAmount(a)
+	if err != nil {
+		return nil, err
+	}
+	denom := tp.Denom
+	if denom == "" {
+		denom = dip.DefaultDenom
+	}
+	fee := cs.fee(tp.Fee)
+	account, err := cs.client.GetAccount(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	// 手续费固定使用pdip支付
+	need := map[string]*big.Int{dip.DefaultDenom: big.NewInt(fee)}
+	if denom == dip.DefaultDenom {
+		need[denom] = new(big.Int).Add(need[denom], amount)
+	} else {
+		need[denom] = amount
+	}
+	for d, n := range need {
+		if balance := account.Balance(d); balance.Cmp(n) < 0 {
+			return nil, fmt.Errorf("[%s] %s is not enough,need=[%s],chainAmount=[%s]", tp.FromAddress, d, n.String(), balance.String())
+		}
+	}
+	chainId := conf.Config.DipCfg.ChainId
+	if chainId == "" {
+		if chainId, err = cs.client.ChainId(); err != nil {
+			return nil, err
+		}
+	}
+	priv, err := cs.privateKey(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.Wipe(priv[:])
+	tx, hash, err := dip.SignTransfer(priv, &dip.TransferParams{
+		ChainId:       chainId,
+		AccountNumber: account.AccountNumber,
+		Sequence:      account.Sequence,
+		From:          tp.FromAddress,
+		To:            tp.ToAddress,
+		Amount:        amount,
+		Denom:         denom,
+		Gas:           cs.gas(tp.Gas),
+		Fee:           fee,
+		Memo:          tp.Memo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result, err := cs.client.Broadcast(tx)
+	if err != nil {
+		return nil, err
+	}
+	if result.TxHash != "" && result.TxHash != hash {
+		log.Warnf("dip broadcast txhash %s is not equal to local txid %s", result.TxHash, hash)
+		hash = result.TxHash
+	}
+	log.Infof("send txid is: %s", hash)
+	return hash, nil
+}
+
+func (cs *DipService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	if err := cs.ValidAddress(req.Address); err != nil {
+		return nil, err
+	}
+	account, err := cs.client.GetAccount(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	denom := req.Token
+	if denom == "" {
+		denom = dip.DefaultDenom
+	}
+	return map[string]string{
+		"coin":   req.CoinName,
+		"amount": account.Balance(denom).String(),
+	}, nil
+}
+
+func (cs *DipService) ValidAddress(address string) error {
+	return dip.ValidAddress(address)
+}
+
+func (cs *DipService) privateKey(address string) (secp256k1.PrivKeySecp256k1, error) {
+	var priv secp256k1.PrivKeySecp256k1
+	err := cs.BaseService.withPrivateKey(address, func(key []byte) (err error) {
+		priv, err = dip.PrivateKeyFromHex(secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return priv, fmt.Errorf("get private key error,Err=%v", err)
+	}
+	return priv, nil
+}
+
+func (cs *DipService) gas(gas uint64) uint64 {
+	if gas == 0 {
+		gas = conf.Config.DipCfg.Gas
+	}
+	return gas
+}
+
+func (cs *DipService) fee(fee int64) int64 {
+	if fee == 0 {
+		fee = conf.Config.DipCfg.Fee
+	}
+	return fee
+}
+
+// parseAmount 金额单位为denom的最小单位
+func (cs *DipService) parseAmount(amount decimal.Decimal) (*big.Int, error) {
+	if !amount.IsPositive() || !amount.Equal(amount.Truncate(0)) {
+		return nil, fmt.Errorf("amount must be a positive integer: %s", amount.String())
+	}
+	return amount.BigInt(), nil
+}
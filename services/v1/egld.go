@@ -14,8 +14,10 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/group-coldwallet/trxsign/conf"
 	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
 	"github.com/group-coldwallet/trxsign/util"
 	"github.com/group-coldwallet/trxsign/util/egld"
+	"github.com/group-coldwallet/trxsign/util/secmem"
 	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 	"math/big"
@@ -29,6 +31,10 @@ type EgldService struct {
 	nonceCtl, noncePool sync.Map
 }
 
+func init() {
+	registerCoinService("egld", func(bs *BaseService) services.IService { return bs.EGLDService() })
+}
+
 func (bs *BaseService) EGLDService() *EgldService {
 	cs := new(EgldService)
 	cs.BaseService = bs
@@ -38,6 +44,7 @@ func (bs *BaseService) EGLDService() *EgldService {
 	cs.nonceCtl = sync.Map{}
 	// 新增nonce维护池
 	cs.noncePool = sync.Map{}
+	bs.generateKey = cs.createAddressInfo
 	return cs
 }
 
@@ -63,9 +70,7 @@ func (cs *EgldService) CreateAddressService(req *model.ReqCreateAddressParamsV2)
 		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
 	}
 	if err == nil {
-		log.Infof("CreateAddressService 完成，共生成 %d 个地址，准备重新加载地址", len(result.Address))
-		cs.InitKeyMap()
-		log.Info("重新加载地址完成")
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
 	}
 	return result, err
 }
@@ -129,14 +134,16 @@ func (cs *EgldService) SignService(req *model.ReqSignParams) (interface{}, error
 	//		tp.Receiver[:])
 	//}
 	from := tp.Sender
-	privateKeys, err := cs.BaseService.addressOrPublicKeyToPrivate(from)
-	hexPrivateKey, err := hex.DecodeString(privateKeys)
-	//fmt.Println("hexPrivateKey", hexPrivateKey)
-	//log.Info("hexPrivateKey", hexPrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("get private key error,Err=%v", err)
-	}
-	signtx, err := cs.getSignaturetx(hexPrivateKey, tp)
+	var signtx string
+	err = cs.BaseService.withPrivateKey(from, func(key []byte) error {
+		hexPrivateKey, err := hex.DecodeString(secmem.String(key))
+		if err != nil {
+			return fmt.Errorf("get private key error,Err=%v", err)
+		}
+		defer secmem.Wipe(hexPrivateKey)
+		signtx, err = cs.getSignaturetx(hexPrivateKey, tp)
+		return err
+	})
 	return signtx, err
 }
 func (cs *EgldService) ValidAddress(address string) error {
@@ -152,14 +159,10 @@ func (cs *EgldService) TransferService(req interface{}) (interface{}, error) {
 		return nil, err
 	}
 
-	//var hexPrivateKey string
 	//地址校验
-	privateKeys, err := cs.BaseService.addressOrPublicKeyToPrivate(tp.Sender)
-	//hexPrivateKey, err := hex.DecodeString(privateKeys)
-	//fmt.Println("hexPrivateKey", hexPrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("get private key error,Err=%v", err)
+	if err := cs.CheckKey(tp.Sender); err != nil {
 		//地址不是由程序生成，没有对应私钥
+		return nil, fmt.Errorf("get private key error,Err=%v", err)
 	}
 
 	//余额️判断
@@ -183,9 +186,16 @@ func (cs *EgldService) TransferService(req interface{}) (interface{}, error) {
 	if valueAmount.Int64() > balanceAmounts.Int64() {
 		return nil, fmt.Errorf("出账金额大于现有余额%v,出账金额%v,账户余额%v", err, valueAmount.Int64(), balanceAmounts.Int64())
 	}
-	hexPrivateKey, err := hex.DecodeString(privateKeys) //私钥进行decode传入符合签名格式
-	fmt.Println("hexPrivateKey", hexPrivateKey)
-	tx, err := cs.Transfer(hexPrivateKey, tp.Sender, tp.Receiver, tp.Value)
+	var tx string
+	err = cs.BaseService.withPrivateKey(tp.Sender, func(key []byte) error {
+		hexPrivateKey, err := hex.DecodeString(secmem.String(key)) //私钥进行decode传入符合签名格式
+		if err != nil {
+			return err
+		}
+		defer secmem.Wipe(hexPrivateKey)
+		tx, err = cs.Transfer(hexPrivateKey, tp.Sender, tp.Receiver, tp.Value)
+		return err
+	})
 	if err != nil {
 		log.Error("unable to get signature", "error", err)
 	}
@@ -239,6 +249,20 @@ func (cs *EgldService) createAddressInfo() (util.AddrInfo, error) {
 	return addrInfo, nil
 }
 
+// DeriveAddress 由hex私钥推导bech32地址
+func (cs *EgldService) DeriveAddress(key []byte) (string, error) {
+	privkey, err := hex.DecodeString(secmem.String(key))
+	if err != nil {
+		return "", fmt.Errorf("decode private key error: %v", err)
+	}
+	defer secmem.Wipe(privkey)
+	address, err := interactors.NewWallet().GetAddressFromPrivateKey(privkey)
+	if err != nil {
+		return "", err
+	}
+	return address.AddressAsBech32String(), nil
+}
+
 func (cs *EgldService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
 	Balance, err := egld.GetBalance(req.Address)
 	if err != nil {
@@ -0,0 +1,492 @@
+package v1
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/evm"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+const (
+	evmDefaultGasLimit      = 21000
+	evmDefaultTokenGasLimit = 100000
+	// 估算的代币gas上浮20%
+	evmTokenGasMultiplier = 1.2
+)
+
+/*
+evm系列链通用服务
+	bsc/heco/cph/cds等链共用，链之间的差异全部来自配置
+*/
+type EvmService struct {
+	*BaseService
+	coinName string
+	cfg      *conf.EvmChainCfg
+	client   *evm.Client
+	nonces   *evm.NonceManager
+	// 同一地址的出账串行执行，保证nonce连续
+	addrLocks sync.Map
+}
+
+/*
+初始化evm服务
+	注意：
+		不在coinServices中注册，NewIService发现[evm.币种]配置时直接调用
+*/
+func (bs *BaseService) EvmService(coinName string) *EvmService {
+	cfg, ok := conf.Config.EvmCfg[strings.ToLower(coinName)]
+	if !ok {
+		panic(fmt.Errorf("do not find evm config for %s", coinName))
+	}
+	return bs.newEvmService(coinName, cfg)
+}
+
+func init() {
+	registerCoinService("bsc", func(bs *BaseService) services.IService { return bs.BSCService() })
+	registerCoinService("heco", func(bs *BaseService) services.IService { return bs.HECOService() })
+	registerCoinService("cph", func(bs *BaseService) services.IService { return bs.CPHService() })
+	registerCoinService("cds", func(bs *BaseService) services.IService { return bs.CDSService() })
+}
+
+// bsc/heco/cph/cds未配置[evm.币种]时，使用各自原有的配置
+func (bs *BaseService) BSCService() *EvmService {
+	c := conf.Config.BscCfg
+	return bs.newEvmService("bsc", &conf.EvmChainCfg{NodeUrl: c.NodeUrl, User: c.User, Password: c.Password,
+		NetWorkId: c.NetWorkId, GasPrice: c.GasPrice, GasLimit: c.GasLimit})
+}
+
+func (bs *BaseService) HECOService() *EvmService {
+	c := conf.Config.HecoCfg
+	return bs.newEvmService("heco", &conf.EvmChainCfg{NodeUrl: c.NodeUrl, User: c.User, Password: c.Password,
+		NetWorkId: c.NetWorkId, GasPrice: c.GasPrice, GasLimit: c.GasLimit})
+}
+
+func (bs *BaseService) CPHService() *EvmService {
+	c := conf.Config.CphCfg
+	return bs.newEvmService("cph", &conf.EvmChainCfg{NodeUrl: c.NodeUrl, User: c.User, Password: c.Password,
+		NetWorkId: int64(c.NetWorkId), GasPrice: c.GasPrice})
+}
+
+func (bs *BaseService) CDSService() *EvmService {
+	c := conf.Config.CdsCfg
+	return bs.newEvmService("cds", &conf.EvmChainCfg{NodeUrl: c.NodeUrl, User: c.User, Password: c.Password,
+		NetWorkId: int64(c.NetWorkId), GasPrice: c.GasPrice})
+}
+
+func (bs *BaseService) newEvmService(coinName string, cfg *conf.EvmChainCfg) *EvmService {
+	if cfg.GasLimit <= 0 {
+		cfg.GasLimit = evmDefaultGasLimit
+	}
+	client, err := evm.NewClient(cfg.NodeUrl, cfg.User, cfg.Password)
+	if err != nil {
+		panic(fmt.Errorf("init %s evm client error: %v", coinName, err))
+	}
+	cs := new(EvmService)
+	cs.BaseService = bs
+	cs.coinName = strings.ToLower(coinName)
+	cs.cfg = cfg
+	cs.client = client
+	cs.nonces = evm.NewNonceManager()
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *EvmService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *EvmService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create %s address", cs.coinName)
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	私钥保存为不带0x的hex，地址统一为小写
+*/
+func (cs *EvmService) createAddressInfo() (util.AddrInfo, error) {
+	priv, address, err := evm.GenerateKey()
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: priv,
+		Address: address,
+	}, nil
+}
+
+// DeriveAddress 由hex私钥推导小写地址，与生成时保存的格式一致
+func (cs *EvmService) DeriveAddress(key []byte) (string, error) {
+	priv, err := evm.PrivateKeyFromHex(secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	return strings.ToLower(evm.Address(priv).Hex()), nil
+}
+
+/*
+离线签名服务
+	nonce和gas由调用方传入，未传gas_price时使用配置
+	返回交易hash和可直接eth_sendRawTransaction的raw_tx
+*/
+func (cs *EvmService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.EvmSignParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	if tp.Nonce < 0 {
+		return nil, fmt.Errorf("nonce can not be negative: %d", tp.Nonce)
+	}
+	amount, err := cs.parseAmount(tp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	p, err := cs.buildTxParams(tp.ToAddress, tp.ContractAddress, amount)
+	if err != nil {
+		return nil, err
+	}
+	p.Nonce = uint64(tp.Nonce)
+	if tp.GasLimit > 0 {
+		p.GasLimit = uint64(tp.GasLimit)
+	} else if tp.ContractAddress != "" {
+		p.GasLimit = uint64(cs.cfg.TokenGasLimit)
+		if p.GasLimit == 0 {
+			p.GasLimit = evmDefaultTokenGasLimit
+		}
+	}
+	gasPrice := big.NewInt(cs.cfg.GasPrice)
+	if tp.GasPrice != "" {
+		if gasPrice, err = cs.parseAmount(tp.GasPrice); err != nil {
+			return nil, err
+		}
+	}
+	if gasPrice.Sign() <= 0 {
+		return nil, errors.New("gas_price is not set in params or config")
+	}
+	if cs.cfg.Eip1559 {
+		p.GasFeeCap = gasPrice
+		p.GasTipCap = big.NewInt(cs.cfg.MaxPriorityFee)
+		if tp.MaxPriorityFee != "" {
+			if p.GasTipCap, err = cs.parseAmount(tp.MaxPriorityFee); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		p.GasPrice = gasPrice
+	}
+	priv, err := cs.privateKey(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	tx, err := evm.SignTx(priv, p)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := evm.RawTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("%s sign txid is: %s", cs.coinName, tx.Hash().Hex())
+	return map[string]interface{}{
+		"txid":   tx.Hash().Hex(),
+		"nonce":  tx.Nonce(),
+		"raw_tx": raw,
+	}, nil
+}
+
+/*
+热钱包出账服务
+	nonce取本地记录和节点pending nonce中较大的一个，同一地址串行出账
+	is_collect为1时归集全部余额
+*/
+func (cs *EvmService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.EvmTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || (tp.Amount == "" && tp.IsCollect != 1) {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	if err := cs.ValidAddress(tp.FromAddress); err != nil {
+		return nil, err
+	}
+	from := common.HexToAddress(tp.FromAddress)
+	priv, err := cs.privateKey(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	lock := cs.addressLock(tp.FromAddress)
+	lock.Lock()
+	defer lock.Unlock()
+
+	amount := big.NewInt(0)
+	if tp.Amount != "" {
+		if amount, err = cs.parseAmount(tp.Amount); err != nil {
+			return nil, err
+		}
+	}
+	// 归集代币时先查询全部余额，用于构造交易和估算gas
+	if tp.IsCollect == 1 && tp.ContractAddress != "" {
+		if err = cs.ValidAddress(tp.ContractAddress); err != nil {
+			return nil, err
+		}
+		if amount, err = cs.client.TokenBalance(common.HexToAddress(tp.ContractAddress), from); err != nil {
+			return nil, err
+		}
+	}
+	p, err := cs.buildTxParams(tp.ToAddress, tp.ContractAddress, amount)
+	if err != nil {
+		return nil, err
+	}
+	if err = cs.fillGas(p, from); err != nil {
+		return nil, err
+	}
+	if err = cs.checkBalance(&tp, p, from); err != nil {
+		return nil, err
+	}
+	pending, err := cs.client.PendingNonce(from)
+	if err != nil {
+		return nil, err
+	}
+	p.Nonce = cs.nonces.Next(tp.FromAddress, pending)
+	tx, err := evm.SignTx(priv, p)
+	if err != nil {
+		cs.nonces.Reset(tp.FromAddress)
+		return nil, err
+	}
+	if err = cs.client.SendTransaction(tx); err != nil {
+		cs.nonces.Reset(tp.FromAddress)
+		return nil, err
+	}
+	log.Infof("send txid is: %s,nonce is: %d", tx.Hash().Hex(), tx.Nonce())
+	return &model.EvmNonceData{
+		Txid:  tx.Hash().Hex(),
+		Nonce: int64(tx.Nonce()),
+	}, nil
+}
+
+func (cs *EvmService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	if err := cs.ValidAddress(req.Address); err != nil {
+		return nil, err
+	}
+	var (
+		balance *big.Int
+		err     error
+	)
+	if req.ContractAddress != "" {
+		if err = cs.ValidAddress(req.ContractAddress); err != nil {
+			return nil, err
+		}
+		balance, err = cs.client.TokenBalance(common.HexToAddress(req.ContractAddress), common.HexToAddress(req.Address))
+	} else {
+		balance, err = cs.client.Balance(common.HexToAddress(req.Address))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"coin":   req.CoinName,
+		"token":  req.Token,
+		"amount": balance.String(),
+	}, nil
+}
+
+func (cs *EvmService) ValidAddress(address string) error {
+	return evm.ValidAddress(address)
+}
+
+// buildTxParams contractAddress不为空时为代币转账，to为合约地址，value为0
+func (cs *EvmService) buildTxParams(to, contractAddress string, amount *big.Int) (*evm.TxParams, error) {
+	if err := cs.ValidAddress(to); err != nil {
+		return nil, err
+	}
+	if cs.cfg.NetWorkId <= 0 {
+		return nil, fmt.Errorf("%s networkid is not set", cs.coinName)
+	}
+	p := &evm.TxParams{
+		ChainId:  big.NewInt(cs.cfg.NetWorkId),
+		GasLimit: uint64(cs.cfg.GasLimit),
+		Eip1559:  cs.cfg.Eip1559,
+	}
+	if contractAddress == "" {
+		p.To = common.HexToAddress(to)
+		p.Value = amount
+		return p, nil
+	}
+	if err := cs.ValidAddress(contractAddress); err != nil {
+		return nil, err
+	}
+	p.To = common.HexToAddress(contractAddress)
+	p.Value = big.NewInt(0)
+	p.Data = evm.TransferData(common.HexToAddress(to), amount)
+	p.GasLimit = 0
+	return p, nil
+}
+
+// fillGas 热钱包填充gas，配置为0的项从节点获取
+func (cs *EvmService) fillGas(p *evm.TxParams, from common.Address) error {
+	var err error
+	if p.GasLimit == 0 {
+		if cs.cfg.TokenGasLimit > 0 {
+			p.GasLimit = uint64(cs.cfg.TokenGasLimit)
+		} else {
+			gas, err := cs.client.EstimateGas(ethereum.CallMsg{From: from, To: &p.To, Data: p.Data})
+			if err != nil {
+				return err
+			}
+			p.GasLimit = uint64(float64(gas) * evmTokenGasMultiplier)
+		}
+	}
+	if !p.Eip1559 {
+		if cs.cfg.GasPrice > 0 {
+			p.GasPrice = big.NewInt(cs.cfg.GasPrice)
+			return nil
+		}
+		p.GasPrice, err = cs.client.GasPrice()
+		return err
+	}
+	if cs.cfg.MaxPriorityFee > 0 {
+		p.GasTipCap = big.NewInt(cs.cfg.MaxPriorityFee)
+	} else if p.GasTipCap, err = cs.client.GasTipCap(); err != nil {
+		return err
+	}
+	baseFee, err := cs.client.BaseFee()
+	if err != nil {
+		return err
+	}
+	// max fee = 2 * base fee + tip，可以承受base fee连续上涨
+	p.GasFeeCap = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), p.GasTipCap)
+	if cs.cfg.GasPrice > 0 && p.GasFeeCap.Cmp(big.NewInt(cs.cfg.GasPrice)) > 0 {
+		if p.GasTipCap.Cmp(big.NewInt(cs.cfg.GasPrice)) > 0 {
+			return fmt.Errorf("max priority fee %s is greater than gasPrice %d", p.GasTipCap.String(), cs.cfg.GasPrice)
+		}
+		p.GasFeeCap = big.NewInt(cs.cfg.GasPrice)
+	}
+	return nil
+}
+
+// checkBalance 校验余额，主币归集时转出余额扣除手续费上限后的全部金额
+func (cs *EvmService) checkBalance(tp *model.EvmTransferParams, p *evm.TxParams, from common.Address) error {
+	balance, err := cs.client.Balance(from)
+	if err != nil {
+		return err
+	}
+	fee := p.MaxFee()
+	if tp.ContractAddress == "" && tp.IsCollect == 1 {
+		p.Value = new(big.Int).Sub(balance, fee)
+		if p.Value.Sign() <= 0 {
+			return fmt.Errorf("[%s] balance %s is not enough for fee %s", tp.FromAddress, balance.String(), fee.String())
+		}
+		return nil
+	}
+	if tp.ContractAddress == "" && p.Value.Sign() <= 0 {
+		return fmt.Errorf("[%s] amount must be greater than 0", tp.FromAddress)
+	}
+	need := new(big.Int).Add(p.Value, fee)
+	if balance.Cmp(need) < 0 {
+		return fmt.Errorf("[%s] balance is not enough,need=[%s],chainAmount=[%s]", tp.FromAddress, need.String(), balance.String())
+	}
+	if tp.ContractAddress == "" {
+		return nil
+	}
+	tokenBalance, err := cs.client.TokenBalance(p.To, from)
+	if err != nil {
+		return err
+	}
+	amount := new(big.Int).SetBytes(p.Data[len(p.Data)-32:])
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("[%s] token amount is 0", tp.FromAddress)
+	}
+	if tokenBalance.Cmp(amount) < 0 {
+		return fmt.Errorf("[%s] token amount is not enough,transAmount=[%s],chainAmount=[%s]", tp.FromAddress, amount.String(), tokenBalance.String())
+	}
+	return nil
+}
+
+// NormalizeKey 私钥存储中的地址为小写
+func (cs *EvmService) NormalizeKey(key string) string {
+	return strings.ToLower(key)
+}
+
+func (cs *EvmService) addressLock(address string) *sync.Mutex {
+	lock, _ := cs.addrLocks.LoadOrStore(strings.ToLower(address), new(sync.Mutex))
+	return lock.(*sync.Mutex)
+}
+
+func (cs *EvmService) privateKey(address string) (*ecdsa.PrivateKey, error) {
+	var priv *ecdsa.PrivateKey
+	err := cs.BaseService.withPrivateKey(address, func(key []byte) (err error) {
+		priv, err = evm.PrivateKeyFromHex(secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get private key error,Err=%v", err)
+	}
+	if !strings.EqualFold(evm.Address(priv).Hex(), address) {
+		return nil, errors.New("private key is not match address " + address)
+	}
+	return priv, nil
+}
+
+// parseAmount 金额为最小单位的整数
+func (cs *EvmService) parseAmount(amount string) (*big.Int, error) {
+	a, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount error,err=%v", err)
+	}
+	if a.IsNegative() || !a.Equal(a.Truncate(0)) {
+		return nil, fmt.Errorf("amount must be a non-negative integer: %s", amount)
+	}
+	return a.BigInt(), nil
+}
@@ -0,0 +1,321 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/fio"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	// 离线签名无法获取区块时间，使用本机时间，有效期适当放长
+	fioSignExpiration     = 10 * time.Minute
+	fioTransferExpiration = 3 * time.Minute
+)
+
+type FioService struct {
+	*BaseService
+	client *fio.Client
+}
+
+func init() {
+	registerCoinService("fio", func(bs *BaseService) services.IService { return bs.FIOService() })
+}
+
+func (bs *BaseService) FIOService() *FioService {
+	cs := new(FioService)
+	cs.BaseService = bs
+	cs.client = fio.NewClient(conf.Config.FioCfg.NodeUrl)
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *FioService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *FioService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create fio address")
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	fio的账户由公钥推导，这里地址为FIO开头的公钥，私钥保存为wif格式
+*/
+func (cs *FioService) createAddressInfo() (util.AddrInfo, error) {
+	wif, pub, err := fio.GenerateKey()
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: wif,
+		Address: pub,
+	}, nil
+}
+
+// DeriveAddress 由wif私钥推导FIO公钥
+func (cs *FioService) DeriveAddress(key []byte) (string, error) {
+	priv, err := fio.WifToPrivateKey(secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	return fio.PublicKeyToString(priv.PubKey()), nil
+}
+
+/*
+离线签名服务
+	to_address为fio地址时需要通过节点解析为公钥，其余不需要联网
+	返回的transaction可直接提交到transfer_tokens_pub_key接口
+*/
+func (cs *FioService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.FioSignParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" || tp.HeadBlockId == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s],headBlockId=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount, tp.HeadBlockId)
+	}
+	chainId := tp.ChainId
+	if chainId == "" {
+		chainId = conf.Config.FioCfg.ChainId
+	}
+	maxFee := conf.Config.FioCfg.MaxFee
+	if tp.MaxFee != "" {
+		if maxFee, err = cs.parseAmount(tp.MaxFee); err != nil {
+			return nil, err
+		}
+	}
+	if maxFee <= 0 {
+		return nil, errors.New("max_fee is not set in params or config")
+	}
+	tx, err := cs.buildTx(tp.FromAddress, tp.ToAddress, tp.Amount, maxFee, tp.HeadBlockId, time.Now().UTC().Add(fioSignExpiration))
+	if err != nil {
+		return nil, err
+	}
+	ptx, err := cs.sign(tx, tp.FromAddress, chainId)
+	if err != nil {
+		return nil, err
+	}
+	txid, err := tx.Id()
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("fio sign txid is: %s", txid)
+	return map[string]interface{}{
+		"txid":        txid,
+		"transaction": ptx,
+	}, nil
+}
+
+/*
+热钱包出账服务
+	max_fee为空时使用节点返回的transfer_tokens_pub_key手续费
+*/
+func (cs *FioService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.FioTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	var (
+		maxFee int64
+		err    error
+	)
+	if tp.MaxFee != "" {
+		maxFee, err = cs.parseAmount(tp.MaxFee)
+	} else {
+		maxFee, err = cs.client.GetFee(fio.EndpointTransferToken, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	amount, err := cs.parseAmount(tp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := cs.client.GetBalance(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	// 相减比较，避免相加溢出
+	if amount > balance.Available-maxFee {
+		return nil, fmt.Errorf("[%s] amount is not enough,transAmount=[%d],maxFee=[%d],chainAmount=[%d]", tp.FromAddress, amount, maxFee, balance.Available)
+	}
+	info, err := cs.client.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	headTime, err := info.HeadTime()
+	if err != nil {
+		return nil, fmt.Errorf("parse head block time error: %v", err)
+	}
+	tx, err := cs.buildTx(tp.FromAddress, tp.ToAddress, tp.Amount, maxFee, info.HeadBlockId, headTime.Add(fioTransferExpiration))
+	if err != nil {
+		return nil, err
+	}
+	ptx, err := cs.sign(tx, tp.FromAddress, info.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	txid, err := cs.client.TransferTokensPubKey(ptx)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("send txid is: %s", txid)
+	return txid, nil
+}
+
+func (cs *FioService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	if err := cs.ValidAddress(req.Address); err != nil {
+		return nil, err
+	}
+	balance, err := cs.client.GetBalance(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"coin":      req.CoinName,
+		"amount":    fmt.Sprintf("%d", balance.Balance),
+		"available": fmt.Sprintf("%d", balance.Available),
+	}, nil
+}
+
+// ValidAddress 校验FIO公钥
+func (cs *FioService) ValidAddress(address string) error {
+	_, err := fio.ParsePublicKey(address)
+	return err
+}
+
+func (cs *FioService) buildTx(from, to, amount string, maxFee int64, headBlockId string, expiration time.Time) (*fio.Transaction, error) {
+	fromPub, err := fio.ParsePublicKey(from)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := fio.ActorFromPublicKey(fromPub)
+	if err != nil {
+		return nil, err
+	}
+	payee, err := cs.resolvePayee(to)
+	if err != nil {
+		return nil, err
+	}
+	value, err := cs.parseAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+	action, err := fio.NewTransferPubKeyAction(payee, value, maxFee, actor, conf.Config.FioCfg.Tpid)
+	if err != nil {
+		return nil, err
+	}
+	tx := &fio.Transaction{
+		Expiration: expiration,
+		Actions:    []*fio.Action{action},
+	}
+	if err = tx.SetTapos(headBlockId); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// resolvePayee 收款方为fio地址时通过get_pub_address解析为公钥
+func (cs *FioService) resolvePayee(to string) (string, error) {
+	if !fio.IsFioAddress(to) {
+		return to, cs.ValidAddress(to)
+	}
+	if err := fio.ValidFioAddress(to); err != nil {
+		return "", err
+	}
+	pub, err := cs.client.GetPubAddress(to)
+	if err != nil {
+		return "", err
+	}
+	if err = cs.ValidAddress(pub); err != nil {
+		return "", fmt.Errorf("fio address %s resolved to invalid public key %s: %v", to, pub, err)
+	}
+	log.Infof("fio address %s resolved to %s", to, pub)
+	return pub, nil
+}
+
+func (cs *FioService) sign(tx *fio.Transaction, publicKey, chainId string) (*fio.PackedTransaction, error) {
+	priv, err := cs.privateKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	return tx.Sign(priv, chainId)
+}
+
+func (cs *FioService) privateKey(publicKey string) (*btcec.PrivateKey, error) {
+	var priv *btcec.PrivateKey
+	err := cs.BaseService.withPrivateKey(publicKey, func(key []byte) (err error) {
+		priv, err = fio.WifToPrivateKey(secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get private key error,Err=%v", err)
+	}
+	if fio.PublicKeyToString(priv.PubKey()) != publicKey {
+		return nil, errors.New("private key is not match public key " + publicKey)
+	}
+	return priv, nil
+}
+
+// parseAmount 金额单位为SUF
+func (cs *FioService) parseAmount(amount string) (int64, error) {
+	a, err := decimal.NewFromString(amount)
+	if err != nil {
+		return 0, fmt.Errorf("parse amount error,err=%v", err)
+	}
+	if !a.IsPositive() || !a.Equal(a.Truncate(0)) {
+		return 0, fmt.Errorf("amount must be a positive integer in SUF: %s", amount)
+	}
+	if !a.BigInt().IsInt64() {
+		return 0, fmt.Errorf("amount is out of range: %s", amount)
+	}
+	return a.IntPart(), nil
+}
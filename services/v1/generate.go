@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	log "github.com/sirupsen/logrus"
+	"runtime"
+	"sync"
+)
+
+// generateOptions Workers<=0时为cpu数，MaxFailures<=0时为数量的1%+10
+type generateOptions struct {
+	Workers     int
+	MaxFailures int
+}
+
+// generateOptions 按[generate]配置
+func (bs *BaseService) generateOptions() generateOptions {
+	return generateOptions{Workers: conf.Config.GenerateCfg.Workers, MaxFailures: conf.Config.GenerateCfg.MaxFailures}
+}
+
+/*
+generateKeys 使用Workers个协程生成number个地址
+	生成失败、自检不通过或重复的地址不计入结果，继续生成直到数量足够
+	失败次数超过MaxFailures或ctx取消时返回错误，不返回部分结果
+	返回的int为失败并重试的次数
+*/
+func (bs *BaseService) generateKeys(ctx context.Context, number int, opts generateOptions, generateKey generateKeyAndAddress) ([]util.AddrInfo, int, error) {
+	if number <= 0 {
+		return nil, 0, errors.New("create address number must be greater than 0")
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > number {
+		workers = number
+	}
+	maxFailures := opts.MaxFailures
+	if maxFailures <= 0 {
+		maxFailures = number/100 + 10
+	}
+	deriver, _ := bs.srv.(services.AddressDeriver)
+	workCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		infos    = make([]util.AddrInfo, 0, number)
+		seen     = make(map[string]bool, number)
+		failures int
+		lastErr  error
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(workId int) {
+			defer wg.Done()
+			for workCtx.Err() == nil {
+				info, err := generateKey()
+				if err == nil {
+					err = checkGeneratedKey(deriver, info)
+				}
+				mu.Lock()
+				if workCtx.Err() != nil {
+					mu.Unlock()
+					return
+				}
+				if err == nil && seen[info.Address] {
+					err = fmt.Errorf("duplicate address %s", info.Address)
+				}
+				if err != nil {
+					failures++
+					lastErr = err
+					log.Errorf("work_id=[%d] create address error,Err=[%v]", workId, err)
+					if failures > maxFailures {
+						stop()
+					}
+				} else {
+					seen[info.Address] = true
+					infos = append(infos, info)
+					if len(infos) == number {
+						stop()
+					}
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(infos) < number {
+		if failures > maxFailures {
+			return nil, failures, fmt.Errorf("create address failed %d times,exceed max failures %d,last error: %v", failures, maxFailures, lastErr)
+		}
+		return nil, failures, fmt.Errorf("create address canceled,%d/%d created: %v", len(infos), number, ctx.Err())
+	}
+	if failures > 0 {
+		log.Warnf("create %d %s addresses with %d retries", number, bs.coinType, failures)
+	}
+	return infos, failures, nil
+}
+
+/*
+checkGeneratedKey 写入文件前由私钥重新推导地址，确认保存的私钥能恢复该地址
+	币种服务没有实现AddressDeriver时只检查不为空
+*/
+func checkGeneratedKey(deriver services.AddressDeriver, info util.AddrInfo) error {
+	if info.Address == "" || info.PrivKey == "" {
+		return errors.New("address or private key is empty")
+	}
+	if deriver == nil {
+		return nil
+	}
+	key := []byte(info.PrivKey)
+	defer secmem.Wipe(key)
+	derived, err := deriver.DeriveAddress(key)
+	if err != nil {
+		return fmt.Errorf("self check %s error: %v", info.Address, err)
+	}
+	if derived != info.Address {
+		return fmt.Errorf("self check %s error: private key derives a different address %s", info.Address, derived)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDeriver 私钥priv-x推导出地址addr-x
+type fakeDeriver struct {
+	services.IService
+}
+
+func (fakeDeriver) DeriveAddress(key []byte) (string, error) {
+	return strings.Replace(string(key), "priv", "addr", 1), nil
+}
+
+func TestGenerateKeys(t *testing.T) {
+	bs := &BaseService{coinType: "dot", srv: fakeDeriver{}}
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	// 每3个中一个报错、一个私钥与地址不匹配、一个正常
+	generate := func() (util.AddrInfo, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		switch calls % 3 {
+		case 0:
+			return util.AddrInfo{}, errors.New("rand error")
+		case 1:
+			return util.AddrInfo{Address: fmt.Sprintf("addr-%d", calls), PrivKey: fmt.Sprintf("priv-%d", calls+1)}, nil
+		}
+		return util.AddrInfo{Address: fmt.Sprintf("addr-%d", calls), PrivKey: fmt.Sprintf("priv-%d", calls)}, nil
+	}
+	infos, failures, err := bs.generateKeys(context.Background(), 20, generateOptions{Workers: 4, MaxFailures: 100}, generate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 20 || failures < 30 {
+		t.Fatalf("generate %d keys with %d failures", len(infos), failures)
+	}
+	for _, info := range infos {
+		if strings.Replace(info.PrivKey, "priv", "addr", 1) != info.Address {
+			t.Fatalf("key of %s is not checked", info.Address)
+		}
+	}
+
+	// 失败次数超过上限时不返回部分结果
+	infos, _, err = bs.generateKeys(context.Background(), 20, generateOptions{Workers: 4, MaxFailures: 5}, generate)
+	if err == nil || !strings.Contains(err.Error(), "exceed max failures") || infos != nil {
+		t.Fatalf("failure budget error: %v %d", err, len(infos))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err = bs.generateKeys(ctx, 20, generateOptions{}, generate); err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Fatalf("canceled error: %v", err)
+	}
+}
@@ -0,0 +1,310 @@
+package v1
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/gxc"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	gxcAssetId    = "1.3.1"
+	gxcPrecision  = 5
+	gxcExpiration = 60 * time.Second
+)
+
+type GxcService struct {
+	*BaseService
+	client *gxc.Client
+}
+
+func init() {
+	registerCoinService("gxc", func(bs *BaseService) services.IService { return bs.GXCService() })
+}
+
+func (bs *BaseService) GXCService() *GxcService {
+	cs := new(GxcService)
+	cs.BaseService = bs
+	cs.client = gxc.NewClient(conf.Config.GxcCfg.NodeUrl, "", "")
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *GxcService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *GxcService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create gxc address")
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	gxc为账户模型，这里生成的是GXC开头的公钥，由业务方使用公钥注册账户
+	私钥保存为wif格式
+*/
+func (cs *GxcService) createAddressInfo() (util.AddrInfo, error) {
+	wif, pub, err := gxc.GenerateKey()
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: wif,
+		Address: pub,
+	}, nil
+}
+
+// DeriveAddress 由wif私钥推导GXC公钥
+func (cs *GxcService) DeriveAddress(key []byte) (string, error) {
+	priv, err := gxc.WifToPrivateKey(secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	return gxc.PublicKeyToString(priv.PubKey()), nil
+}
+
+/*
+离线签名服务
+	对业务方序列化好的交易签名，返回hex编码的签名
+	chainId为空时使用配置中的chainId
+*/
+func (cs *GxcService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.GxcSignParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if tp.PublicKey == "" || tp.StxHex == "" {
+		return nil, fmt.Errorf("params is null,publicKey=[%s],stxHex=[%s]", tp.PublicKey, tp.StxHex)
+	}
+	chainId := tp.ChainId
+	if chainId == "" {
+		chainId = conf.Config.GxcCfg.ChainId
+	}
+	stx, err := hex.DecodeString(tp.StxHex)
+	if err != nil {
+		return nil, fmt.Errorf("decode stxHex error: %v", err)
+	}
+	priv, err := cs.privateKey(tp.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	sig, err := gxc.SignTransaction(priv, chainId, stx)
+	if err != nil {
+		return nil, err
+	}
+	return hex.EncodeToString(sig), nil
+}
+
+/*
+热钱包出账服务
+	from/to为账户名，publicKey为from账户的active公钥
+	有memo时使用memoKey加密，memoKey未配置则使用from账户的memo_key
+	fee为空时从节点获取
+*/
+func (cs *GxcService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.GxcTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAccount == "" || tp.ToAccount == "" || tp.Amount == "" || tp.PublicKey == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s],publicKey=[%s]", tp.FromAccount, tp.ToAccount, tp.Amount, tp.PublicKey)
+	}
+	if err := gxc.ValidAccountName(tp.FromAccount); err != nil {
+		return nil, err
+	}
+	if err := gxc.ValidAccountName(tp.ToAccount); err != nil {
+		return nil, err
+	}
+	amount, err := cs.toUnit(tp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := cs.privateKey(tp.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.WipeBigInt(priv.D)
+	from, err := cs.client.GetAccount(tp.FromAccount)
+	if err != nil {
+		return nil, err
+	}
+	to, err := cs.client.GetAccount(tp.ToAccount)
+	if err != nil {
+		return nil, err
+	}
+	op := &gxc.TransferOperation{
+		Fee:    gxc.Asset{AssetId: gxcAssetId},
+		From:   from.Id,
+		To:     to.Id,
+		Amount: gxc.Asset{Amount: amount, AssetId: gxcAssetId},
+	}
+	if tp.Memo != "" {
+		if op.Memo, err = cs.encryptMemo(from, to, tp.Memo); err != nil {
+			return nil, err
+		}
+	}
+	if tp.Fee != "" {
+		op.Fee.Amount, err = cs.toUnit(tp.Fee)
+	} else {
+		op.Fee.Amount, err = cs.client.GetRequiredFee(op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	balance, err := cs.client.GetBalance(tp.FromAccount, gxcAssetId)
+	if err != nil {
+		return nil, err
+	}
+	if amount+op.Fee.Amount > balance {
+		return nil, fmt.Errorf("[%s] amount is not enough,transAmount=[%d],fee=[%d],chainAmount=[%d]", tp.FromAccount, amount, op.Fee.Amount, balance)
+	}
+	dgp, err := cs.client.GetDynamicGlobalProperties()
+	if err != nil {
+		return nil, err
+	}
+	headTime, err := dgp.HeadTime()
+	if err != nil {
+		return nil, fmt.Errorf("parse head block time error: %v", err)
+	}
+	tx, err := gxc.NewTransferTransaction(op, dgp.HeadBlockNumber, dgp.HeadBlockId, headTime.Add(gxcExpiration))
+	if err != nil {
+		return nil, err
+	}
+	stx, err := tx.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := gxc.SignTransaction(priv, conf.Config.GxcCfg.ChainId, stx)
+	if err != nil {
+		return nil, err
+	}
+	tx.AddSignature(sig)
+	txid, err := tx.Id()
+	if err != nil {
+		return nil, err
+	}
+	if err = cs.client.BroadcastTransaction(tx); err != nil {
+		return nil, err
+	}
+	log.Infof("send txid is: %s", txid)
+	return &model.RespGxcTransferParams{
+		Txid: txid,
+		Fee:  decimal.New(op.Fee.Amount, -gxcPrecision).String(),
+		Memo: tp.Memo,
+	}, nil
+}
+
+func (cs *GxcService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	if err := gxc.ValidAccountName(req.Address); err != nil {
+		return nil, err
+	}
+	balance, err := cs.client.GetBalance(req.Address, gxcAssetId)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"coin":   req.CoinName,
+		"amount": decimal.New(balance, -gxcPrecision).String(),
+	}, nil
+}
+
+// ValidAddress 支持账户名和GXC公钥
+func (cs *GxcService) ValidAddress(address string) error {
+	if gxc.ValidAccountName(address) == nil {
+		return nil
+	}
+	if _, err := gxc.ParsePublicKey(address); err != nil {
+		return fmt.Errorf("%s is neither account name nor public key: %v", address, err)
+	}
+	return nil
+}
+
+func (cs *GxcService) encryptMemo(from, to *gxc.Account, memo string) (*gxc.Memo, error) {
+	memoKey := conf.Config.GxcCfg.MemoKey
+	if memoKey == "" {
+		memoKey = from.Options.MemoKey
+	}
+	priv, err := cs.privateKey(memoKey)
+	if err != nil {
+		return nil, fmt.Errorf("get memo private key error: %v", err)
+	}
+	defer secmem.WipeBigInt(priv.D)
+	toPub, err := gxc.ParsePublicKey(to.Options.MemoKey)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s memo key error: %v", to.Name, err)
+	}
+	return gxc.EncryptMemo(priv, toPub, memo)
+}
+
+func (cs *GxcService) privateKey(publicKey string) (*btcec.PrivateKey, error) {
+	var priv *btcec.PrivateKey
+	err := cs.BaseService.withPrivateKey(publicKey, func(key []byte) (err error) {
+		priv, err = gxc.WifToPrivateKey(secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get private key error,Err=%v", err)
+	}
+	if gxc.PublicKeyToString(priv.PubKey()) != publicKey {
+		return nil, errors.New("private key is not match public key " + publicKey)
+	}
+	return priv, nil
+}
+
+// toUnit GXC精度为5
+func (cs *GxcService) toUnit(amount string) (int64, error) {
+	a, err := decimal.NewFromString(amount)
+	if err != nil {
+		return 0, fmt.Errorf("parse amount error,err=%v", err)
+	}
+	a = a.Shift(gxcPrecision)
+	if !a.IsPositive() || !a.Equal(a.Truncate(0)) || !a.BigInt().IsInt64() {
+		return 0, fmt.Errorf("invalid gxc amount: %s", amount)
+	}
+	return a.IntPart(), nil
+}
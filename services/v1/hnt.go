@@ -0,0 +1,312 @@
+package v1
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/redis"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/hnt"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+const hntDefaultLockTime = 60
+
+type HntService struct {
+	*BaseService
+	client  *hnt.Client
+	network hnt.Network
+	// 未启用redis时使用内存锁，key为地址，value为锁过期时间
+	lockMu sync.Mutex
+	locks  map[string]time.Time
+}
+
+func init() {
+	registerCoinService("hnt", func(bs *BaseService) services.IService { return bs.HNTService() })
+}
+
+func (bs *BaseService) HNTService() *HntService {
+	cs := new(HntService)
+	cs.BaseService = bs
+	cs.client = hnt.NewClient(conf.Config.HntCfg.NodeUrl)
+	cs.locks = make(map[string]time.Time)
+	cs.network = hnt.Mainnet
+	if conf.Config.HntCfg.Testnet {
+		cs.network = hnt.Testnet
+	}
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *HntService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *HntService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create hnt address")
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	私钥保存为base58编码的64字节ed25519私钥
+*/
+func (cs *HntService) createAddressInfo() (util.AddrInfo, error) {
+	priv, address, err := hnt.GenerateKey(cs.network)
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: priv,
+		Address: address,
+	}, nil
+}
+
+// DeriveAddress 由base58私钥推导地址
+func (cs *HntService) DeriveAddress(key []byte) (string, error) {
+	priv, err := hnt.ParsePrivateKey(secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer secmem.Wipe(priv)
+	return hnt.EncodeAddress(cs.network, priv.Public().(ed25519.PublicKey)), nil
+}
+
+/*
+离线签名服务
+	nonce由调用方传入（链上nonce+1），手续费使用配置的feeMultiplier计算
+	返回base64编码的交易，可直接提交到api的pending_transactions接口
+*/
+func (cs *HntService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.HntTransferParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	if tp.Nonce <= 0 {
+		return nil, fmt.Errorf("nonce must be greater than 0: %d", tp.Nonce)
+	}
+	txn, err := cs.buildTx(&tp, uint64(tp.Nonce), conf.Config.HntCfg.FeeMultiplier)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("hnt sign txid is: %s", txn.Hash())
+	return txn.ToBase64(), nil
+}
+
+/*
+热钱包出账服务
+	nonce从api获取，出账成功后地址锁定lockTime秒，避免speculative_nonce未更新时重复使用nonce
+*/
+func (cs *HntService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.HntTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	if err := cs.ValidAddress(tp.FromAddress); err != nil {
+		return nil, err
+	}
+	if err := cs.lock(tp.FromAddress); err != nil {
+		return nil, err
+	}
+	txid, err := cs.transfer(&tp)
+	if err != nil {
+		// 未广播成功，释放锁
+		cs.unlock(tp.FromAddress)
+		return nil, err
+	}
+	log.Infof("send txid is: %s", txid)
+	return txid, nil
+}
+
+func (cs *HntService) transfer(tp *model.HntTransferParams) (string, error) {
+	account, err := cs.client.GetAccount(tp.FromAddress)
+	if err != nil {
+		return "", err
+	}
+	multiplier, err := cs.client.GetTxnFeeMultiplier()
+	if err != nil {
+		return "", err
+	}
+	txn, err := cs.buildTx(tp, account.SpeculativeNonce+1, multiplier)
+	if err != nil {
+		return "", err
+	}
+	// dc不足时链上会按预言机价格燃烧hnt支付手续费，这里只校验转账金额
+	if txn.Payments[0].Amount > account.Balance {
+		return "", fmt.Errorf("[%s] amount is not enough,transAmount=[%d],chainAmount=[%d]", tp.FromAddress, txn.Payments[0].Amount, account.Balance)
+	}
+	if txn.Fee > account.DcBalance {
+		log.Warnf("[%s] dc balance %d is less than fee %d,hnt will be burned for fee", tp.FromAddress, account.DcBalance, txn.Fee)
+	}
+	hash, err := cs.client.SubmitTransaction(txn.ToBase64())
+	if err != nil {
+		return "", err
+	}
+	if hash != txn.Hash() {
+		log.Warnf("hnt submit hash %s is not equal to local txid %s", hash, txn.Hash())
+	}
+	return hash, nil
+}
+
+func (cs *HntService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	if err := cs.ValidAddress(req.Address); err != nil {
+		return nil, err
+	}
+	account, err := cs.client.GetAccount(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"coin":       req.CoinName,
+		"amount":     fmt.Sprintf("%d", account.Balance),
+		"dc_balance": fmt.Sprintf("%d", account.DcBalance),
+	}, nil
+}
+
+func (cs *HntService) ValidAddress(address string) error {
+	_, err := hnt.DecodeAddress(cs.network, address)
+	return err
+}
+
+func (cs *HntService) buildTx(tp *model.HntTransferParams, nonce, multiplier uint64) (*hnt.PaymentV2, error) {
+	payer, err := hnt.DecodeAddress(cs.network, tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	payee, err := hnt.DecodeAddress(cs.network, tp.ToAddress)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := cs.parseAmount(tp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := cs.privateKey(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.Wipe(priv)
+	txn := &hnt.PaymentV2{
+		Payer:    payer,
+		Payments: []hnt.Payment{{Payee: payee, Amount: amount}},
+		Nonce:    nonce,
+	}
+	txn.Fee = txn.CalculateFee(multiplier)
+	if err = txn.Sign(priv); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+func (cs *HntService) privateKey(address string) (ed25519.PrivateKey, error) {
+	var priv ed25519.PrivateKey
+	err := cs.BaseService.withPrivateKey(address, func(key []byte) (err error) {
+		priv, err = hnt.ParsePrivateKey(secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get private key error,Err=%v", err)
+	}
+	return priv, nil
+}
+
+/*
+地址锁
+	启用redis时使用SetNX，多实例之间共享；否则使用内存锁
+*/
+func (cs *HntService) lock(address string) error {
+	lockTime := conf.Config.HntCfg.LockTime
+	if lockTime <= 0 {
+		lockTime = hntDefaultLockTime
+	}
+	expiration := time.Duration(lockTime) * time.Second
+	if redis.Client != nil {
+		ok, err := redis.Client.SetNX(redis.GetHntAddressLockKey(address), time.Now().Unix(), expiration)
+		if err != nil {
+			return fmt.Errorf("lock address %s error: %v", address, err)
+		}
+		if !ok {
+			return fmt.Errorf("address %s is locked by a pending transaction,please retry later", address)
+		}
+		return nil
+	}
+	cs.lockMu.Lock()
+	defer cs.lockMu.Unlock()
+	now := time.Now()
+	if until, ok := cs.locks[address]; ok && now.Before(until) {
+		return fmt.Errorf("address %s is locked by a pending transaction,please retry after %s", address, until.Format("15:04:05"))
+	}
+	cs.locks[address] = now.Add(expiration)
+	return nil
+}
+
+func (cs *HntService) unlock(address string) {
+	if redis.Client != nil {
+		if err := redis.Client.Del(redis.GetHntAddressLockKey(address)); err != nil {
+			log.Errorf("unlock address %s error: %v", address, err)
+		}
+		return
+	}
+	cs.lockMu.Lock()
+	delete(cs.locks, address)
+	cs.lockMu.Unlock()
+}
+
+func (cs *HntService) parseAmount(amount string) (uint64, error) {
+	a, err := decimal.NewFromString(amount)
+	if err != nil {
+		return 0, fmt.Errorf("parse amount error,err=%v", err)
+	}
+	if !a.IsPositive() || !a.Equal(a.Truncate(0)) {
+		return 0, fmt.Errorf("amount must be a positive integer in bones: %s", amount)
+	}
+	if !a.BigInt().IsUint64() {
+		return 0, fmt.Errorf("amount is out of range: %s", amount)
+	}
+	return a.BigInt().Uint64(), nil
+}
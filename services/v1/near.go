@@ -0,0 +1,332 @@
+package v1
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/near"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"math/big"
+)
+
+const nearDefaultAccountSuffix = "near"
+
+type NearService struct {
+	*BaseService
+	client *near.Client
+}
+
+func init() {
+	registerCoinService("near", func(bs *BaseService) services.IService { return bs.NEARService() })
+}
+
+func (bs *BaseService) NEARService() *NearService {
+	cs := new(NearService)
+	cs.BaseService = bs
+	cs.client = near.NewClient(conf.Config.NearCfg.NodeUrl, "", "")
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *NearService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *NearService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create near address")
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	地址为隐式账户（公钥hex），收到near后链上自动创建账户
+	私钥格式为ed25519:base58，与near-cli一致
+*/
+func (cs *NearService) createAddressInfo() (util.AddrInfo, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: near.PrivateKeyString(priv),
+		Address: near.ImplicitAccountId(pub),
+	}, nil
+}
+
+// DeriveAddress 由私钥推导隐式账户
+func (cs *NearService) DeriveAddress(key []byte) (string, error) {
+	priv, err := near.ParsePrivateKey(secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer secmem.Wipe(priv)
+	return near.ImplicitAccountId(priv.Public().(ed25519.PublicKey)), nil
+}
+
+/*
+离线签名服务
+	nonce和block_hash由调用方传入，返回base64编码的已签名交易
+*/
+func (cs *NearService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.NearSignParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	if tp.BlockHash == "" || tp.Nonce == 0 {
+		return nil, fmt.Errorf("params is null,block_hash=[%s],nonce=[%d]", tp.BlockHash, tp.Nonce)
+	}
+	amount, err := cs.parseAmount(tp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	var storageDeposit *big.Int
+	if tp.StorageDeposit != "" {
+		if tp.ContractAddress == "" {
+			return nil, fmt.Errorf("storage_deposit is only used for token transfer")
+		}
+		if storageDeposit, err = cs.parseAmount(tp.StorageDeposit); err != nil {
+			return nil, err
+		}
+	}
+	priv, err := cs.privateKey(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.Wipe(priv)
+	tx, err := cs.buildTx(priv, tp.FromAddress, tp.ToAddress, tp.ContractAddress, amount, storageDeposit)
+	if err != nil {
+		return nil, err
+	}
+	tx.Nonce = tp.Nonce
+	tx.BlockHash = tp.BlockHash
+	signedTx, hash, err := tx.Sign(priv)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("near sign txid is: %s", hash)
+	return signedTx, nil
+}
+
+/*
+热钱包出账服务
+	nonce和block_hash从access key获取，token转账时接收账户未注册则自动附加storage_deposit
+*/
+func (cs *NearService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.NearTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	amount, err := cs.parseAmount(tp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := cs.privateKey(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.Wipe(priv)
+	balance, err := cs.client.GetBalance(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	var storageDeposit *big.Int
+	if tp.ContractAddress != "" {
+		tokenBalance, err := cs.client.FtBalanceOf(tp.ContractAddress, tp.FromAddress)
+		if err != nil {
+			return nil, err
+		}
+		if amount.Cmp(tokenBalance) > 0 {
+			return nil, fmt.Errorf("[%s] token amount is not enough,transAmount=[%s],chainAmount=[%s]", tp.FromAddress, amount.String(), tokenBalance.String())
+		}
+		registered, err := cs.client.IsStorageRegistered(tp.ContractAddress, tp.ToAddress)
+		if err != nil {
+			return nil, err
+		}
+		if !registered {
+			if storageDeposit, err = cs.client.StorageDepositMin(tp.ContractAddress); err != nil {
+				return nil, err
+			}
+			if storageDeposit.Cmp(balance) >= 0 {
+				return nil, fmt.Errorf("[%s] near amount is not enough for storage deposit,deposit=[%s],chainAmount=[%s]", tp.FromAddress, storageDeposit.String(), balance.String())
+			}
+			log.Infof("%s is not registered in %s,storage deposit %s", tp.ToAddress, tp.ContractAddress, storageDeposit.String())
+		}
+	} else if amount.Cmp(balance) >= 0 {
+		return nil, fmt.Errorf("[%s] amount is not enough,transAmount=[%s],chainAmount=[%s]", tp.FromAddress, amount.String(), balance.String())
+	}
+	tx, err := cs.buildTx(priv, tp.FromAddress, tp.ToAddress, tp.ContractAddress, amount, storageDeposit)
+	if err != nil {
+		return nil, err
+	}
+	ak, err := cs.client.ViewAccessKey(tp.FromAddress, near.PublicKeyString(tx.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	tx.Nonce = ak.Nonce + 1
+	tx.BlockHash = ak.BlockHash
+	signedTx, hash, err := tx.Sign(priv)
+	if err != nil {
+		return nil, err
+	}
+	txid, err := cs.client.BroadcastTxAsync(signedTx)
+	if err != nil {
+		return nil, err
+	}
+	if txid != hash {
+		log.Warnf("near broadcast hash %s is not equal to local txid %s", txid, hash)
+	}
+	log.Infof("send txid is: %s", txid)
+	return txid, nil
+}
+
+func (cs *NearService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	if err := cs.ValidAddress(req.Address); err != nil {
+		return nil, err
+	}
+	if req.ContractAddress != "" {
+		balance, err := cs.client.FtBalanceOf(req.ContractAddress, req.Address)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{
+			"coin":   req.Token,
+			"amount": balance.String(),
+		}, nil
+	}
+	balance, err := cs.client.GetBalance(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"coin":   req.CoinName,
+		"amount": balance.String(),
+	}, nil
+}
+
+func (cs *NearService) ValidAddress(address string) error {
+	suffix := conf.Config.NearCfg.AccountSuffix
+	if suffix == "" {
+		suffix = nearDefaultAccountSuffix
+	}
+	return near.ValidAccountId(address, suffix)
+}
+
+/*
+构造交易
+	near转账：receiver为接收账户，一个Transfer action
+	token转账：receiver为token合约，需要注册时先storage_deposit再ft_transfer
+*/
+func (cs *NearService) buildTx(priv ed25519.PrivateKey, from, to, contract string, amount, storageDeposit *big.Int) (*near.Transaction, error) {
+	if err := cs.ValidAddress(to); err != nil {
+		return nil, err
+	}
+	tx := &near.Transaction{
+		SignerId:   from,
+		PublicKey:  priv.Public().(ed25519.PublicKey),
+		ReceiverId: to,
+	}
+	if contract == "" {
+		action, err := near.NewTransferAction(amount)
+		if err != nil {
+			return nil, err
+		}
+		tx.Actions = append(tx.Actions, action)
+		return tx, nil
+	}
+	// 合约账户不限制后缀，如顶级账户或其他网络的合约
+	if err := near.ValidAccountId(contract, ""); err != nil {
+		return nil, fmt.Errorf("contract address error: %v", err)
+	}
+	gas := conf.Config.NearCfg.FtGas
+	if gas == 0 {
+		gas = near.DefaultFunctionCallGas
+	}
+	tx.ReceiverId = contract
+	if storageDeposit != nil {
+		action, err := near.NewStorageDepositAction(to, storageDeposit, gas)
+		if err != nil {
+			return nil, err
+		}
+		tx.Actions = append(tx.Actions, action)
+	}
+	action, err := near.NewFtTransferAction(to, amount, gas)
+	if err != nil {
+		return nil, err
+	}
+	tx.Actions = append(tx.Actions, action)
+	return tx, nil
+}
+
+func (cs *NearService) privateKey(address string) (ed25519.PrivateKey, error) {
+	if err := cs.ValidAddress(address); err != nil {
+		return nil, err
+	}
+	var priv ed25519.PrivateKey
+	err := cs.BaseService.withPrivateKey(address, func(key []byte) (err error) {
+		priv, err = near.ParsePrivateKey(secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get private key error,Err=%v", err)
+	}
+	if near.IsImplicitAccount(address) && near.ImplicitAccountId(priv.Public().(ed25519.PublicKey)) != address {
+		return nil, fmt.Errorf("private key is not match address %s", address)
+	}
+	return priv, nil
+}
+
+func (cs *NearService) parseAmount(amount string) (*big.Int, error) {
+	a, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount error,err=%v", err)
+	}
+	if !a.IsPositive() || !a.Equal(a.Truncate(0)) {
+		return nil, fmt.Errorf("amount must be a positive integer in minimal unit: %s", amount)
+	}
+	return a.BigInt(), nil
+}
@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/services"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+// coinServiceFunc 创建币种服务，构造时节点等配置错误会panic
+type coinServiceFunc func(bs *BaseService) services.IService
+
+// coinServices 各币种文件在init中注册，key为小写币种
+var coinServices = make(map[string]coinServiceFunc)
+
+func registerCoinService(coin string, fn coinServiceFunc) {
+	coin = strings.ToLower(coin)
+	if _, ok := coinServices[coin]; ok {
+		panic(fmt.Sprintf("coin service %s is registered twice", coin))
+	}
+	coinServices[coin] = fn
+}
+
+/*
+NewIService 创建币种的服务
+	依次匹配[substrate.币种]、[evm.币种]配置和coinServices中注册的币种
+	不支持的币种直接返回错误，已注册币种初始化失败(panic)时返回错误
+*/
+func NewIService(coinType string) (services.IService, error) {
+	coin := strings.ToLower(strings.TrimSpace(coinType))
+	if coin == "" {
+		return nil, errors.New("coin type is empty")
+	}
+	newService, err := coinServiceOf(coin)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := newBaseService(coin)
+	if err != nil {
+		return nil, err
+	}
+	srv, err := callCoinService(coin, newService, bs)
+	if err != nil {
+		return nil, err
+	}
+	// 核对私钥文件、导入观察地址时使用币种的推导和校验方法
+	bs.srv = srv
+	if bs.generateKey != nil {
+		if err = bs.openAddressJobs(bs.generateKey); err != nil {
+			return nil, err
+		}
+	}
+	return srv, nil
+}
+
+func coinServiceOf(coin string) (coinServiceFunc, error) {
+	// substrate系列链只需配置即可使用
+	if _, ok := conf.Config.SubstrateCfg[coin]; ok {
+		return func(bs *BaseService) services.IService { return bs.SubstrateService(coin) }, nil
+	}
+	// evm系列链同理
+	if _, ok := conf.Config.EvmCfg[coin]; ok {
+		return func(bs *BaseService) services.IService { return bs.EvmService(coin) }, nil
+	}
+	newService, ok := coinServices[coin]
+	if !ok {
+		return nil, fmt.Errorf("unsupported coin type: %s", coin)
+	}
+	return newService, nil
+}
+
+// callCoinService 币种的构造方法在节点配置错误时panic，转为启动错误
+func callCoinService(coin string, newService coinServiceFunc, bs *BaseService) (srv services.IService, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			srv, err = nil, fmt.Errorf("init %s service error: %v", coin, r)
+		}
+	}()
+	return newService(bs), nil
+}
+
+/*
+Registry 同一进程内启用的币种服务
+	启动时一次性创建，之后只读
+*/
+type Registry struct {
+	coinTypes []string
+	services  map[string]services.IService
+}
+
+func NewRegistry(coinTypes []string) (*Registry, error) {
+	if len(coinTypes) == 0 {
+		return nil, errors.New("coin types is empty")
+	}
+	r := &Registry{services: make(map[string]services.IService)}
+	for _, coinType := range coinTypes {
+		coin := strings.ToLower(strings.TrimSpace(coinType))
+		if _, ok := r.services[coin]; ok {
+			return nil, fmt.Errorf("duplicate coin type: %s", coinType)
+		}
+		srv, err := NewIService(coin)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("register %s sign service", coin)
+		r.coinTypes = append(r.coinTypes, coin)
+		r.services[coin] = srv
+	}
+	return r, nil
+}
+
+// CoinTypes 已注册的币种，小写，按配置顺序
+func (r *Registry) CoinTypes() []string {
+	return append([]string{}, r.coinTypes...)
+}
+
+func (r *Registry) Get(coinType string) (services.IService, bool) {
+	srv, ok := r.services[strings.ToLower(coinType)]
+	return srv, ok
+}
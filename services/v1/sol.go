@@ -0,0 +1,445 @@
+package v1
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/services"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/group-coldwallet/trxsign/util/sol"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"math/big"
+	"time"
+)
+
+const (
+	solLamportsPerSignature  = 5000
+	solTokenAccountRent      = 2039280 //165字节token账户的免租金额
+	solDefaultConfirmTimeout = 60
+	solConfirmPollInterval   = 2 * time.Second
+)
+
+type SolService struct {
+	*BaseService
+	client *sol.Client
+}
+
+func init() {
+	registerCoinService("sol", func(bs *BaseService) services.IService { return bs.SOLService() })
+}
+
+func (bs *BaseService) SOLService() *SolService {
+	cs := new(SolService)
+	cs.BaseService = bs
+	// 初始化连接
+	cs.client = sol.NewClient(conf.Config.SolCfg.NodeUrl, conf.Config.SolCfg.User, conf.Config.SolCfg.Password)
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *SolService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *SolService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create sol address")
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	私钥保存为base58编码的64字节私钥，可直接导入钱包
+*/
+func (cs *SolService) createAddressInfo() (util.AddrInfo, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: base58.Encode(priv),
+		Address: base58.Encode(pub),
+	}, nil
+}
+
+// DeriveAddress 由base58私钥推导地址
+func (cs *SolService) DeriveAddress(key []byte) (string, error) {
+	priv, err := sol.PrivateKeyFromBase58(secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer secmem.Wipe(priv)
+	return sol.PublicKeyFromPrivateKey(priv).String(), nil
+}
+
+/*
+离线签名服务
+	返回base64编码的已签名交易
+*/
+func (cs *SolService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.SolSignParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	if tp.RecentBlockHash == "" {
+		return nil, errors.New("recent block hash is null")
+	}
+	amount, err := cs.parseAmount(tp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	nonceAuthority := tp.NonceAuthority
+	if nonceAuthority == "" {
+		nonceAuthority = tp.FromAddress
+	}
+	var instructions []sol.Instruction
+	if tp.ContractAddress != "" {
+		if tp.Decimals == nil {
+			return nil, fmt.Errorf("decimals of mint %s is null", tp.ContractAddress)
+		}
+		instructions, err = cs.tokenTransferInstructions(tp.FromAddress, tp.ToAddress, tp.ContractAddress, amount, *tp.Decimals, tp.CreateAta)
+	} else {
+		instructions, err = cs.transferInstructions(tp.FromAddress, tp.ToAddress, amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tx, err := cs.buildTx(tp.FromAddress, tp.RecentBlockHash, tp.NonceAccount, nonceAuthority, instructions)
+	if err != nil {
+		return nil, err
+	}
+	return tx.ToBase64(), nil
+}
+
+/*
+热钱包出账服务
+	未指定nonce账户时使用最新区块hash，广播后轮询等待确认
+*/
+func (cs *SolService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.SolTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	amount, err := cs.parseAmount(tp.Amount)
+	if err != nil {
+		return nil, err
+	}
+	var instructions []sol.Instruction
+	if tp.ContractAddress != "" {
+		instructions, err = cs.prepareTokenTransfer(&tp, amount)
+	} else {
+		instructions, err = cs.prepareTransfer(&tp, amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var blockHash, nonceAuthority string
+	if tp.NonceAccount != "" {
+		nonce, err := cs.client.GetNonceAccount(tp.NonceAccount)
+		if err != nil {
+			return nil, err
+		}
+		blockHash = nonce.Nonce.String()
+		nonceAuthority = nonce.Authority.String()
+	} else {
+		blockHash, err = cs.client.GetLatestBlockHash()
+		if err != nil {
+			return nil, err
+		}
+	}
+	tx, err := cs.buildTx(tp.FromAddress, blockHash, tp.NonceAccount, nonceAuthority, instructions)
+	if err != nil {
+		return nil, err
+	}
+	txid, err := cs.client.SendTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("send txid is: %s", txid)
+	timeout := conf.Config.SolCfg.ConfirmTimeout
+	if timeout <= 0 {
+		timeout = solDefaultConfirmTimeout
+	}
+	if err = cs.client.WaitForConfirmation(txid, time.Duration(timeout)*time.Second, solConfirmPollInterval); err != nil {
+		return nil, fmt.Errorf("txid=[%s] %v", txid, err)
+	}
+	return txid, nil
+}
+
+/*
+获取余额
+	contract_address不为空时返回该mint的token余额，否则返回sol余额以及按mint汇总的所有token余额
+*/
+func (cs *SolService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	if req.ContractAddress != "" {
+		balances, err := cs.client.GetTokenBalances(req.Address, req.ContractAddress)
+		if err != nil {
+			return nil, err
+		}
+		resp := map[string]interface{}{
+			"coin":   req.Token,
+			"amount": "0",
+		}
+		if b, ok := balances[req.ContractAddress]; ok {
+			resp["amount"] = b.Amount
+			resp["decimals"] = b.Decimals
+		}
+		return resp, nil
+	}
+	balance, err := cs.client.GetBalance(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := cs.client.GetTokenBalances(req.Address, "")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"coin":   req.CoinName,
+		"amount": decimal.NewFromInt(int64(balance)).String(),
+		"tokens": tokens,
+	}, nil
+}
+
+func (cs *SolService) ValidAddress(address string) error {
+	_, err := sol.PublicKeyFromBase58(address)
+	return err
+}
+
+// prepareTransfer 热钱包sol转账前校验余额
+func (cs *SolService) prepareTransfer(tp *model.SolTransferParams, lamports uint64) ([]sol.Instruction, error) {
+	balance, err := cs.client.GetBalance(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	if lamports+solLamportsPerSignature > balance {
+		return nil, fmt.Errorf("[%s] amount is not enough,transAmount=[%d],chainAmount=[%d]", tp.FromAddress, lamports, balance)
+	}
+	return cs.transferInstructions(tp.FromAddress, tp.ToAddress, lamports)
+}
+
+/*
+prepareTokenTransfer 热钱包token转账前准备
+	从链上获取mint精度，校验token余额和手续费，接收地址ATA不存在时根据create_ata决定是否创建
+*/
+func (cs *SolService) prepareTokenTransfer(tp *model.SolTransferParams, amount uint64) ([]sol.Instruction, error) {
+	fromPk, toPk, mintPk, err := cs.parseTokenAccounts(tp.FromAddress, tp.ToAddress, tp.ContractAddress)
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := cs.client.GetMintDecimals(tp.ContractAddress)
+	if err != nil {
+		return nil, err
+	}
+	source, err := sol.FindAssociatedTokenAddress(fromPk, mintPk)
+	if err != nil {
+		return nil, err
+	}
+	tokenBalance, err := cs.client.GetTokenAccountBalance(source.String())
+	if err != nil {
+		return nil, err
+	}
+	chainAmount, err := decimal.NewFromString(tokenBalance.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("parse token balance error: %v", err)
+	}
+	if decimal.NewFromBigInt(new(big.Int).SetUint64(amount), 0).GreaterThan(chainAmount) {
+		return nil, fmt.Errorf("[%s] amount is not enough,contract_address=[%s],transAmount=[%d],chainAmount=[%s]",
+			tp.FromAddress, tp.ContractAddress, amount, chainAmount.String())
+	}
+	destination, err := sol.FindAssociatedTokenAddress(toPk, mintPk)
+	if err != nil {
+		return nil, err
+	}
+	destData, err := cs.client.GetAccountData(destination.String())
+	if err != nil {
+		return nil, err
+	}
+	createAta := destData == nil
+	if createAta && !tp.CreateAta {
+		return nil, fmt.Errorf("to address %s associated token account %s is not exist", tp.ToAddress, destination.String())
+	}
+	fee := uint64(solLamportsPerSignature)
+	if createAta {
+		fee += solTokenAccountRent
+	}
+	balance, err := cs.client.GetBalance(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	if fee > balance {
+		return nil, fmt.Errorf("from=[%s] fee[%d] is less than %d lamports", tp.FromAddress, balance, fee)
+	}
+	return cs.tokenTransferInstructions(tp.FromAddress, tp.ToAddress, tp.ContractAddress, amount, decimals, createAta)
+}
+
+func (cs *SolService) transferInstructions(from, to string, lamports uint64) ([]sol.Instruction, error) {
+	fromPk, err := sol.PublicKeyFromBase58(from)
+	if err != nil {
+		return nil, fmt.Errorf("from address error: %v", err)
+	}
+	toPk, err := sol.PublicKeyFromBase58(to)
+	if err != nil {
+		return nil, fmt.Errorf("to address error: %v", err)
+	}
+	return []sol.Instruction{sol.NewTransferInstruction(fromPk, toPk, lamports)}, nil
+}
+
+/*
+tokenTransferInstructions 构建spl token转账指令
+	to为接收者钱包地址，发送和接收的token账户均为对应的关联token账户(ATA)
+*/
+func (cs *SolService) tokenTransferInstructions(from, to, mint string, amount uint64, decimals uint8, createAta bool) ([]sol.Instruction, error) {
+	fromPk, toPk, mintPk, err := cs.parseTokenAccounts(from, to, mint)
+	if err != nil {
+		return nil, err
+	}
+	source, err := sol.FindAssociatedTokenAddress(fromPk, mintPk)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := sol.FindAssociatedTokenAddress(toPk, mintPk)
+	if err != nil {
+		return nil, err
+	}
+	var instructions []sol.Instruction
+	if createAta {
+		ins, err := sol.NewCreateAssociatedTokenAccountInstruction(fromPk, toPk, mintPk)
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, ins)
+	}
+	instructions = append(instructions, sol.NewTransferCheckedInstruction(source, mintPk, destination, fromPk, amount, decimals))
+	return instructions, nil
+}
+
+func (cs *SolService) parseTokenAccounts(from, to, mint string) (fromPk, toPk, mintPk sol.PublicKey, err error) {
+	if fromPk, err = sol.PublicKeyFromBase58(from); err != nil {
+		err = fmt.Errorf("from address error: %v", err)
+		return
+	}
+	if toPk, err = sol.PublicKeyFromBase58(to); err != nil {
+		err = fmt.Errorf("to address error: %v", err)
+		return
+	}
+	if mintPk, err = sol.PublicKeyFromBase58(mint); err != nil {
+		err = fmt.Errorf("contract address error: %v", err)
+	}
+	return
+}
+
+/*
+buildTx 组装并签名交易
+	nonceAccount不为空时在最前面插入AdvanceNonceAccount指令，blockHash需为nonce账户中的nonce值
+*/
+func (cs *SolService) buildTx(from, blockHash, nonceAccount, nonceAuthority string, instructions []sol.Instruction) (*sol.Transaction, error) {
+	fromPk, err := sol.PublicKeyFromBase58(from)
+	if err != nil {
+		return nil, fmt.Errorf("from address error: %v", err)
+	}
+	fromKey, err := cs.getPrivateKey(from)
+	if err != nil {
+		return nil, err
+	}
+	defer secmem.Wipe(fromKey)
+	keys := []ed25519.PrivateKey{fromKey}
+	if nonceAccount != "" {
+		noncePk, err := sol.PublicKeyFromBase58(nonceAccount)
+		if err != nil {
+			return nil, fmt.Errorf("nonce account error: %v", err)
+		}
+		authorityPk, err := sol.PublicKeyFromBase58(nonceAuthority)
+		if err != nil {
+			return nil, fmt.Errorf("nonce authority error: %v", err)
+		}
+		if authorityPk != fromPk {
+			authorityKey, err := cs.getPrivateKey(nonceAuthority)
+			if err != nil {
+				return nil, err
+			}
+			defer secmem.Wipe(authorityKey)
+			keys = append(keys, authorityKey)
+		}
+		instructions = append([]sol.Instruction{sol.NewAdvanceNonceAccountInstruction(noncePk, authorityPk)}, instructions...)
+	}
+	msg, err := sol.NewMessage(fromPk, instructions, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return sol.NewTransaction(msg, keys...)
+}
+
+func (cs *SolService) getPrivateKey(address string) (ed25519.PrivateKey, error) {
+	var priv ed25519.PrivateKey
+	err := cs.BaseService.withPrivateKey(address, func(key []byte) (err error) {
+		priv, err = sol.PrivateKeyFromBase58(secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parse private key error,address=[%s],Err=%v", address, err)
+	}
+	if sol.PublicKeyFromPrivateKey(priv).String() != address {
+		return nil, fmt.Errorf("private key is not match address %s", address)
+	}
+	return priv, nil
+}
+
+func (cs *SolService) parseAmount(amount string) (uint64, error) {
+	a, err := decimal.NewFromString(amount)
+	if err != nil {
+		return 0, fmt.Errorf("parse amount error,err=%v", err)
+	}
+	if !a.IsPositive() || !a.Equal(a.Truncate(0)) {
+		return 0, fmt.Errorf("amount must be a positive integer in minimal unit: %s", amount)
+	}
+	if !a.BigInt().IsUint64() {
+		return 0, fmt.Errorf("amount is out of range: %s", amount)
+	}
+	return a.BigInt().Uint64(), nil
+}
@@ -0,0 +1,331 @@
+package v1
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/conf"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/group-coldwallet/trxsign/util/substrate"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"math/big"
+	"strings"
+)
+
+const (
+	substrateDefaultEraPeriod = 64
+	substrateDefaultRefCounts = 3
+	substrateDefaultKeyType   = substrate.KeyTypeSr25519
+)
+
+/*
+substrate系列链通用服务
+	dot/ksm/cring/fis/ori/pcx等链共用，链之间的差异全部来自[substrate.币种]配置
+*/
+type SubstrateService struct {
+	*BaseService
+	coinName string
+	cfg      *conf.SubstrateChainCfg
+	chain    *substrate.Chain
+	client   *substrate.Client
+}
+
+/*
+初始化substrate服务
+	注意：
+		不在coinServices中注册，NewIService发现[substrate.币种]配置时直接调用
+*/
+func (bs *BaseService) SubstrateService(coinName string) *SubstrateService {
+	cfg, ok := conf.Config.SubstrateCfg[strings.ToLower(coinName)]
+	if !ok {
+		panic(fmt.Errorf("do not find substrate config for %s", coinName))
+	}
+	if cfg.KeyType == "" {
+		cfg.KeyType = substrateDefaultKeyType
+	}
+	if cfg.AccountRefCounts <= 0 {
+		cfg.AccountRefCounts = substrateDefaultRefCounts
+	}
+	chain, err := substrate.NewChain(cfg.Ss58Prefix, cfg.KeyType, cfg.CallIndex, cfg.AddressType, cfg.CheckMetadataHash)
+	if err != nil {
+		panic(fmt.Errorf("init %s substrate chain error: %v", coinName, err))
+	}
+	cs := new(SubstrateService)
+	cs.BaseService = bs
+	cs.coinName = strings.ToLower(coinName)
+	cs.cfg = cfg
+	cs.chain = chain
+	cs.client = substrate.NewClient(cfg.NodeUrl, cfg.User, cfg.Password)
+	bs.generateKey = cs.createAddressInfo
+	return cs
+}
+
+/*
+接口创建地址服务
+	无需改动
+*/
+func (cs *SubstrateService) CreateAddressService(req *model.ReqCreateAddressParamsV2) (*model.RespCreateAddressParams, error) {
+	if req.Count == 0 {
+		req.Count = 1000
+	}
+	if req.BatchNo == "" {
+		req.BatchNo = util.GetTimeNowStr()
+	}
+
+	var (
+		result *model.RespCreateAddressParams
+		err    error
+	)
+	if conf.Config.IsStartThread {
+		result, err = cs.BaseService.multiThreadCreateAddress(req.Count, req.CoinCode, req.Mch, req.BatchNo, cs.createAddressInfo)
+	} else {
+		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
+	}
+	if err == nil {
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
+	}
+	return result, err
+}
+
+/*
+离线创建地址服务，通过多线程创建
+	无需改动
+*/
+func (cs *SubstrateService) MultiThreadCreateAddrService(nums int, coinName, mchId, orderId string) error {
+	log.Infof("start create %s address", cs.coinName)
+	_, err := cs.BaseService.multiThreadCreateAddress(nums, coinName, mchId, orderId, cs.createAddressInfo)
+	return err
+}
+
+/*
+创建地址实体方法
+	私钥保存为0x开头的32字节seed，与subkey的secret seed一致
+*/
+func (cs *SubstrateService) createAddressInfo() (util.AddrInfo, error) {
+	seed, err := substrate.GenerateSeed()
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	kp, err := substrate.NewKeyPairFromSeed(cs.cfg.KeyType, seed)
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	address, err := cs.chain.Address(kp.Public())
+	if err != nil {
+		return util.AddrInfo{}, err
+	}
+	return util.AddrInfo{
+		PrivKey: "0x" + hex.EncodeToString(seed),
+		Address: address,
+	}, nil
+}
+
+// DeriveAddress 由seed推导ss58地址
+func (cs *SubstrateService) DeriveAddress(key []byte) (string, error) {
+	kp, err := substrate.NewKeyPairFromHex(cs.cfg.KeyType, secmem.String(key))
+	if err != nil {
+		return "", err
+	}
+	defer kp.Wipe()
+	return cs.chain.Address(kp.Public())
+}
+
+/*
+离线签名服务
+	所有链上参数由调用方传入，返回0x开头的hex编码extrinsic
+*/
+func (cs *SubstrateService) SignService(req *model.ReqSignParams) (interface{}, error) {
+	reqData, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var tp model.SubstrateColdParams
+	if err := json.Unmarshal(reqData, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	if tp.GenesisHash == "" || tp.SpecVersion == 0 {
+		return nil, fmt.Errorf("params is null,genesis_hash=[%s],spec_version=[%d]", tp.GenesisHash, tp.SpecVersion)
+	}
+	args, err := cs.transferArgs(tp.FromAddress, tp.ToAddress, tp.Amount, tp.Tip)
+	if err != nil {
+		return nil, err
+	}
+	args.Nonce = tp.Nonce
+	args.SpecVersion = tp.SpecVersion
+	args.TransactionVersion = tp.TransactionVersion
+	if args.GenesisHash, err = substrate.DecodeHash(tp.GenesisHash); err != nil {
+		return nil, err
+	}
+	if tp.BlockHash == "" {
+		args.BlockHash = args.GenesisHash
+	} else {
+		period := tp.EraPeriod
+		if period == 0 {
+			period = cs.cfg.EraPeriod
+		}
+		if period == 0 {
+			period = substrateDefaultEraPeriod
+		}
+		if args.BlockHash, err = substrate.DecodeHash(tp.BlockHash); err != nil {
+			return nil, err
+		}
+		args.Era = substrate.NewMortalEra(period, tp.BlockNumber)
+	}
+	extrinsic, txid, err := cs.sign(args)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("%s sign txid is: %s", cs.coinName, txid)
+	return extrinsic, nil
+}
+
+/*
+热钱包出账服务
+	nonce、runtime版本、创世hash和出生区块从节点获取
+*/
+func (cs *SubstrateService) TransferService(req interface{}) (interface{}, error) {
+	var tp model.SubstrateTransferParams
+	if err := cs.BaseService.parseData(req, &tp); err != nil {
+		return nil, err
+	}
+	if tp.FromAddress == "" || tp.ToAddress == "" || tp.Amount == "" {
+		return nil, fmt.Errorf("params is null,from=[%s],to=[%s],amount=[%s]", tp.FromAddress, tp.ToAddress, tp.Amount)
+	}
+	args, err := cs.transferArgs(tp.FromAddress, tp.ToAddress, tp.Amount, tp.Tip)
+	if err != nil {
+		return nil, err
+	}
+	fromPub, err := cs.chain.DecodeAddress(tp.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+	free, err := cs.client.GetFreeBalance(fromPub, cs.cfg.AccountRefCounts)
+	if err != nil {
+		return nil, err
+	}
+	if new(big.Int).Add(args.Amount, args.Tip).Cmp(free) >= 0 {
+		return nil, fmt.Errorf("[%s] amount is not enough,transAmount=[%s],chainAmount=[%s]", tp.FromAddress, args.Amount.String(), free.String())
+	}
+	if args.Nonce, err = cs.client.GetNonce(tp.FromAddress); err != nil {
+		return nil, err
+	}
+	rv, err := cs.client.GetRuntimeVersion()
+	if err != nil {
+		return nil, err
+	}
+	args.SpecVersion = rv.SpecVersion
+	args.TransactionVersion = rv.TransactionVersion
+	genesis, err := cs.client.GetGenesisHash()
+	if err != nil {
+		return nil, err
+	}
+	if args.GenesisHash, err = substrate.DecodeHash(genesis); err != nil {
+		return nil, err
+	}
+	args.BlockHash = args.GenesisHash
+	if cs.cfg.EraPeriod > 0 {
+		hash, number, err := cs.client.GetFinalizedHead()
+		if err != nil {
+			return nil, err
+		}
+		if args.BlockHash, err = substrate.DecodeHash(hash); err != nil {
+			return nil, err
+		}
+		args.Era = substrate.NewMortalEra(cs.cfg.EraPeriod, number)
+	}
+	extrinsic, txid, err := cs.sign(args)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := cs.client.SubmitExtrinsic(extrinsic)
+	if err != nil {
+		return nil, err
+	}
+	if hash != txid {
+		log.Warnf("%s submit hash %s is not equal to local txid %s", cs.coinName, hash, txid)
+	}
+	log.Infof("send txid is: %s", hash)
+	return hash, nil
+}
+
+func (cs *SubstrateService) GetBalance(req *model.ReqGetBalanceParams) (interface{}, error) {
+	pub, err := cs.chain.DecodeAddress(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	free, err := cs.client.GetFreeBalance(pub, cs.cfg.AccountRefCounts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"coin":   req.CoinName,
+		"amount": free.String(),
+	}, nil
+}
+
+func (cs *SubstrateService) ValidAddress(address string) error {
+	_, err := cs.chain.DecodeAddress(address)
+	return err
+}
+
+func (cs *SubstrateService) transferArgs(from, to, amount, tip string) (*substrate.TransferArgs, error) {
+	if err := cs.ValidAddress(from); err != nil {
+		return nil, fmt.Errorf("from address error: %v", err)
+	}
+	if err := cs.ValidAddress(to); err != nil {
+		return nil, fmt.Errorf("to address error: %v", err)
+	}
+	a, err := cs.parseAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+	if !a.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than 0: %s", amount)
+	}
+	if tip == "" {
+		tip = cs.cfg.Tip
+	}
+	t := decimal.Zero
+	if tip != "" {
+		if t, err = cs.parseAmount(tip); err != nil {
+			return nil, err
+		}
+	}
+	return &substrate.TransferArgs{
+		From:   from,
+		To:     to,
+		Amount: a.BigInt(),
+		Tip:    t.BigInt(),
+	}, nil
+}
+
+func (cs *SubstrateService) sign(args *substrate.TransferArgs) (string, string, error) {
+	var kp *substrate.KeyPair
+	err := cs.BaseService.withPrivateKey(args.From, func(key []byte) (err error) {
+		kp, err = substrate.NewKeyPairFromHex(cs.cfg.KeyType, secmem.String(key))
+		return err
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("get private key error,Err=%v", err)
+	}
+	defer kp.Wipe()
+	return cs.chain.SignTransfer(kp, args)
+}
+
+func (cs *SubstrateService) parseAmount(amount string) (decimal.Decimal, error) {
+	a, err := decimal.NewFromString(amount)
+	if err != nil {
+		return a, fmt.Errorf("parse amount error,err=%v", err)
+	}
+	if a.IsNegative() || !a.Equal(a.Truncate(0)) {
+		return a, errors.New("amount must be a non-negative integer in minimal unit")
+	}
+	return a, nil
+}
@@ -2,19 +2,24 @@ package v1
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/JFJun/trx-sign-go/genkeys"
 	"github.com/JFJun/trx-sign-go/grpcs"
 	"github.com/JFJun/trx-sign-go/sign"
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcutil/base58"
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
 	"github.com/fbsobreira/gotron-sdk/pkg/common"
 	"github.com/fbsobreira/gotron-sdk/pkg/proto/api"
 	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
 	"github.com/group-coldwallet/trxsign/conf"
 	"github.com/group-coldwallet/trxsign/model"
 	"github.com/group-coldwallet/trxsign/redis"
+	"github.com/group-coldwallet/trxsign/services"
 	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/secmem"
 	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 	"strings"
@@ -35,11 +40,16 @@ type TrxService struct {
 	mainUrl     string
 }
 
+func init() {
+	registerCoinService("trx", func(bs *BaseService) services.IService { return bs.TRXService() })
+}
+
 /*
 初始化币种服务
 	注意：
 		方法接受者： BaseService
-		方法命名： 币种大写 + Service
+		在init中使用registerCoinService注册
+		bs.generateKey设置为生成地址的方法后才支持异步创建地址任务
 */
 func (bs *BaseService) TRXService() *TrxService {
 	var err error
@@ -60,7 +70,7 @@ func (bs *BaseService) TRXService() *TrxService {
 	cs.backClients[cs.mainUrl] = cs.mainClient
 	cs.initClients()
 	log.Infof("配置back节点：%d,可用节点数(包含主节点)：%d", len(conf.Config.TrxCfg.BackUrls), len(cs.backClients))
-
+	bs.generateKey = cs.createAddressInfo
 	return cs
 }
 
@@ -138,9 +148,7 @@ func (cs *TrxService) CreateAddressService(req *model.ReqCreateAddressParamsV2)
 		result, err = cs.BaseService.createAddress(req, cs.createAddressInfo)
 	}
 	if err == nil {
-		log.Infof("CreateAddressService 完成，共生成 %d 个地址，准备重新加载地址", len(result.Address))
-		cs.InitKeyMap()
-		log.Info("重新加载地址完成")
+		log.Infof("CreateAddressService 完成，共生成 %d 个地址", len(result.Address))
 	}
 	return result, err
 }
@@ -326,13 +334,11 @@ func (cs *TrxService) TransferService(req interface{}) (interface{}, error) {
 		return nil, errors.New("unknown transfer")
 	}
 	// 签名交易
-	var hexPrivateKey string
-	hexPrivateKey, err = cs.BaseService.addressOrPublicKeyToPrivate(tp.FromAddress)
-	if err != nil {
-		return nil, fmt.Errorf("get private key error,Err=%v", err)
-	}
 	var tx *core.Transaction
-	tx, err = sign.SignTransaction(aTx.Transaction, hexPrivateKey)
+	err = cs.BaseService.withPrivateKey(tp.FromAddress, func(key []byte) (err error) {
+		tx, err = sign.SignTransaction(aTx.Transaction, secmem.String(key))
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("sign transaction error: %v", err)
 	}
@@ -354,7 +360,6 @@ func (cs *TrxService) TransferService(req interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("broadcast tx error: %v", err)
 	}
 	txid := common.BytesToHexString(aTx.GetTxid())
-	_ = hexPrivateKey
 	if strings.HasPrefix(txid, "0x") {
 		txid = strings.TrimPrefix(txid, "0x")
 	}
@@ -383,3 +388,18 @@ func (cs *TrxService) createAddressInfo() (util.AddrInfo, error) {
 		Address: address,
 	}, nil
 }
+
+/*
+DeriveAddress 由hex私钥推导base58地址
+	genkeys生成的私钥可能不足32字节，不能使用genkeys.CreateAddressBySeed
+*/
+func (cs *TrxService) DeriveAddress(key []byte) (string, error) {
+	seed, err := hex.DecodeString(secmem.String(key))
+	if err != nil {
+		return "", fmt.Errorf("decode private key error: %v", err)
+	}
+	defer secmem.Wipe(seed)
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), seed)
+	defer secmem.WipeBigInt(priv.D)
+	return address.PubkeyToAddress(priv.ToECDSA().PublicKey).String(), nil
+}
@@ -0,0 +1,70 @@
+package ar
+
+import (
+	"context"
+	"fmt"
+	"github.com/JFJun/arweave-go/api"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+type Client struct {
+	api *api.Client
+}
+
+func NewClient(url string) *Client {
+	c, _ := api.Dial(strings.TrimRight(url, "/"))
+	return &Client{api: c}
+}
+
+// GetAnchor 获取交易锚点，有效期内可代替last_tx，同一地址可并发出账
+func (c *Client) GetAnchor() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	anchor, err := c.api.GetTransactionAnchor(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get tx anchor error: %v", err)
+	}
+	return strings.TrimSpace(anchor), nil
+}
+
+// GetPrice 获取转账到target的手续费，target为新地址时节点会自动加上新钱包费用
+func (c *Client) GetPrice(target string) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	price, err := c.api.GetRewardV2(ctx, nil, target)
+	if err != nil {
+		return nil, fmt.Errorf("get price error: %v", err)
+	}
+	return parseWinston(price)
+}
+
+func (c *Client) GetBalance(address string) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	balance, err := c.api.GetBalance(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("get balance error: %v", err)
+	}
+	return parseWinston(balance)
+}
+
+func (c *Client) SendTransaction(tx string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	if _, err := c.api.Commit(ctx, []byte(tx)); err != nil {
+		return fmt.Errorf("send transaction error: %v", err)
+	}
+	return nil
+}
+
+func parseWinston(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+	if !ok {
+		return nil, fmt.Errorf("parse winston amount %s error", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,166 @@
+package ar
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+const formatV2 = 2
+
+type Tag struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+/*
+Transaction format 2交易
+	字段与/tx接口的json一致，二进制字段均为base64url编码，数量均为winston
+*/
+type Transaction struct {
+	Format    int    `json:"format"`
+	ID        string `json:"id"`
+	LastTx    string `json:"last_tx"`
+	Owner     string `json:"owner"`
+	Tags      []Tag  `json:"tags"`
+	Target    string `json:"target"`
+	Quantity  string `json:"quantity"`
+	Data      string `json:"data"`
+	DataSize  string `json:"data_size"`
+	DataRoot  string `json:"data_root"`
+	Reward    string `json:"reward"`
+	Signature string `json:"signature"`
+}
+
+/*
+NewTransfer 构造AR转账交易
+	anchor: /tx_anchor返回的区块锚点，或账户的last_tx
+	quantity、reward: winston
+*/
+func NewTransfer(owner []byte, anchor, target string, quantity, reward *big.Int) (*Transaction, error) {
+	if err := ValidAddress(target); err != nil {
+		return nil, err
+	}
+	if _, err := Decode(anchor); err != nil {
+		return nil, fmt.Errorf("decode anchor %s error: %v", anchor, err)
+	}
+	if quantity.Sign() <= 0 || reward.Sign() < 0 {
+		return nil, fmt.Errorf("invalid quantity %s or reward %s", quantity.String(), reward.String())
+	}
+	return &Transaction{
+		Format:   formatV2,
+		LastTx:   anchor,
+		Owner:    Encode(owner),
+		Tags:     []Tag{},
+		Target:   target,
+		Quantity: quantity.String(),
+		DataSize: "0",
+		Reward:   reward.String(),
+	}, nil
+}
+
+// SignatureData 待签名数据，即交易字段的deep hash
+func (tx *Transaction) SignatureData() ([]byte, error) {
+	fields := make([]interface{}, 0, 9)
+	fields = append(fields, []byte(strconv.Itoa(tx.Format)))
+	for _, s := range []string{tx.Owner, tx.Target} {
+		b, err := Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s error: %v", s, err)
+		}
+		fields = append(fields, b)
+	}
+	fields = append(fields, []byte(tx.Quantity), []byte(tx.Reward))
+	lastTx, err := Decode(tx.LastTx)
+	if err != nil {
+		return nil, fmt.Errorf("decode last_tx %s error: %v", tx.LastTx, err)
+	}
+	fields = append(fields, lastTx)
+	tags := make([]interface{}, 0, len(tx.Tags))
+	for _, tag := range tx.Tags {
+		name, err := Decode(tag.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := Decode(tag.Value)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, []interface{}{name, value})
+	}
+	fields = append(fields, tags, []byte(tx.DataSize))
+	dataRoot, err := Decode(tx.DataRoot)
+	if err != nil {
+		return nil, fmt.Errorf("decode data_root %s error: %v", tx.DataRoot, err)
+	}
+	fields = append(fields, dataRoot)
+	return DeepHash(fields), nil
+}
+
+// Sign 签名并计算交易id，id为sha256(signature)
+func (tx *Transaction) Sign(w *Wallet) error {
+	if tx.Owner != Encode(w.Owner()) {
+		return fmt.Errorf("wallet %s is not the owner of transaction", w.Address())
+	}
+	data, err := tx.SignatureData()
+	if err != nil {
+		return err
+	}
+	sig, err := w.Sign(data)
+	if err != nil {
+		return fmt.Errorf("sign transaction error: %v", err)
+	}
+	id := sha256.Sum256(sig)
+	tx.Signature = Encode(sig)
+	tx.ID = Encode(id[:])
+	return nil
+}
+
+func (tx *Transaction) Verify() error {
+	owner, err := Decode(tx.Owner)
+	if err != nil {
+		return err
+	}
+	sig, err := Decode(tx.Signature)
+	if err != nil {
+		return err
+	}
+	data, err := tx.SignatureData()
+	if err != nil {
+		return err
+	}
+	return Verify(owner, data, sig)
+}
+
+func (tx *Transaction) Marshal() (string, error) {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return "", fmt.Errorf("marshal transaction error: %v", err)
+	}
+	return string(data), nil
+}
+
+/*
+DeepHash arweave的deep hash算法
+	data只能是[]byte或[]interface{}
+*/
+func DeepHash(data interface{}) []byte {
+	if list, ok := data.([]interface{}); ok {
+		acc := sha384([]byte("list" + strconv.Itoa(len(list))))
+		for _, item := range list {
+			acc = sha384(append(acc, DeepHash(item)...))
+		}
+		return acc
+	}
+	blob := data.([]byte)
+	tag := sha384([]byte("blob" + strconv.Itoa(len(blob))))
+	return sha384(append(tag, sha384(blob)...))
+}
+
+func sha384(b []byte) []byte {
+	h := sha512.Sum384(b)
+	return h[:]
+}
@@ -0,0 +1,91 @@
+package ar
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"github.com/JFJun/arweave-go/tx"
+	"math/big"
+	"testing"
+)
+
+func testWallet(t *testing.T) *Wallet {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewWallet(priv)
+}
+
+func TestJWK(t *testing.T) {
+	w := testWallet(t)
+	jwk, err := w.JWK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w2, err := LoadWallet(jwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w2.Address() != w.Address() || !bytes.Equal(w2.Owner(), w.Owner()) {
+		t.Fatalf("jwk round trip mismatch")
+	}
+	if err = ValidAddress(w.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err = ValidAddress(w.Address()[:42]); err == nil {
+		t.Fatal("expected invalid address")
+	}
+}
+
+// 与arweave-go的format 2实现交叉验证deep hash
+func TestSignatureDataCompatible(t *testing.T) {
+	w := testWallet(t)
+	target := OwnerToAddress([]byte("target"))
+	anchor := Encode(bytes.Repeat([]byte{0x11}, 48))
+	amount, fee := big.NewInt(1000000000000), big.NewInt(65536)
+
+	mine, err := NewTransfer(w.Owner(), anchor, target, amount, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mine.Sign(w); err != nil {
+		t.Fatal(err)
+	}
+	if err = mine.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	signer := &captureSigner{owner: new(big.Int).SetBytes(w.Owner())}
+	if _, err = tx.NewTransactionV2(anchor, signer.owner, amount.String(), target, nil, fee.String()).Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := mine.SignatureData()
+	if h := sha256.Sum256(data); !bytes.Equal(h[:], signer.msg) {
+		t.Fatalf("signature data mismatch")
+	}
+}
+
+// captureSigner 记录arweave-go传入的待签名数据
+type captureSigner struct {
+	owner *big.Int
+	msg   []byte
+}
+
+func (s *captureSigner) Sign(msg []byte) ([]byte, error) {
+	s.msg = msg
+	return []byte{1}, nil
+}
+
+func (s *captureSigner) Verify(msg []byte, sig []byte) error { return nil }
+
+func (s *captureSigner) Address() string { return OwnerToAddress(s.owner.Bytes()) }
+
+func (s *captureSigner) PubKeyModulus() *big.Int { return s.owner }
+
+func TestDeepHashLeadingZero(t *testing.T) {
+	if bytes.Equal(DeepHash([]byte{0x00, 0x01}), DeepHash([]byte{0x01})) {
+		t.Fatal("deep hash must keep leading zero bytes")
+	}
+}
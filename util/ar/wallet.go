@@ -0,0 +1,174 @@
+package ar
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"math/big"
+)
+
+const (
+	KeyBits       = 4096
+	AddressLength = 32
+)
+
+var pssOptions = &rsa.PSSOptions{
+	SaltLength: rsa.PSSSaltLengthEqualsHash,
+	Hash:       crypto.SHA256,
+}
+
+type Wallet struct {
+	priv *rsa.PrivateKey
+}
+
+// Wipe 签名后清零rsa私钥
+func (w *Wallet) Wipe() {
+	secmem.WipeBigInt(w.priv.D)
+	for _, p := range w.priv.Primes {
+		secmem.WipeBigInt(p)
+	}
+	secmem.WipeBigInt(w.priv.Precomputed.Dp)
+	secmem.WipeBigInt(w.priv.Precomputed.Dq)
+	secmem.WipeBigInt(w.priv.Precomputed.Qinv)
+}
+
+// GenerateWallet 生成RSA-4096钱包，返回JWK格式的私钥，与arweave官方钱包导出的keyfile一致
+func GenerateWallet() (string, string, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, KeyBits)
+	if err != nil {
+		return "", "", fmt.Errorf("generate rsa key error: %v", err)
+	}
+	w := &Wallet{priv: priv}
+	jwk, err := w.JWK()
+	if err != nil {
+		return "", "", err
+	}
+	return jwk, w.Address(), nil
+}
+
+func NewWallet(priv *rsa.PrivateKey) *Wallet {
+	return &Wallet{priv: priv}
+}
+
+/*
+jwk arweave keyfile格式
+	包含p/q及CRT参数，与arweave官方钱包互相导入导出
+*/
+type jwk struct {
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	D   string `json:"d"`
+	P   string `json:"p"`
+	Q   string `json:"q"`
+	Dp  string `json:"dp"`
+	Dq  string `json:"dq"`
+	Qi  string `json:"qi"`
+}
+
+// LoadWallet 从JWK私钥加载钱包
+func LoadWallet(key string) (*Wallet, error) {
+	var k jwk
+	if err := json.Unmarshal([]byte(key), &k); err != nil {
+		return nil, fmt.Errorf("unmarshal jwk error: %v", err)
+	}
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("unsupported jwk key type: %s", k.Kty)
+	}
+	fields := []string{k.N, k.E, k.D, k.P, k.Q}
+	values := make([]*big.Int, len(fields))
+	for i, f := range fields {
+		b, err := Decode(f)
+		if err != nil || len(b) == 0 {
+			return nil, errors.New("malformed jwk rsa private key")
+		}
+		values[i] = new(big.Int).SetBytes(b)
+	}
+	if !values[1].IsInt64() {
+		return nil, errors.New("jwk rsa exponent is too large")
+	}
+	priv := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: values[0], E: int(values[1].Int64())},
+		D:         values[2],
+		Primes:    []*big.Int{values[3], values[4]},
+	}
+	if err := priv.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rsa private key: %v", err)
+	}
+	priv.Precompute()
+	return &Wallet{priv: priv}, nil
+}
+
+func (w *Wallet) JWK() (string, error) {
+	if len(w.priv.Primes) != 2 {
+		return "", errors.New("only two primes rsa key is supported")
+	}
+	w.priv.Precompute()
+	data, err := json.Marshal(&jwk{
+		Kty: "RSA",
+		N:   Encode(w.priv.N.Bytes()),
+		E:   Encode(big.NewInt(int64(w.priv.E)).Bytes()),
+		D:   Encode(w.priv.D.Bytes()),
+		P:   Encode(w.priv.Primes[0].Bytes()),
+		Q:   Encode(w.priv.Primes[1].Bytes()),
+		Dp:  Encode(w.priv.Precomputed.Dp.Bytes()),
+		Dq:  Encode(w.priv.Precomputed.Dq.Bytes()),
+		Qi:  Encode(w.priv.Precomputed.Qinv.Bytes()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal jwk error: %v", err)
+	}
+	return string(data), nil
+}
+
+// Owner 公钥模数n，即交易中的owner字段
+func (w *Wallet) Owner() []byte {
+	return w.priv.N.Bytes()
+}
+
+func (w *Wallet) Address() string {
+	return OwnerToAddress(w.Owner())
+}
+
+// Sign RSA-PSS(SHA256)签名
+func (w *Wallet) Sign(msg []byte) ([]byte, error) {
+	h := sha256.Sum256(msg)
+	return rsa.SignPSS(rand.Reader, w.priv, crypto.SHA256, h[:], pssOptions)
+}
+
+// OwnerToAddress 地址为sha256(n)的base64url编码
+func OwnerToAddress(owner []byte) string {
+	h := sha256.Sum256(owner)
+	return Encode(h[:])
+}
+
+func Verify(owner, msg, sig []byte) error {
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(owner), E: 65537}
+	h := sha256.Sum256(msg)
+	return rsa.VerifyPSS(pub, crypto.SHA256, h[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: crypto.SHA256})
+}
+
+func ValidAddress(address string) error {
+	b, err := Decode(address)
+	if err != nil {
+		return fmt.Errorf("decode address %s error: %v", address, err)
+	}
+	if len(b) != AddressLength {
+		return fmt.Errorf("address %s length is not %d bytes", address, AddressLength)
+	}
+	return nil
+}
+
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
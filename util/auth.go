@@ -1,7 +1,14 @@
 package util
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"strings"
 )
@@ -20,3 +27,47 @@ func DecodeBasicAuth(authInfo string) string {
 	}
 	return string(authByte)
 }
+
+/*
+CanonicalRequestBody 请求签名的原文
+	去掉sign字段后按key排序的json，无空格、不转义html字符，数字保持原样
+*/
+func CanonicalRequestBody(body []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var params map[string]interface{}
+	if err := decoder.Decode(&params); err != nil {
+		return nil, fmt.Errorf("parse request body error: %v", err)
+	}
+	delete(params, "sign")
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(params); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// SignRequestBody 计算请求签名，hex(hmac-sha256(secret, 原文))
+func SignRequestBody(secret string, body []byte) (string, error) {
+	canonical, err := CanonicalRequestBody(body)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(canonical)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyRequestSign 校验请求签名，使用常量时间比较
+func VerifyRequestSign(secret string, body []byte, sign string) error {
+	expect, err := SignRequestBody(secret, body)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expect), []byte(strings.ToLower(sign))) {
+		return errors.New("sign is invalid")
+	}
+	return nil
+}
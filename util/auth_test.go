@@ -0,0 +1,37 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestCanonicalRequestBody(t *testing.T) {
+	body := []byte(`{"sign":"x","orderId":"o1","mchId":"hoo","createTime":"1600000000","data":{"to":"a<b","amount":100000000000000000001,"from":"c"}}`)
+	canonical, err := CanonicalRequestBody(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"createTime":"1600000000","data":{"amount":100000000000000000001,"from":"c","to":"a<b"},"mchId":"hoo","orderId":"o1"}`
+	if string(canonical) != expect {
+		t.Fatalf("canonical body mismatch:\n%s\n%s", canonical, expect)
+	}
+}
+
+func TestVerifyRequestSign(t *testing.T) {
+	body := []byte(`{"orderId":"o1","mchId":"hoo","createTime":"1600000000","data":{"amount":"1"}}`)
+	sign, err := SignRequestBody("secret", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 签名本身和key的顺序不影响结果
+	signed := []byte(`{"sign":"` + sign + `","mchId":"hoo","orderId":"o1","data":{"amount":"1"},"createTime":"1600000000"}`)
+	if err = VerifyRequestSign("secret", signed, sign); err != nil {
+		t.Fatal(err)
+	}
+	if err = VerifyRequestSign("other", signed, sign); err == nil {
+		t.Fatal("wrong secret should fail")
+	}
+	tampered := []byte(`{"sign":"` + sign + `","mchId":"hoo","orderId":"o1","data":{"amount":"2"},"createTime":"1600000000"}`)
+	if err = VerifyRequestSign("secret", tampered, sign); err == nil {
+		t.Fatal("tampered body should fail")
+	}
+}
@@ -0,0 +1,114 @@
+package bnc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func testKey(t *testing.T, seed byte) (*btcec.PrivateKey, string) {
+	priv, err := PrivateKeyFromHex(fmt.Sprintf("%064x", int(seed)+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := Address(MainnetHrp, priv.PubKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, addr
+}
+
+func TestAddress(t *testing.T) {
+	_, addr, err := GenerateKey(MainnetHrp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(addr, "bnb1") {
+		t.Fatalf("address prefix error: %s", addr)
+	}
+	if _, err := DecodeAddress(MainnetHrp, addr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeAddress(TestnetHrp, addr); err == nil {
+		t.Fatal("mainnet address should be rejected on testnet")
+	}
+}
+
+func TestSignBytes(t *testing.T) {
+	_, from := testKey(t, 0)
+	_, to := testKey(t, 1)
+	tx, err := NewSendTx(MainnetHrp, "Binance-Chain-Tigris", from, to, []Coin{{Denom: "BNB", Amount: 100000000}}, "12345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.AccountNumber = 12
+	tx.Sequence = 3
+	b, err := tx.SignBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"account_number":"12","chain_id":"Binance-Chain-Tigris","data":null,"memo":"12345","msgs":[{"inputs":[{"address":"` + from +
+		`","coins":[{"amount":100000000,"denom":"BNB"}]}],"outputs":[{"address":"` + to +
+		`","coins":[{"amount":100000000,"denom":"BNB"}]}]}],"sequence":"3","source":"0"}`
+	if string(b) != expect {
+		t.Fatalf("sign bytes mismatch:\n%s\n%s", b, expect)
+	}
+}
+
+func TestSignAndSerialize(t *testing.T) {
+	priv, from := testKey(t, 0)
+	_, to := testKey(t, 1)
+	tx, err := NewSendTx(MainnetHrp, "Binance-Chain-Tigris", from, to, []Coin{{Denom: "BUSD-BD1", Amount: 5}}, "memo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.AccountNumber = 1
+	tx.Sequence = 2
+	if _, err := tx.Serialize(); err == nil {
+		t.Fatal("serialize unsigned transaction should fail")
+	}
+	other, _ := testKey(t, 2)
+	if err := tx.Sign(other); err == nil {
+		t.Fatal("sign with wrong key should fail")
+	}
+	if err := tx.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+	signBytes, _ := tx.SignBytes()
+	h := sha256.Sum256(signBytes)
+	sig := &btcec.Signature{R: new(big.Int).SetBytes(tx.signature[:32]), S: new(big.Int).SetBytes(tx.signature[32:])}
+	if !sig.Verify(h[:], priv.PubKey()) {
+		t.Fatal("signature verify failed")
+	}
+	raw, err := tx.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, n := binary.Uvarint(raw); int(l)+n != len(raw) {
+		t.Fatalf("length prefix error")
+	}
+	for _, prefix := range [][]byte{prefixStdTx, prefixMsgSend, prefixPubKeySec, []byte("BUSD-BD1"), []byte("memo")} {
+		if !bytes.Contains(raw, prefix) {
+			t.Fatalf("serialized transaction does not contain %x", prefix)
+		}
+	}
+}
+
+func TestNewSendTxCheck(t *testing.T) {
+	_, from := testKey(t, 0)
+	_, to := testKey(t, 1)
+	if _, err := NewSendTx(MainnetHrp, "", from, to, []Coin{{Denom: "BNB", Amount: 1}}, strings.Repeat("a", MaxMemoLength+1)); err == nil {
+		t.Fatal("memo too long should fail")
+	}
+	if _, err := NewSendTx(MainnetHrp, "", from, to, []Coin{{Denom: "BNB", Amount: 0}}, ""); err == nil {
+		t.Fatal("zero amount should fail")
+	}
+	if _, err := NewSendTx(MainnetHrp, "", from, "tbnb1abc", []Coin{{Denom: "BNB", Amount: 1}}, ""); err == nil {
+		t.Fatal("invalid address should fail")
+	}
+}
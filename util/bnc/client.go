@@ -0,0 +1,117 @@
+package bnc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"strings"
+)
+
+// Client binance chain的api接口，如https://dex.binance.org
+type Client struct {
+	url string
+}
+
+type Balance struct {
+	Symbol string `json:"symbol"`
+	Free   string `json:"free"`
+	Frozen string `json:"frozen"`
+	Locked string `json:"locked"`
+}
+
+type Account struct {
+	Address       string    `json:"address"`
+	AccountNumber int64     `json:"account_number"`
+	Sequence      int64     `json:"sequence"`
+	Balances      []Balance `json:"balances"`
+}
+
+// Free 可用余额，单位为最小单位（1e-8）
+func (a *Account) Free(denom string) (int64, error) {
+	for _, b := range a.Balances {
+		if b.Symbol != denom {
+			continue
+		}
+		free, err := decimal.NewFromString(b.Free)
+		if err != nil {
+			return 0, fmt.Errorf("parse %s balance error: %v", denom, err)
+		}
+		return free.Shift(Decimals).IntPart(), nil
+	}
+	return 0, nil
+}
+
+type BroadcastResult struct {
+	Code int    `json:"code"`
+	Hash string `json:"hash"`
+	Log  string `json:"log"`
+	Ok   bool   `json:"ok"`
+}
+
+type NodeInfo struct {
+	NodeInfo struct {
+		Network string `json:"network"`
+	} `json:"node_info"`
+}
+
+func NewClient(url string) *Client {
+	return &Client{url: strings.TrimRight(url, "/")}
+}
+
+func (c *Client) do(req *util.HTTPRequest, result interface{}) error {
+	resp, err := req.Response()
+	if err != nil {
+		return err
+	}
+	body, err := req.Bytes()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http status %d: %s", resp.StatusCode, string(body))
+	}
+	if err = json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("json unmarshal response error: %v", err)
+	}
+	return nil
+}
+
+/*
+GetAccount 获取账户的account_number、sequence和余额
+	新地址未收到过转账时接口返回404
+*/
+func (c *Client) GetAccount(address string) (*Account, error) {
+	var account Account
+	if err := c.do(util.HttpGet(c.url+"/api/v1/account/"+address), &account); err != nil {
+		return nil, fmt.Errorf("get account %s error: %v", address, err)
+	}
+	return &account, nil
+}
+
+// GetChainId 从node-info获取chain id
+func (c *Client) GetChainId() (string, error) {
+	var info NodeInfo
+	if err := c.do(util.HttpGet(c.url+"/api/v1/node-info"), &info); err != nil {
+		return "", fmt.Errorf("get node info error: %v", err)
+	}
+	return info.NodeInfo.Network, nil
+}
+
+// Broadcast 广播hex编码的交易，sync模式会返回checkTx的结果
+func (c *Client) Broadcast(tx []byte) (string, error) {
+	req := util.HttpPost(c.url+"/api/v1/broadcast?sync=true").Header("Content-Type", "text/plain").Body([]byte(hex.EncodeToString(tx)))
+	var results []BroadcastResult
+	if err := c.do(req, &results); err != nil {
+		return "", fmt.Errorf("broadcast transaction error: %v", err)
+	}
+	if len(results) == 0 {
+		return "", fmt.Errorf("broadcast transaction error: empty result")
+	}
+	if !results[0].Ok || results[0].Code != 0 {
+		return "", fmt.Errorf("broadcast transaction error: code=%d,log=%s", results[0].Code, results[0].Log)
+	}
+	return results[0].Hash, nil
+}
@@ -0,0 +1,100 @@
+package bnc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/bech32"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	MainnetHrp = "bnb"
+	TestnetHrp = "tbnb"
+)
+
+// GenerateKey 生成secp256k1私钥，返回hex私钥和bech32地址
+func GenerateKey(hrp string) (string, string, error) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		return "", "", err
+	}
+	address, err := Address(hrp, priv.PubKey())
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(paddedKey(priv)), address, nil
+}
+
+func PrivateKeyFromHex(key string) (*btcec.PrivateKey, error) {
+	b, err := hex.DecodeString(key)
+	defer secmem.Wipe(b)
+	if err != nil || len(b) != 32 {
+		return nil, fmt.Errorf("invalid bnc private key")
+	}
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), b)
+	return priv, nil
+}
+
+// Address bech32(hrp, ripemd160(sha256(压缩公钥)))
+func Address(hrp string, pub *btcec.PublicKey) (string, error) {
+	return EncodeAddress(hrp, addressBytes(pub))
+}
+
+func EncodeAddress(hrp string, addr []byte) (string, error) {
+	conv, err := bech32.ConvertBits(addr, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(hrp, conv)
+}
+
+// DecodeAddress 解析地址，返回20字节地址
+func DecodeAddress(hrp, address string) ([]byte, error) {
+	prefix, data, err := bech32.Decode(address)
+	if err != nil {
+		return nil, fmt.Errorf("decode address %s error: %v", address, err)
+	}
+	if prefix != hrp {
+		return nil, fmt.Errorf("address %s prefix must be %s", address, hrp)
+	}
+	addr, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return nil, fmt.Errorf("decode address %s error: %v", address, err)
+	}
+	if len(addr) != 20 {
+		return nil, fmt.Errorf("address %s length is invalid", address)
+	}
+	return addr, nil
+}
+
+func addressBytes(pub *btcec.PublicKey) []byte {
+	h := sha256.Sum256(pub.SerializeCompressed())
+	r := ripemd160.New()
+	r.Write(h[:])
+	return r.Sum(nil)
+}
+
+/*
+sign tendermint格式的签名
+	对sha256(signBytes)签名，返回64字节的r||s，s为low-s
+*/
+func sign(priv *btcec.PrivateKey, signBytes []byte) ([]byte, error) {
+	h := sha256.Sum256(signBytes)
+	sig, err := priv.Sign(h[:])
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 64)
+	sig.R.FillBytes(b[:32])
+	sig.S.FillBytes(b[32:])
+	return b, nil
+}
+
+func paddedKey(priv *btcec.PrivateKey) []byte {
+	b := make([]byte, 32)
+	priv.D.FillBytes(b)
+	return b
+}
@@ -0,0 +1,253 @@
+package bnc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	NativeDenom = "BNB"
+	// 所有BEP2资产精度都是8
+	Decimals = 8
+	// memo最大长度
+	MaxMemoLength = 128
+)
+
+// amino注册类型的前缀
+var (
+	prefixStdTx     = []byte{0xf0, 0x62, 0x5d, 0xee}
+	prefixMsgSend   = []byte{0x2a, 0x2c, 0x87, 0xfa}
+	prefixPubKeySec = []byte{0xeb, 0x5a, 0xe9, 0x87}
+)
+
+// 签名数据要求json的key有序，字段按字母顺序定义
+type Coin struct {
+	Amount int64  `json:"amount"`
+	Denom  string `json:"denom"`
+}
+
+type io struct {
+	Address string `json:"address"`
+	Coins   []Coin `json:"coins"`
+}
+
+type msgSend struct {
+	Inputs  []io `json:"inputs"`
+	Outputs []io `json:"outputs"`
+}
+
+/*
+SendTx 单个转出地址、单个接收地址的MsgSend交易
+	Coins为转账的资产，支持同时转多种BEP2资产
+*/
+type SendTx struct {
+	ChainId       string
+	AccountNumber int64
+	Sequence      int64
+	Source        int64
+	From          string
+	To            string
+	Coins         []Coin
+	Memo          string
+
+	hrp       string
+	pubKey    []byte
+	signature []byte
+}
+
+func NewSendTx(hrp, chainId, from, to string, coins []Coin, memo string) (*SendTx, error) {
+	if _, err := DecodeAddress(hrp, from); err != nil {
+		return nil, err
+	}
+	if _, err := DecodeAddress(hrp, to); err != nil {
+		return nil, err
+	}
+	if len(memo) > MaxMemoLength {
+		return nil, fmt.Errorf("memo length must be less than %d", MaxMemoLength)
+	}
+	if len(coins) == 0 {
+		return nil, errors.New("coins is empty")
+	}
+	// coins需要按denom排序且不能重复
+	sorted := append([]Coin{}, coins...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Denom < sorted[j].Denom })
+	for i, c := range sorted {
+		if c.Amount <= 0 {
+			return nil, fmt.Errorf("%s amount must be greater than 0", c.Denom)
+		}
+		if i > 0 && sorted[i-1].Denom == c.Denom {
+			return nil, fmt.Errorf("duplicate denom %s", c.Denom)
+		}
+	}
+	return &SendTx{ChainId: chainId, From: from, To: to, Coins: sorted, Memo: memo, hrp: hrp}, nil
+}
+
+func (tx *SendTx) msg() msgSend {
+	return msgSend{
+		Inputs:  []io{{Address: tx.From, Coins: tx.Coins}},
+		Outputs: []io{{Address: tx.To, Coins: tx.Coins}},
+	}
+}
+
+/*
+SignBytes 待签名数据
+	按key排序的json，account_number、sequence和source为字符串，msg中的amount为数字
+*/
+func (tx *SendTx) SignBytes() ([]byte, error) {
+	msg, err := json.Marshal(tx.msg())
+	if err != nil {
+		return nil, err
+	}
+	signMsg := struct {
+		AccountNumber string            `json:"account_number"`
+		ChainId       string            `json:"chain_id"`
+		Data          []byte            `json:"data"`
+		Memo          string            `json:"memo"`
+		Msgs          []json.RawMessage `json:"msgs"`
+		Sequence      string            `json:"sequence"`
+		Source        string            `json:"source"`
+	}{
+		AccountNumber: strconv.FormatInt(tx.AccountNumber, 10),
+		ChainId:       tx.ChainId,
+		Memo:          tx.Memo,
+		Msgs:          []json.RawMessage{msg},
+		Sequence:      strconv.FormatInt(tx.Sequence, 10),
+		Source:        strconv.FormatInt(tx.Source, 10),
+	}
+	return json.Marshal(signMsg)
+}
+
+func (tx *SendTx) Sign(priv *btcec.PrivateKey) error {
+	from, err := Address(tx.hrp, priv.PubKey())
+	if err != nil {
+		return err
+	}
+	if from != tx.From {
+		return fmt.Errorf("private key is not match address %s", tx.From)
+	}
+	signBytes, err := tx.SignBytes()
+	if err != nil {
+		return err
+	}
+	if tx.signature, err = sign(priv, signBytes); err != nil {
+		return err
+	}
+	tx.pubKey = priv.PubKey().SerializeCompressed()
+	return nil
+}
+
+/*
+Serialize amino编码的StdTx，带长度前缀
+	StdTx: msgs=1 signatures=2 memo=3 source=4 data=5
+	StdSignature: pub_key=1 signature=2 account_number=3 sequence=4
+*/
+func (tx *SendTx) Serialize() ([]byte, error) {
+	if tx.signature == nil {
+		return nil, errors.New("transaction is not signed")
+	}
+	msg, err := tx.encodeMsg()
+	if err != nil {
+		return nil, err
+	}
+	pub := new(bytes.Buffer)
+	pub.Write(prefixPubKeySec)
+	writeUvarint(pub, uint64(len(tx.pubKey)))
+	pub.Write(tx.pubKey)
+
+	sig := new(bytes.Buffer)
+	writeBytes(sig, 1, pub.Bytes())
+	writeBytes(sig, 2, tx.signature)
+	writeInt64(sig, 3, tx.AccountNumber)
+	writeInt64(sig, 4, tx.Sequence)
+
+	stdTx := new(bytes.Buffer)
+	stdTx.Write(prefixStdTx)
+	writeBytes(stdTx, 1, msg)
+	writeBytes(stdTx, 2, sig.Bytes())
+	writeString(stdTx, 3, tx.Memo)
+	writeInt64(stdTx, 4, tx.Source)
+
+	out := new(bytes.Buffer)
+	writeUvarint(out, uint64(stdTx.Len()))
+	out.Write(stdTx.Bytes())
+	return out.Bytes(), nil
+}
+
+func (tx *SendTx) encodeMsg() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.Write(prefixMsgSend)
+	m := tx.msg()
+	for field, list := range [][]io{m.Inputs, m.Outputs} {
+		for _, item := range list {
+			b, err := tx.encodeIO(item)
+			if err != nil {
+				return nil, err
+			}
+			writeBytes(buf, field+1, b)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (tx *SendTx) encodeIO(item io) ([]byte, error) {
+	addr, err := DecodeAddress(tx.hrp, item.Address)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	writeBytes(buf, 1, addr)
+	for _, c := range item.Coins {
+		coin := new(bytes.Buffer)
+		writeString(coin, 1, c.Denom)
+		writeInt64(coin, 2, c.Amount)
+		writeBytes(buf, 2, coin.Bytes())
+	}
+	return buf.Bytes(), nil
+}
+
+// Hash 交易hash为sha256(编码后的交易)，大写hex
+func (tx *SendTx) Hash() (string, error) {
+	b, err := tx.Serialize()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return strings.ToUpper(hex.EncodeToString(h[:])), nil
+}
+
+// amino的二进制编码与protobuf相同，默认值字段不编码
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	buf.Write(b[:n])
+}
+
+func writeInt64(buf *bytes.Buffer, field int, v int64) {
+	if v == 0 {
+		return
+	}
+	writeUvarint(buf, uint64(field<<3))
+	writeUvarint(buf, uint64(v))
+}
+
+func writeBytes(buf *bytes.Buffer, field int, v []byte) {
+	if len(v) == 0 {
+		return
+	}
+	writeUvarint(buf, uint64(field<<3|2))
+	writeUvarint(buf, uint64(len(v)))
+	buf.Write(v)
+}
+
+func writeString(buf *bytes.Buffer, field int, v string) {
+	writeBytes(buf, field, []byte(v))
+}
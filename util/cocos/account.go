@@ -0,0 +1,28 @@
+package cocos
+
+import (
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util/gxc"
+	"regexp"
+)
+
+var (
+	accountIdRegexp = regexp.MustCompile(`^1\.2\.[0-9]+$`)
+	assetIdRegexp   = regexp.MustCompile(`^1\.3\.[0-9]+$`)
+)
+
+// ValidAccountName 账户名规则与其他graphene链相同
+func ValidAccountName(name string) error {
+	if err := gxc.ValidAccountName(name); err != nil {
+		return fmt.Errorf("invalid cocos account name: %s", name)
+	}
+	return nil
+}
+
+func IsAccountId(id string) bool {
+	return accountIdRegexp.MatchString(id)
+}
+
+func IsAssetId(id string) bool {
+	return assetIdRegexp.MatchString(id)
+}
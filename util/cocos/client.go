@@ -0,0 +1,167 @@
+package cocos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiDatabase  = "database"
+	apiBroadcast = "network_broadcast"
+)
+
+type Client struct {
+	rpc *util.RpcClient
+}
+
+type Account struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Active struct {
+		WeightThreshold uint32          `json:"weight_threshold"`
+		KeyAuths        [][]interface{} `json:"key_auths"` //[[公钥, 权重]]
+	} `json:"active"`
+	Options struct {
+		MemoKey string `json:"memo_key"`
+	} `json:"options"`
+}
+
+// ActiveKeys active权限中的公钥
+func (a *Account) ActiveKeys() []string {
+	var keys []string
+	for _, auth := range a.Active.KeyAuths {
+		if len(auth) == 2 {
+			if key, ok := auth[0].(string); ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+type AssetObject struct {
+	Id        string `json:"id"`
+	Symbol    string `json:"symbol"`
+	Precision int32  `json:"precision"`
+}
+
+type DynamicGlobalProperties struct {
+	HeadBlockNumber uint32 `json:"head_block_number"`
+	HeadBlockId     string `json:"head_block_id"`
+	Time            string `json:"time"`
+}
+
+// HeadTime 最新区块时间，节点返回的是不带时区的UTC时间
+func (p *DynamicGlobalProperties) HeadTime() (time.Time, error) {
+	return time.Parse(timeLayout, p.Time)
+}
+
+// amount 节点返回的金额可能是数字也可能是字符串
+type amount int64
+
+func (a *amount) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*a = amount(v)
+	return nil
+}
+
+type assetAmount struct {
+	Amount  amount `json:"amount"`
+	AssetId string `json:"asset_id"`
+}
+
+func NewClient(url, user, password string) *Client {
+	return &Client{rpc: util.New(url, user, password)}
+}
+
+func (c *Client) call(api, method string, args []interface{}, resp interface{}) error {
+	data, err := c.rpc.SendRequest("call", []interface{}{api, method, args})
+	if err != nil {
+		return fmt.Errorf("call %s error: %v", method, err)
+	}
+	if resp == nil {
+		return nil
+	}
+	if data == nil {
+		return fmt.Errorf("call %s result is null", method)
+	}
+	if err = json.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("json unmarshal %s result error: %v", method, err)
+	}
+	return nil
+}
+
+func (c *Client) GetChainId() (string, error) {
+	var chainId string
+	if err := c.call(apiDatabase, "get_chain_id", []interface{}{}, &chainId); err != nil {
+		return "", err
+	}
+	return chainId, nil
+}
+
+// GetAccount 支持账户名和账户id(1.2.x)
+func (c *Client) GetAccount(nameOrId string) (*Account, error) {
+	var account Account
+	if IsAccountId(nameOrId) {
+		var accounts []*Account
+		if err := c.call(apiDatabase, "get_objects", []interface{}{[]string{nameOrId}}, &accounts); err != nil {
+			return nil, err
+		}
+		if len(accounts) == 1 && accounts[0] != nil {
+			account = *accounts[0]
+		}
+	} else if err := c.call(apiDatabase, "get_account_by_name", []interface{}{nameOrId}, &account); err != nil {
+		return nil, err
+	}
+	if account.Id == "" {
+		return nil, fmt.Errorf("account %s is not exist", nameOrId)
+	}
+	return &account, nil
+}
+
+func (c *Client) LookupAssetSymbol(symbol string) (*AssetObject, error) {
+	var assets []*AssetObject
+	if err := c.call(apiDatabase, "lookup_asset_symbols", []interface{}{[]string{symbol}}, &assets); err != nil {
+		return nil, err
+	}
+	if len(assets) != 1 || assets[0] == nil {
+		return nil, fmt.Errorf("asset %s is not exist", symbol)
+	}
+	return assets[0], nil
+}
+
+func (c *Client) GetDynamicGlobalProperties() (*DynamicGlobalProperties, error) {
+	var p DynamicGlobalProperties
+	if err := c.call(apiDatabase, "get_dynamic_global_properties", []interface{}{}, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (c *Client) GetBalance(accountId, assetId string) (int64, error) {
+	var balances []assetAmount
+	if err := c.call(apiDatabase, "get_account_balances", []interface{}{accountId, []string{assetId}}, &balances); err != nil {
+		return 0, err
+	}
+	for _, b := range balances {
+		if b.AssetId == assetId {
+			return int64(b.Amount), nil
+		}
+	}
+	return 0, nil
+}
+
+func (c *Client) BroadcastTransaction(tx *Transaction) error {
+	if len(tx.Signatures) == 0 {
+		return errors.New("transaction is not signed")
+	}
+	return c.call(apiBroadcast, "broadcast_transaction", []interface{}{tx}, nil)
+}
@@ -0,0 +1,105 @@
+package cocos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/btcsuite/btcd/btcec"
+	"testing"
+	"time"
+)
+
+const testChainId = "4f7d07969c446f8342033acb3ab2ae5044cbe0fde93db02de75bd17fa8fd84b8"
+
+func testKey(seed string) *btcec.PrivateKey {
+	h := sha256.Sum256([]byte(seed))
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), h[:])
+	return priv
+}
+
+func TestPublicKey(t *testing.T) {
+	wif, pub, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := WifToPrivateKey(wif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PublicKeyToString(priv.PubKey()) != pub {
+		t.Fatal("wif is not match public key")
+	}
+	if _, err = ParsePublicKey(pub); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ParsePublicKey("GXC" + pub[len(PublicKeyPrefix):]); err == nil {
+		t.Fatal("public key without COCOS prefix should be invalid")
+	}
+}
+
+func TestMemo(t *testing.T) {
+	from, to := testKey("from"), testKey("to")
+	m, err := EncryptMemo(from, to.PubKey(), "hello cocos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Memo
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Encrypted == nil || decoded.Encrypted.From != PublicKeyToString(from.PubKey()) {
+		t.Fatalf("unexpected memo json: %s", b)
+	}
+	plain, err := DecryptMemo(to, from.PubKey(), &decoded)
+	if err != nil || plain != "hello cocos" {
+		t.Fatalf("decrypt memo error: %v %s", err, plain)
+	}
+	if b, _ = json.Marshal(NewPlainMemo("abc")); string(b) != `[0,"abc"]` {
+		t.Fatalf("unexpected plain memo json: %s", b)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	op := &TransferOperation{
+		From:   "1.2.17",
+		To:     "1.2.300",
+		Amount: Asset{Amount: 100000, AssetId: "1.3.0"},
+		Memo:   NewPlainMemo("hi"),
+	}
+	headBlockId := "0066ee5f" + "01020304" + "00000000000000000000000000000000"
+	tx, err := NewTransferTransaction(op, 0x0166ee5f, headBlockId, time.Unix(1600000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stx, err := tx.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "5fee" + "01020304" + "00105e5f" + "01" + "00" + // tapos,expiration,1个操作,transfer
+		"11" + "ac02" + "a086010000000000" + "00" + // from,to,amount
+		"01" + "00" + "02" + hex.EncodeToString([]byte("hi")) + // 明文memo
+		"00" + "00"
+	if hex.EncodeToString(stx) != expected {
+		t.Fatalf("serialize result is %x", stx)
+	}
+	priv := testKey("signer")
+	sig, err := SignTransaction(priv, testChainId, stx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cid, _ := hex.DecodeString(testChainId)
+	digest := sha256.Sum256(append(cid, stx...))
+	pub, _, err := btcec.RecoverCompact(btcec.S256(), sig, digest[:])
+	if err != nil || !bytes.Equal(pub.SerializeCompressed(), priv.PubKey().SerializeCompressed()) {
+		t.Fatalf("recover public key error: %v", err)
+	}
+	id, _ := tx.Id()
+	if len(id) != 64 {
+		t.Fatalf("unexpected txid: %s", id)
+	}
+}
@@ -0,0 +1,65 @@
+package cocos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/group-coldwallet/trxsign/util/gxc"
+	"golang.org/x/crypto/ripemd160"
+	"strings"
+)
+
+// 私钥格式、签名算法与gxc等graphene链一致，只有公钥前缀不同
+const PublicKeyPrefix = "COCOS"
+
+// GenerateKey 生成私钥，返回wif私钥和COCOS公钥
+func GenerateKey() (string, string, error) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		return "", "", err
+	}
+	return gxc.PrivateKeyToWif(priv), PublicKeyToString(priv.PubKey()), nil
+}
+
+func WifToPrivateKey(wif string) (*btcec.PrivateKey, error) {
+	return gxc.WifToPrivateKey(wif)
+}
+
+// PublicKeyToString COCOS + base58(压缩公钥 + ripemd160(压缩公钥)[:4])
+func PublicKeyToString(pub *btcec.PublicKey) string {
+	data := pub.SerializeCompressed()
+	return PublicKeyPrefix + base58.Encode(append(data, ripemd(data)[:4]...))
+}
+
+func ParsePublicKey(key string) (*btcec.PublicKey, error) {
+	if !strings.HasPrefix(key, PublicKeyPrefix) {
+		return nil, fmt.Errorf("public key %s must start with %s", key, PublicKeyPrefix)
+	}
+	b := base58.Decode(strings.TrimPrefix(key, PublicKeyPrefix))
+	if len(b) != 37 {
+		return nil, fmt.Errorf("invalid cocos public key: %s", key)
+	}
+	if !bytes.Equal(ripemd(b[:33])[:4], b[33:]) {
+		return nil, fmt.Errorf("public key %s checksum error", key)
+	}
+	return btcec.ParsePubKey(b[:33], btcec.S256())
+}
+
+// SignTransaction 对 sha256(chainId + 序列化交易) 签名
+func SignTransaction(priv *btcec.PrivateKey, chainId string, serializedTx []byte) ([]byte, error) {
+	cid, err := hex.DecodeString(chainId)
+	if err != nil || len(cid) != 32 {
+		return nil, fmt.Errorf("invalid chain id: %s", chainId)
+	}
+	digest := sha256.Sum256(append(cid, serializedTx...))
+	return gxc.SignDigest(priv, digest[:])
+}
+
+func ripemd(b []byte) []byte {
+	h := ripemd160.New()
+	h.Write(b)
+	return h.Sum(nil)
+}
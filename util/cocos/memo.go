@@ -0,0 +1,84 @@
+package cocos
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/group-coldwallet/trxsign/util/gxc"
+)
+
+const (
+	memoPlain     = 0
+	memoEncrypted = 1
+)
+
+/*
+Memo cocos的memo为static_variant
+	[0, "明文"] 或 [1, {from, to, nonce, message}]
+*/
+type Memo struct {
+	Plain     string
+	Encrypted *EncryptedMemo
+}
+
+type EncryptedMemo struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Nonce   uint64 `json:"nonce,string"`
+	Message string `json:"message"` //hex编码的密文
+}
+
+func NewPlainMemo(memo string) *Memo {
+	return &Memo{Plain: memo}
+}
+
+// EncryptMemo 加密算法与gxc相同，只需替换公钥前缀
+func EncryptMemo(priv *btcec.PrivateKey, to *btcec.PublicKey, memo string) (*Memo, error) {
+	m, err := gxc.EncryptMemo(priv, to, memo)
+	if err != nil {
+		return nil, err
+	}
+	return &Memo{Encrypted: &EncryptedMemo{
+		From:    PublicKeyToString(priv.PubKey()),
+		To:      PublicKeyToString(to),
+		Nonce:   m.Nonce,
+		Message: m.Message,
+	}}, nil
+}
+
+// DecryptMemo 解密memo，priv为memo收发任意一方的私钥，pub为另一方公钥
+func DecryptMemo(priv *btcec.PrivateKey, pub *btcec.PublicKey, m *Memo) (string, error) {
+	if m.Encrypted == nil {
+		return m.Plain, nil
+	}
+	return gxc.DecryptMemo(priv, pub, &gxc.Memo{Nonce: m.Encrypted.Nonce, Message: m.Encrypted.Message})
+}
+
+func (m *Memo) MarshalJSON() ([]byte, error) {
+	if m.Encrypted != nil {
+		return json.Marshal([]interface{}{memoEncrypted, m.Encrypted})
+	}
+	return json.Marshal([]interface{}{memoPlain, m.Plain})
+}
+
+func (m *Memo) UnmarshalJSON(b []byte) error {
+	var v []json.RawMessage
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if len(v) != 2 {
+		return errors.New("invalid cocos memo")
+	}
+	var tag int
+	if err := json.Unmarshal(v[0], &tag); err != nil {
+		return err
+	}
+	switch tag {
+	case memoPlain:
+		return json.Unmarshal(v[1], &m.Plain)
+	case memoEncrypted:
+		m.Encrypted = new(EncryptedMemo)
+		return json.Unmarshal(v[1], m.Encrypted)
+	}
+	return errors.New("unknown cocos memo type")
+}
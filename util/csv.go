@@ -1,12 +1,16 @@
 package util
 
 import (
+	"bytes"
 	"encoding/csv"
 	"errors"
 	"fmt"
+	"github.com/group-coldwallet/trxsign/util/shamir"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 //根据币种 扩展AddrInfo信息
@@ -56,9 +60,43 @@ func ReadCsv(execlFileName string, index int) ([]string, error) {
 //c 文件 明文文件
 //d 文件 地址文件
 func CreateAddrCsv(createPath, mchId, orderId, coinName string, addrInfos []AddrInfo) (addrs []string, err error) {
+	return CreateAddrCsvWithMasterKey(createPath, mchId, orderId, coinName, addrInfos, nil)
+}
+
+// CreateAddrCsvWithMasterKey mk不为空时b文件中的数据密钥由主密钥加密(v3格式)
+func CreateAddrCsvWithMasterKey(createPath, mchId, orderId, coinName string, addrInfos []AddrInfo, mk *MasterKey) (addrs []string, err error) {
+	return CreateAddrCsvWithOptions(createPath, mchId, orderId, coinName, addrInfos, CsvOptions{MasterKey: mk})
+}
+
+/*
+ShareOptions c文件的Shamir拆分参数
+	启用后不再写c文件，c文件内容拆分为Total个份额文件，任意Threshold个可恢复
+	第i个份额写入Dirs[i]，Dirs必须为Total个互不相同且不在createPath下的目录(如各保管人的usb)
+*/
+type ShareOptions struct {
+	Threshold int
+	Total     int
+	Dirs      []string
+}
+
+type CsvOptions struct {
+	MasterKey *MasterKey
+	Shares    *ShareOptions
+}
+
+func CreateAddrCsvWithOptions(createPath, mchId, orderId, coinName string, addrInfos []AddrInfo, opts CsvOptions) (addrs []string, err error) {
 	if createPath == "" || mchId == "" || orderId == "" || coinName == "" {
 		return nil, errors.New("empty params")
 	}
+	mk := opts.MasterKey
+	if sh := opts.Shares; sh != nil && (sh.Threshold < 2 || sh.Threshold > sh.Total || sh.Total > shamir.MaxShares) {
+		return nil, fmt.Errorf("invalid shamir threshold %d of %d shares", sh.Threshold, sh.Total)
+	}
+	if opts.Shares != nil {
+		if err = checkShareDirs(createPath, opts.Shares); err != nil {
+			return nil, err
+		}
+	}
 
 	if len(addrInfos) == 0 {
 		return nil, errors.New("empty addrs")
@@ -97,12 +135,19 @@ func CreateAddrCsv(createPath, mchId, orderId, coinName string, addrInfos []Addr
 	}
 	defer fileB.Close()
 
-	//C文件
-	fileC, err := os.Create(fileCPath)
-	if err != nil {
-		return nil, err
+	//C文件，拆分时只写入内存
+	var fileC io.Writer
+	plain := new(bytes.Buffer)
+	if opts.Shares == nil {
+		f, err := os.Create(fileCPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		fileC = f
+	} else {
+		fileC = plain
 	}
-	defer fileC.Close()
 
 	//D文件
 	_, err = os.Stat(fileDPath)
@@ -114,16 +159,29 @@ func CreateAddrCsv(createPath, mchId, orderId, coinName string, addrInfos []Addr
 	wc := csv.NewWriter(fileC)
 	wd := csv.NewWriter(fileD)
 
+	//a、b文件首行为文件头
+	header := CurrentKeyFileHeader()
+	if mk != nil {
+		header = WrappedKeyFileHeader()
+	}
+	wa.Write(header.Record())
+	wb.Write(header.Record())
 	for _, info := range addrInfos {
-		aesKey := RandBase64Key()
-		ciphertext, err := AesBase64Crypt([]byte(info.PrivKey), aesKey, true)
+		var aesKey, ciphertext string
+		aesKey, ciphertext, err = EncryptPrivateKey(info.Address, []byte(info.PrivKey))
 		if err != nil {
-			err = fmt.Errorf("AesBase64Crypt error:%s ", err)
+			err = fmt.Errorf("EncryptPrivateKey error:%s ", err)
 			//不使用return,break之后直接手动释放写入流
 			break
 		}
-		wa.Write([]string{info.Address, string(ciphertext)})
-		wb.Write([]string{info.Address, string(aesKey)})
+		if mk != nil {
+			if aesKey, err = mk.WrapDataKey(info.Address, aesKey); err != nil {
+				err = fmt.Errorf("WrapDataKey error:%s ", err)
+				break
+			}
+		}
+		wa.Write([]string{info.Address, ciphertext})
+		wb.Write([]string{info.Address, aesKey})
 		if info.Mnemonic == "" {
 			wc.Write([]string{info.Address, string(info.PrivKey)})
 		} else {
@@ -140,5 +198,67 @@ func CreateAddrCsv(createPath, mchId, orderId, coinName string, addrInfos []Addr
 	if err != nil {
 		return nil, err
 	}
+	if opts.Shares != nil {
+		if err = writeAddrShares(createPath, mchId, orderId, coinName, len(addrs), opts.Shares, plain.Bytes()); err != nil {
+			// 没有备份的私钥不能使用
+			for _, path := range []string{fileAPath, fileBPath, fileDPath} {
+				os.Remove(path)
+			}
+			return nil, err
+		}
+	}
 	return addrs, nil
 }
+
+func writeAddrShares(createPath, mchId, orderId, coinName string, count int, sh *ShareOptions, plain []byte) error {
+	defer func() {
+		for i := range plain {
+			plain[i] = 0
+		}
+	}()
+	paths, err := shamir.WriteShareFiles(sh.Dirs, shamir.ShareFile{
+		CoinType:  coinName,
+		MchId:     mchId,
+		BatchNo:   orderId,
+		Threshold: sh.Threshold,
+		Total:     sh.Total,
+		Addresses: count,
+	}, plain)
+	if err != nil {
+		for _, path := range paths {
+			os.Remove(path)
+		}
+		return fmt.Errorf("write shamir share files error: %v", err)
+	}
+	log.Printf("write %d shamir share files to %v,threshold is %d", len(paths), sh.Dirs, sh.Threshold)
+	return nil
+}
+
+// checkShareDirs 份额写入同一目录或私钥文件目录时，拿到该目录即可恢复私钥，拆分没有意义
+func checkShareDirs(createPath string, sh *ShareOptions) error {
+	if len(sh.Dirs) != sh.Total {
+		return fmt.Errorf("shamir share dirs count %d is not equal to total %d", len(sh.Dirs), sh.Total)
+	}
+	root, err := filepath.Abs(createPath)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(sh.Dirs))
+	for _, dir := range sh.Dirs {
+		if dir == "" {
+			return errors.New("shamir share dir is empty")
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if seen[abs] {
+			return fmt.Errorf("shamir share dir %s is duplicated", dir)
+		}
+		seen[abs] = true
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("shamir share dir %s is in key file path %s", dir, createPath)
+		}
+	}
+	return nil
+}
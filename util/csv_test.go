@@ -1,6 +1,12 @@
 package util
 
-import "testing"
+import (
+	"github.com/group-coldwallet/trxsign/util/shamir"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestCreateAddrCsv(t *testing.T) {
 
@@ -21,3 +27,53 @@ func TestCreateAddrCsv(t *testing.T) {
 	t.Logf("%+v", addrs)
 
 }
+
+func TestCreateAddrCsvShares(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shares")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	infos := []AddrInfo{{Address: "addr1", PrivKey: "priv1"}, {Address: "addr2", PrivKey: "priv2"}}
+	keyPath := filepath.Join(dir, "keys")
+	dirs := []string{filepath.Join(dir, "usb1"), filepath.Join(dir, "usb2"), filepath.Join(dir, "usb3")}
+	opts := CsvOptions{Shares: &ShareOptions{Threshold: 2, Total: 3, Dirs: dirs}}
+	if _, err = CreateAddrCsvWithOptions(keyPath, "mch1", "1", "dot", infos, opts); err != nil {
+		t.Fatal(err)
+	}
+	// 不写明文c文件
+	if _, err = os.Stat(filepath.Join(keyPath, "mch1", "dot_c_usb_1.csv")); !os.IsNotExist(err) {
+		t.Fatal("plaintext c file should not be written")
+	}
+	var files []*shamir.ShareFile
+	for _, i := range []int{1, 3} {
+		f, err := shamir.ReadShareFile(filepath.Join(dirs[i-1], shamir.ShareFileName("dot", "1", i, 3)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	_, secret, err := shamir.CombineShareFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(secret) != "addr1,priv1\naddr2,priv2\n" {
+		t.Fatalf("recovered secret error: %q", secret)
+	}
+
+	// 无效的拆分参数或份额目录不生成任何文件
+	invalid := []*ShareOptions{
+		{Threshold: 4, Total: 3, Dirs: dirs},
+		{Threshold: 2, Total: 3},
+		{Threshold: 2, Total: 3, Dirs: []string{dirs[0], dirs[1], dirs[1]}},
+		{Threshold: 2, Total: 3, Dirs: []string{dirs[0], dirs[1], filepath.Join(keyPath, "shares")}},
+	}
+	for i, sh := range invalid {
+		if _, err = CreateAddrCsvWithOptions(keyPath, "mch1", "2", "dot", infos, CsvOptions{Shares: sh}); err == nil {
+			t.Fatalf("invalid share options %d should fail", i)
+		}
+		if _, err = os.Stat(filepath.Join(keyPath, "mch1", "dot_a_usb_2.csv")); !os.IsNotExist(err) {
+			t.Fatal("key files should not be written")
+		}
+	}
+}
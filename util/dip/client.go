@@ -0,0 +1,123 @@
+package dip
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Dipper-Labs/Dipper-Protocol/app/v0/auth"
+	"github.com/Dipper-Labs/go-sdk/types"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/util"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const broadcastModeSync = "sync"
+
+// Client lcd接口
+type Client struct {
+	url string
+}
+
+type Account struct {
+	AccountNumber uint64
+	Sequence      uint64
+	Coins         map[string]*big.Int
+}
+
+// broadcastReq 使用amino json编码，msg等接口类型需要带上type
+type broadcastReq struct {
+	Tx   auth.StdTx `json:"tx"`
+	Mode string     `json:"mode"`
+}
+
+type BroadcastResult struct {
+	Height string `json:"height"`
+	TxHash string `json:"txhash"`
+	Code   uint32 `json:"code"`
+	RawLog string `json:"raw_log"`
+}
+
+func NewClient(url string) *Client {
+	return &Client{url: strings.TrimRight(url, "/")}
+}
+
+func (c *Client) do(req *util.HTTPRequest, result interface{}) error {
+	resp, err := req.Response()
+	if err != nil {
+		return err
+	}
+	body, err := req.Bytes()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http status %d: %s", resp.StatusCode, string(body))
+	}
+	if err = json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("json unmarshal response error: %v", err)
+	}
+	return nil
+}
+
+// ChainId lcd的node_info中network即为chain id
+func (c *Client) ChainId() (string, error) {
+	var info model.DipNodeInfo
+	if err := c.do(util.HttpGet(c.url+"/node_info"), &info); err != nil {
+		return "", fmt.Errorf("get node info error: %v", err)
+	}
+	return info.NodeInfo.Network, nil
+}
+
+// GetAccount 获取account_number、sequence和余额，未激活的账户account_number和sequence为0
+func (c *Client) GetAccount(address string) (*Account, error) {
+	var body model.DipAccountBody
+	if err := c.do(util.HttpGet(fmt.Sprintf("%s/auth/accounts/%s", c.url, address)), &body); err != nil {
+		return nil, fmt.Errorf("get account %s error: %v", address, err)
+	}
+	account := &Account{Coins: make(map[string]*big.Int)}
+	var err error
+	if body.Result.Value.AccountNumber != "" {
+		if account.AccountNumber, err = strconv.ParseUint(body.Result.Value.AccountNumber, 10, 64); err != nil {
+			return nil, fmt.Errorf("parse account number error: %v", err)
+		}
+	}
+	if body.Result.Value.Sequence != "" {
+		if account.Sequence, err = strconv.ParseUint(body.Result.Value.Sequence, 10, 64); err != nil {
+			return nil, fmt.Errorf("parse sequence error: %v", err)
+		}
+	}
+	for _, coin := range body.Result.Value.Coins {
+		amount, ok := new(big.Int).SetString(coin.Amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("parse %s amount error: %s", coin.Denom, coin.Amount)
+		}
+		account.Coins[coin.Denom] = amount
+	}
+	return account, nil
+}
+
+func (a *Account) Balance(denom string) *big.Int {
+	if b, ok := a.Coins[denom]; ok {
+		return b
+	}
+	return big.NewInt(0)
+}
+
+// Broadcast 通过lcd的/txs接口广播交易
+func (c *Client) Broadcast(tx *auth.StdTx) (*BroadcastResult, error) {
+	body, err := types.Cdc.MarshalJSON(broadcastReq{Tx: *tx, Mode: broadcastModeSync})
+	if err != nil {
+		return nil, err
+	}
+	req := util.HttpPost(c.url+"/txs").Header("Content-Type", "application/json").Body(body)
+	var result BroadcastResult
+	if err = c.do(req, &result); err != nil {
+		return nil, fmt.Errorf("broadcast tx error: %v", err)
+	}
+	if result.Code != 0 {
+		return nil, fmt.Errorf("broadcast tx error,code=%d,log=%s", result.Code, result.RawLog)
+	}
+	return &result, nil
+}
@@ -0,0 +1,93 @@
+package dip
+
+import (
+	"encoding/json"
+	"github.com/Dipper-Labs/go-sdk/types"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	key, address, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(address, "dip1") {
+		t.Fatalf("unexpected address %s", address)
+	}
+	priv, err := PrivateKeyFromHex(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Address(priv) != address || ValidAddress(address) != nil {
+		t.Fatal("private key is not match address")
+	}
+	if ValidAddress("cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu") == nil {
+		t.Fatal("address with other prefix should be invalid")
+	}
+}
+
+func TestSignTransfer(t *testing.T) {
+	priv := secp256k1.GenPrivKeySecp256k1([]byte("dip test key"))
+	to := Address(secp256k1.GenPrivKeySecp256k1([]byte("dip test to")))
+	p := &TransferParams{
+		ChainId:       "dip-test",
+		AccountNumber: 12,
+		Sequence:      3,
+		From:          Address(priv),
+		To:            to,
+		Amount:        big.NewInt(1000000000000),
+		Memo:          "memo",
+	}
+	tx, hash, err := SignTransfer(priv, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hash) != 64 || strings.ToUpper(hash) != hash {
+		t.Fatalf("unexpected hash %s", hash)
+	}
+	if tx.Fee.Gas != DefaultGas || tx.Fee.Amount.AmountOf(DefaultDenom).Int64() != DefaultFee {
+		t.Fatalf("unexpected fee %v", tx.Fee)
+	}
+	signMsg := types.StdSignMsg{
+		ChainID:       p.ChainId,
+		AccountNumber: p.AccountNumber,
+		Sequence:      p.Sequence,
+		Fee:           tx.Fee,
+		Msgs:          tx.Msgs,
+		Memo:          tx.Memo,
+	}
+	if !priv.PubKey().VerifyBytes(signMsg.Bytes(), tx.Signatures[0].Signature) {
+		t.Fatal("verify signature failed")
+	}
+	signMsg.ChainID = "other"
+	if priv.PubKey().VerifyBytes(signMsg.Bytes(), tx.Signatures[0].Signature) {
+		t.Fatal("signature should be bound to chain id")
+	}
+
+	body, err := types.Cdc.MarshalJSON(broadcastReq{Tx: *tx, Mode: broadcastModeSync})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req struct {
+		Tx struct {
+			Msg []struct {
+				Type string `json:"type"`
+			} `json:"msg"`
+			Memo string `json:"memo"`
+		} `json:"tx"`
+		Mode string `json:"mode"`
+	}
+	if err = json.Unmarshal(body, &req); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Tx.Msg) != 1 || req.Tx.Msg[0].Type == "" || req.Tx.Memo != "memo" || req.Mode != "sync" {
+		t.Fatalf("unexpected broadcast body: %s", body)
+	}
+
+	if _, _, err = SignTransfer(secp256k1.GenPrivKey(), p); err == nil {
+		t.Fatal("sign with other key should fail")
+	}
+}
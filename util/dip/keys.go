@@ -0,0 +1,37 @@
+package dip
+
+import (
+	"encoding/hex"
+	"fmt"
+	sdk "github.com/Dipper-Labs/Dipper-Protocol/types"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+// GenerateKey 生成secp256k1私钥，返回hex私钥和bech32地址(dip开头)
+func GenerateKey() (string, string, error) {
+	priv := secp256k1.GenPrivKey()
+	return hex.EncodeToString(priv[:]), Address(priv), nil
+}
+
+func PrivateKeyFromHex(key string) (secp256k1.PrivKeySecp256k1, error) {
+	var priv secp256k1.PrivKeySecp256k1
+	b, err := hex.DecodeString(key)
+	defer secmem.Wipe(b)
+	if err != nil || len(b) != len(priv) {
+		return priv, fmt.Errorf("invalid dip private key")
+	}
+	copy(priv[:], b)
+	return priv, nil
+}
+
+func Address(priv secp256k1.PrivKeySecp256k1) string {
+	return sdk.AccAddress(priv.PubKey().Address()).String()
+}
+
+func ValidAddress(address string) error {
+	if _, err := sdk.AccAddressFromBech32(address); err != nil {
+		return fmt.Errorf("invalid dip address %s: %v", address, err)
+	}
+	return nil
+}
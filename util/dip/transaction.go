@@ -0,0 +1,100 @@
+package dip
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/Dipper-Labs/Dipper-Protocol/app/v0/auth"
+	"github.com/Dipper-Labs/Dipper-Protocol/app/v0/bank"
+	sdk "github.com/Dipper-Labs/Dipper-Protocol/types"
+	"github.com/Dipper-Labs/go-sdk/types"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	"math/big"
+	"strings"
+)
+
+const (
+	DefaultDenom = "pdip"
+	// 与go-sdk的默认值一致
+	DefaultGas = uint64(200000)
+	DefaultFee = int64(500000)
+)
+
+type TransferParams struct {
+	ChainId       string
+	AccountNumber uint64
+	Sequence      uint64
+	From          string
+	To            string
+	Amount        *big.Int
+	Denom         string
+	Gas           uint64
+	Fee           int64 //手续费，单位为pdip
+	Memo          string
+}
+
+/*
+SignTransfer 构造并签名MsgSend
+	返回签名后的StdTx和交易hash(tendermint中为amino编码交易的sha256)
+*/
+func SignTransfer(priv secp256k1.PrivKeySecp256k1, p *TransferParams) (*auth.StdTx, string, error) {
+	from, err := sdk.AccAddressFromBech32(p.From)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid from address %s: %v", p.From, err)
+	}
+	if !from.Equals(sdk.AccAddress(priv.PubKey().Address())) {
+		return nil, "", fmt.Errorf("private key is not match address %s", p.From)
+	}
+	to, err := sdk.AccAddressFromBech32(p.To)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid to address %s: %v", p.To, err)
+	}
+	if p.Amount == nil || p.Amount.Sign() <= 0 {
+		return nil, "", fmt.Errorf("amount must be greater than 0")
+	}
+	if p.ChainId == "" {
+		return nil, "", fmt.Errorf("chain id is empty")
+	}
+	denom := p.Denom
+	if denom == "" {
+		denom = DefaultDenom
+	}
+	gas, fee := p.Gas, p.Fee
+	if gas == 0 {
+		gas = DefaultGas
+	}
+	if fee == 0 {
+		fee = DefaultFee
+	}
+	msg := bank.NewMsgSend(from, to, sdk.NewCoins(sdk.NewCoin(denom, sdk.NewIntFromBigInt(p.Amount))))
+	if err = msg.ValidateBasic(); err != nil {
+		return nil, "", err
+	}
+	signMsg := types.StdSignMsg{
+		ChainID:       p.ChainId,
+		AccountNumber: p.AccountNumber,
+		Sequence:      p.Sequence,
+		Fee:           auth.NewStdFee(gas, sdk.NewCoins(sdk.NewInt64Coin(DefaultDenom, fee))),
+		Msgs:          []sdk.Msg{msg},
+		Memo:          p.Memo,
+	}
+	sig, err := priv.Sign(signMsg.Bytes())
+	if err != nil {
+		return nil, "", err
+	}
+	tx := auth.NewStdTx(signMsg.Msgs, signMsg.Fee, []auth.StdSignature{{PubKey: priv.PubKey(), Signature: sig}}, signMsg.Memo)
+	hash, err := TxHash(tx)
+	if err != nil {
+		return nil, "", err
+	}
+	return &tx, hash, nil
+}
+
+func TxHash(tx auth.StdTx) (string, error) {
+	bz, err := types.Cdc.MarshalBinaryLengthPrefixed(tx)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(bz)
+	return strings.ToUpper(hex.EncodeToString(h[:])), nil
+}
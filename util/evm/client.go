@@ -0,0 +1,122 @@
+package evm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math/big"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+type Client struct {
+	eth *ethclient.Client
+}
+
+func NewClient(url, user, password string) (*Client, error) {
+	c, err := rpc.DialHTTP(url)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s error: %v", url, err)
+	}
+	if user != "" {
+		c.SetHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+password)))
+	}
+	return &Client{eth: ethclient.NewClient(c)}, nil
+}
+
+func ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), requestTimeout)
+}
+
+func (c *Client) PendingNonce(address common.Address) (uint64, error) {
+	ctx, cancel := ctx()
+	defer cancel()
+	nonce, err := c.eth.PendingNonceAt(ctx, address)
+	if err != nil {
+		return 0, fmt.Errorf("get %s pending nonce error: %v", address.Hex(), err)
+	}
+	return nonce, nil
+}
+
+func (c *Client) Balance(address common.Address) (*big.Int, error) {
+	ctx, cancel := ctx()
+	defer cancel()
+	balance, err := c.eth.BalanceAt(ctx, address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get %s balance error: %v", address.Hex(), err)
+	}
+	return balance, nil
+}
+
+func (c *Client) TokenBalance(contract, owner common.Address) (*big.Int, error) {
+	ctx, cancel := ctx()
+	defer cancel()
+	out, err := c.eth.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: BalanceOfData(owner)}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get %s token %s balance error: %v", owner.Hex(), contract.Hex(), err)
+	}
+	if len(out) < 32 {
+		return nil, fmt.Errorf("contract %s balanceOf result is invalid: %x", contract.Hex(), out)
+	}
+	return new(big.Int).SetBytes(out[:32]), nil
+}
+
+func (c *Client) GasPrice() (*big.Int, error) {
+	ctx, cancel := ctx()
+	defer cancel()
+	price, err := c.eth.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get gas price error: %v", err)
+	}
+	return price, nil
+}
+
+func (c *Client) GasTipCap() (*big.Int, error) {
+	ctx, cancel := ctx()
+	defer cancel()
+	tip, err := c.eth.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get max priority fee error: %v", err)
+	}
+	return tip, nil
+}
+
+// BaseFee 最新区块的base fee，链未启用EIP-1559时返回错误
+func (c *Client) BaseFee() (*big.Int, error) {
+	ctx, cancel := ctx()
+	defer cancel()
+	header, err := c.eth.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get latest header error: %v", err)
+	}
+	if header.BaseFee == nil {
+		return nil, errors.New("chain does not support eip1559")
+	}
+	return header.BaseFee, nil
+}
+
+func (c *Client) EstimateGas(msg ethereum.CallMsg) (uint64, error) {
+	ctx, cancel := ctx()
+	defer cancel()
+	gas, err := c.eth.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, fmt.Errorf("estimate gas error: %v", err)
+	}
+	return gas, nil
+}
+
+func (c *Client) SendTransaction(tx *types.Transaction) error {
+	ctx, cancel := ctx()
+	defer cancel()
+	if err := c.eth.SendTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("send transaction error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package evm
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+)
+
+var (
+	// transfer(address,uint256)
+	transferMethodId = []byte{0xa9, 0x05, 0x9c, 0xbb}
+	// balanceOf(address)
+	balanceOfMethodId = []byte{0x70, 0xa0, 0x82, 0x31}
+)
+
+// TransferData BEP20/HRC20等ERC20代币transfer的input
+func TransferData(to common.Address, amount *big.Int) []byte {
+	data := make([]byte, 0, 4+32+32)
+	data = append(data, transferMethodId...)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+	return data
+}
+
+func BalanceOfData(owner common.Address) []byte {
+	return append(append([]byte{}, balanceOfMethodId...), common.LeftPadBytes(owner.Bytes(), 32)...)
+}
@@ -0,0 +1,119 @@
+package evm
+
+import (
+	"encoding/hex"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	key, address, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := PrivateKeyFromHex(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if common.HexToAddress(address) != Address(priv) || ValidAddress(address) != nil {
+		t.Fatal("private key is not match address")
+	}
+	checksum := Address(priv).Hex()
+	if err = ValidAddress(checksum); err != nil {
+		t.Fatal(err)
+	}
+	bad := []byte(checksum)
+	for i := 2; i < len(bad); i++ {
+		if bad[i] >= 'a' && bad[i] <= 'f' {
+			bad[i] -= 'a' - 'A'
+			break
+		} else if bad[i] >= 'A' && bad[i] <= 'F' {
+			bad[i] += 'a' - 'A'
+			break
+		}
+	}
+	if ValidAddress(string(bad)) == nil {
+		t.Fatal("address with bad checksum should be invalid")
+	}
+}
+
+func TestTransferData(t *testing.T) {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	data := TransferData(to, big.NewInt(1000))
+	expected := "a9059cbb" +
+		"00000000000000000000000000000000000000000000000000000000000000ff" +
+		"00000000000000000000000000000000000000000000000000000000000003e8"
+	if hex.EncodeToString(data) != expected {
+		t.Fatalf("unexpected transfer data: %x", data)
+	}
+}
+
+func TestSignTx(t *testing.T) {
+	priv, _ := PrivateKeyFromHex("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	p := &TxParams{
+		ChainId:  big.NewInt(56),
+		Nonce:    7,
+		To:       common.HexToAddress("0x00000000000000000000000000000000000000ff"),
+		Value:    big.NewInt(1),
+		GasLimit: 21000,
+		GasPrice: big.NewInt(5000000000),
+	}
+	tx, err := SignTx(priv, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Type() != types.LegacyTxType || !tx.Protected() || tx.ChainId().Int64() != 56 {
+		t.Fatal("legacy tx should be eip155 protected")
+	}
+	sender, err := types.Sender(types.NewEIP155Signer(big.NewInt(56)), tx)
+	if err != nil || sender != Address(priv) {
+		t.Fatalf("recover sender error: %v", err)
+	}
+	if p.MaxFee().Cmp(big.NewInt(21000*5000000000)) != 0 {
+		t.Fatal("unexpected max fee")
+	}
+
+	p.Eip1559 = true
+	p.GasTipCap = big.NewInt(1000000000)
+	p.GasFeeCap = big.NewInt(3000000000)
+	tx, err = SignTx(priv, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Type() != types.DynamicFeeTxType || tx.GasFeeCap().Int64() != 3000000000 {
+		t.Fatal("unexpected eip1559 tx")
+	}
+	sender, err = types.Sender(types.NewLondonSigner(big.NewInt(56)), tx)
+	if err != nil || sender != Address(priv) {
+		t.Fatalf("recover sender error: %v", err)
+	}
+	raw, err := RawTx(tx)
+	if err != nil || raw[:4] != "0x02" {
+		t.Fatalf("unexpected raw tx: %s", raw)
+	}
+	p.GasFeeCap = big.NewInt(1)
+	if _, err = SignTx(priv, p); err == nil {
+		t.Fatal("gas fee cap less than tip should fail")
+	}
+}
+
+func TestNonceManager(t *testing.T) {
+	m := NewNonceManager()
+	addr := "0xAbC0000000000000000000000000000000000001"
+	if n := m.Next(addr, 5); n != 5 {
+		t.Fatalf("nonce is %d", n)
+	}
+	// 节点pending nonce未更新时使用本地记录
+	if n := m.Next(addr, 5); n != 6 {
+		t.Fatalf("nonce is %d", n)
+	}
+	if n := m.Next("0xabc0000000000000000000000000000000000001", 9); n != 9 {
+		t.Fatalf("nonce is %d", n)
+	}
+	m.Reset(addr)
+	if n := m.Next(addr, 3); n != 3 {
+		t.Fatalf("nonce is %d", n)
+	}
+}
@@ -0,0 +1,44 @@
+package evm
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"strings"
+)
+
+// GenerateKey 生成secp256k1私钥，返回hex私钥(不带0x)和小写地址
+func GenerateKey() (string, string, error) {
+	priv, err := crypto.GenerateKey()
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(crypto.FromECDSA(priv)), strings.ToLower(crypto.PubkeyToAddress(priv.PublicKey).Hex()), nil
+}
+
+func PrivateKeyFromHex(key string) (*ecdsa.PrivateKey, error) {
+	priv, err := crypto.HexToECDSA(strings.TrimPrefix(key, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	return priv, nil
+}
+
+func Address(priv *ecdsa.PrivateKey) common.Address {
+	return crypto.PubkeyToAddress(priv.PublicKey)
+}
+
+// ValidAddress 校验地址，大小写混合时校验EIP-55 checksum
+func ValidAddress(address string) error {
+	if !common.IsHexAddress(address) || !strings.HasPrefix(address, "0x") {
+		return fmt.Errorf("invalid address: %s", address)
+	}
+	body := address[2:]
+	if strings.ToLower(body) != body && strings.ToUpper(body) != body &&
+		common.HexToAddress(address).Hex() != address {
+		return fmt.Errorf("address %s checksum error", address)
+	}
+	return nil
+}
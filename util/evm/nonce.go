@@ -0,0 +1,39 @@
+package evm
+
+import (
+	"strings"
+	"sync"
+)
+
+/*
+NonceManager 本地维护地址的nonce
+	连续出账时节点的pending nonce可能还未更新，取本地记录和节点pending nonce中较大的一个
+*/
+type NonceManager struct {
+	mu     sync.Mutex
+	nonces map[string]uint64
+}
+
+func NewNonceManager() *NonceManager {
+	return &NonceManager{nonces: make(map[string]uint64)}
+}
+
+// Next 获取本次使用的nonce，并记录下一个nonce
+func (m *NonceManager) Next(address string, pending uint64) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := strings.ToLower(address)
+	nonce := pending
+	if local, ok := m.nonces[key]; ok && local > nonce {
+		nonce = local
+	}
+	m.nonces[key] = nonce + 1
+	return nonce
+}
+
+// Reset 广播失败时清除本地记录，下次重新以节点为准
+func (m *NonceManager) Reset(address string) {
+	m.mu.Lock()
+	delete(m.nonces, strings.ToLower(address))
+	m.mu.Unlock()
+}
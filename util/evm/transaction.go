@@ -0,0 +1,102 @@
+package evm
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+)
+
+/*
+TxParams 交易参数
+	Eip1559为false时使用GasPrice构造EIP-155 legacy交易，
+	为true时使用GasTipCap/GasFeeCap构造EIP-1559交易
+*/
+type TxParams struct {
+	ChainId   *big.Int
+	Nonce     uint64
+	To        common.Address
+	Value     *big.Int
+	Data      []byte
+	GasLimit  uint64
+	GasPrice  *big.Int
+	Eip1559   bool
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// MaxGasPrice 每单位gas最多消耗的费用，用于计算手续费上限
+func (p *TxParams) MaxGasPrice() *big.Int {
+	if p.Eip1559 {
+		return p.GasFeeCap
+	}
+	return p.GasPrice
+}
+
+// MaxFee gasLimit * MaxGasPrice
+func (p *TxParams) MaxFee() *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(p.GasLimit), p.MaxGasPrice())
+}
+
+func (p *TxParams) newTx() (*types.Transaction, error) {
+	if p.ChainId == nil || p.ChainId.Sign() <= 0 {
+		return nil, errors.New("chain id must be greater than 0")
+	}
+	if p.GasLimit == 0 {
+		return nil, errors.New("gas limit is 0")
+	}
+	value := p.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	to := p.To
+	if p.Eip1559 {
+		if p.GasTipCap == nil || p.GasFeeCap == nil {
+			return nil, errors.New("gas tip cap and gas fee cap are required for eip1559 tx")
+		}
+		if p.GasFeeCap.Cmp(p.GasTipCap) < 0 {
+			return nil, errors.New("gas fee cap is less than gas tip cap")
+		}
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   p.ChainId,
+			Nonce:     p.Nonce,
+			GasTipCap: p.GasTipCap,
+			GasFeeCap: p.GasFeeCap,
+			Gas:       p.GasLimit,
+			To:        &to,
+			Value:     value,
+			Data:      p.Data,
+		}), nil
+	}
+	if p.GasPrice == nil || p.GasPrice.Sign() <= 0 {
+		return nil, errors.New("gas price must be greater than 0")
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    p.Nonce,
+		GasPrice: p.GasPrice,
+		Gas:      p.GasLimit,
+		To:       &to,
+		Value:    value,
+		Data:     p.Data,
+	}), nil
+}
+
+// SignTx 签名交易，legacy交易使用EIP-155签名
+func SignTx(priv *ecdsa.PrivateKey, p *TxParams) (*types.Transaction, error) {
+	tx, err := p.newTx()
+	if err != nil {
+		return nil, err
+	}
+	return types.SignTx(tx, types.NewLondonSigner(p.ChainId), priv)
+}
+
+// RawTx 签名后交易的hex编码，用于eth_sendRawTransaction
+func RawTx(tx *types.Transaction) (string, error) {
+	b, err := tx.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(b), nil
+}
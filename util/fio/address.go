@@ -0,0 +1,46 @@
+package fio
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+ValidFioAddress 校验fio地址(handle)，格式为 名称@域名
+	总长度3~64，名称和域名只能包含字母、数字和-，不能以-开头或结尾，不能有连续的-
+*/
+func ValidFioAddress(address string) error {
+	if len(address) < 3 || len(address) > 64 {
+		return fmt.Errorf("fio address %s length must be between 3 and 64", address)
+	}
+	parts := strings.Split(address, "@")
+	if len(parts) != 2 {
+		return fmt.Errorf("fio address %s must be in the format name@domain", address)
+	}
+	for _, part := range parts {
+		if err := validPart(part); err != nil {
+			return fmt.Errorf("invalid fio address %s: %v", address, err)
+		}
+	}
+	return nil
+}
+
+func IsFioAddress(address string) bool {
+	return strings.Contains(address, "@")
+}
+
+func validPart(s string) error {
+	if s == "" {
+		return errors.New("empty name or domain")
+	}
+	if s[0] == '-' || s[len(s)-1] == '-' || strings.Contains(s, "--") {
+		return errors.New("hyphen can not be at the start or end or repeated")
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return fmt.Errorf("invalid character %q", c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package fio
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	timeLayout = "2006-01-02T15:04:05"
+	chainCode  = "FIO"
+	tokenCode  = "FIO"
+)
+
+type Client struct {
+	url string
+}
+
+type ChainInfo struct {
+	ChainId       string `json:"chain_id"`
+	HeadBlockNum  uint32 `json:"head_block_num"`
+	HeadBlockId   string `json:"head_block_id"`
+	HeadBlockTime string `json:"head_block_time"`
+}
+
+// HeadTime 最新区块时间，节点返回的是不带时区的UTC时间
+func (info *ChainInfo) HeadTime() (time.Time, error) {
+	t := info.HeadBlockTime
+	if i := strings.IndexByte(t, '.'); i > 0 {
+		t = t[:i]
+	}
+	return time.Parse(timeLayout, t)
+}
+
+type Balance struct {
+	Balance   int64 `json:"balance"`
+	Available int64 `json:"available"` //扣除质押和锁仓后可用的余额，旧版本节点没有该字段
+}
+
+func NewClient(url string) *Client {
+	return &Client{url: strings.TrimRight(url, "/")}
+}
+
+func (c *Client) post(path string, params, result interface{}) error {
+	req, err := util.HttpPost(c.url + path).JSONBody(params)
+	if err != nil {
+		return err
+	}
+	resp, err := req.Response()
+	if err != nil {
+		return err
+	}
+	body, err := req.Bytes()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("http status %d: %s", resp.StatusCode, string(body))
+	}
+	if err = json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("json unmarshal response error: %v", err)
+	}
+	return nil
+}
+
+func (c *Client) GetInfo() (*ChainInfo, error) {
+	var info ChainInfo
+	if err := c.post("/v1/chain/get_info", struct{}{}, &info); err != nil {
+		return nil, fmt.Errorf("get info error: %v", err)
+	}
+	return &info, nil
+}
+
+// GetBalance 余额单位为SUF
+func (c *Client) GetBalance(publicKey string) (*Balance, error) {
+	balance := Balance{Available: -1}
+	if err := c.post("/v1/chain/get_fio_balance", map[string]string{"fio_public_key": publicKey}, &balance); err != nil {
+		return nil, fmt.Errorf("get %s balance error: %v", publicKey, err)
+	}
+	if balance.Available < 0 {
+		balance.Available = balance.Balance
+	}
+	return &balance, nil
+}
+
+// GetFee 获取接口手续费，不需要fio地址的接口fioAddress传空
+func (c *Client) GetFee(endpoint, fioAddress string) (int64, error) {
+	var result struct {
+		Fee int64 `json:"fee"`
+	}
+	params := map[string]string{"end_point": endpoint, "fio_address": fioAddress}
+	if err := c.post("/v1/chain/get_fee", params, &result); err != nil {
+		return 0, fmt.Errorf("get %s fee error: %v", endpoint, err)
+	}
+	return result.Fee, nil
+}
+
+// GetPubAddress 通过fio地址(如alice@wallet)获取绑定的FIO公钥
+func (c *Client) GetPubAddress(fioAddress string) (string, error) {
+	var result struct {
+		PublicAddress string `json:"public_address"`
+	}
+	params := map[string]string{"fio_address": fioAddress, "chain_code": chainCode, "token_code": tokenCode}
+	if err := c.post("/v1/chain/get_pub_address", params, &result); err != nil {
+		return "", fmt.Errorf("get %s public address error: %v", fioAddress, err)
+	}
+	if result.PublicAddress == "" {
+		return "", fmt.Errorf("fio address %s is not mapped to a public key", fioAddress)
+	}
+	return result.PublicAddress, nil
+}
+
+// TransferTokensPubKey 广播trnsfiopubky交易，返回交易id
+func (c *Client) TransferTokensPubKey(tx *PackedTransaction) (string, error) {
+	var result struct {
+		TransactionId string `json:"transaction_id"`
+	}
+	if err := c.post("/v1/chain/"+EndpointTransferToken, tx, &result); err != nil {
+		return "", fmt.Errorf("push transaction error: %v", err)
+	}
+	return result.TransactionId, nil
+}
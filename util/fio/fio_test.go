@@ -0,0 +1,138 @@
+package fio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/btcsuite/btcd/btcec"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestName(t *testing.T) {
+	v, err := StringToName("eosio.token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 6138663591592764928 {
+		t.Fatalf("eosio.token is %d", v)
+	}
+	for _, s := range []string{TokenContract, ActionTransferPubKey, PermissionActive, "a1b2c3d4e5f.j"} {
+		v, err := StringToName(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if NameToString(v) != s {
+			t.Fatalf("name round trip %s -> %s", s, NameToString(v))
+		}
+	}
+	if _, err = StringToName("Upper"); err == nil {
+		t.Fatal("upper case name should be invalid")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	wif, pub, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := WifToPrivateKey(wif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PublicKeyToString(priv.PubKey()) != pub {
+		t.Fatal("wif is not match public key")
+	}
+	pk, err := ParsePublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actor, err := ActorFromPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[a-z1-5]{12}$`).MatchString(actor) {
+		t.Fatalf("invalid actor %s", actor)
+	}
+	if _, err = ParsePublicKey("EOS" + pub[3:]); err == nil {
+		t.Fatal("public key without FIO prefix should be invalid")
+	}
+}
+
+func TestValidFioAddress(t *testing.T) {
+	for _, a := range []string{"alice@wallet", "a-b@c1", "x@y"} {
+		if err := ValidFioAddress(a); err != nil {
+			t.Fatalf("%s should be valid: %v", a, err)
+		}
+	}
+	for _, a := range []string{"alice", "@wallet", "a--b@wallet", "-a@wallet", "a@wallet-", "a@b@c", "al ice@wallet"} {
+		if err := ValidFioAddress(a); err == nil {
+			t.Fatalf("%s should be invalid", a)
+		}
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	seed := sha256.Sum256([]byte("fio test key"))
+	priv, _ := btcec.PrivKeyFromBytes(curve, seed[:])
+	actor, err := ActorFromPublicKey(priv.PubKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	payee := PublicKeyToString(priv.PubKey())
+	action, err := NewTransferPubKeyAction(payee, 1000000000, 2000000000, actor, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1字节长度 + 53字节公钥 + amount + max_fee + actor + 空tpid
+	if len(action.Data) != 1+53+8+8+8+1 || action.Data[0] != 53 {
+		t.Fatalf("unexpected action data: %x", action.Data)
+	}
+	tx := &Transaction{
+		Expiration: time.Unix(1600000000, 0),
+		Actions:    []*Action{action},
+	}
+	blockId := "00000064aabbccdd11223344" + "0000000000000000000000000000000000000000"
+	if err = tx.SetTapos(blockId); err != nil {
+		t.Fatal(err)
+	}
+	if tx.RefBlockNum != 100 || tx.RefBlockPrefix != 0x44332211 {
+		t.Fatalf("unexpected tapos %d %x", tx.RefBlockNum, tx.RefBlockPrefix)
+	}
+	packed, err := tx.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _ := hex.DecodeString("00105e5f" + "6400" + "11223344" + "000000" + "00" + "01")
+	if !bytes.Equal(packed[:len(header)], header) {
+		t.Fatalf("unexpected header: %x", packed[:len(header)])
+	}
+	if packed[len(packed)-1] != 0 {
+		t.Fatal("transaction extensions should be empty")
+	}
+
+	chainId := "21dcae42c0182200e93f954a074011f9048a7624c6fe81d3c9541a614a88bd1c"
+	ptx, err := tx.Sign(priv, chainId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ptx.PackedTrx != hex.EncodeToString(packed) {
+		t.Fatal("packed trx mismatch")
+	}
+	sig, err := ParseSignature(ptx.Signatures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := SigDigest(chainId, packed)
+	pub, compressed, err := btcec.RecoverCompact(curve, sig, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compressed || !pub.IsEqual(priv.PubKey()) {
+		t.Fatal("recovered public key mismatch")
+	}
+	if !isCanonical(sig) {
+		t.Fatal("signature is not canonical")
+	}
+}
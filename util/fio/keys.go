@@ -0,0 +1,180 @@
+package fio
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"golang.org/x/crypto/ripemd160"
+	"math/big"
+	"strings"
+)
+
+const (
+	PublicKeyPrefix = "FIO"
+	SignaturePrefix = "SIG_K1_"
+	wifVersion      = 0x80
+	maxSignRetry    = 100
+)
+
+var curve = btcec.S256()
+
+// GenerateKey 生成私钥，返回wif私钥和FIO公钥
+func GenerateKey() (string, string, error) {
+	priv, err := btcec.NewPrivateKey(curve)
+	if err != nil {
+		return "", "", err
+	}
+	return PrivateKeyToWif(priv), PublicKeyToString(priv.PubKey()), nil
+}
+
+func PrivateKeyToWif(priv *btcec.PrivateKey) string {
+	payload := append([]byte{wifVersion}, paddedKey(priv)...)
+	return base58.Encode(append(payload, doubleSha256(payload)[:4]...))
+}
+
+func WifToPrivateKey(wif string) (*btcec.PrivateKey, error) {
+	b := base58.Decode(wif)
+	defer secmem.Wipe(b)
+	if len(b) != 37 || b[0] != wifVersion {
+		return nil, errors.New("invalid wif private key")
+	}
+	if !bytes.Equal(doubleSha256(b[:33])[:4], b[33:]) {
+		return nil, errors.New("wif private key checksum error")
+	}
+	priv, _ := btcec.PrivKeyFromBytes(curve, b[1:33])
+	return priv, nil
+}
+
+// PublicKeyToString FIO + base58(压缩公钥 + ripemd160(压缩公钥)[:4])
+func PublicKeyToString(pub *btcec.PublicKey) string {
+	data := pub.SerializeCompressed()
+	return PublicKeyPrefix + base58.Encode(append(data, ripemd(data)[:4]...))
+}
+
+func ParsePublicKey(key string) (*btcec.PublicKey, error) {
+	if !strings.HasPrefix(key, PublicKeyPrefix) {
+		return nil, fmt.Errorf("public key %s must start with %s", key, PublicKeyPrefix)
+	}
+	b := base58.Decode(strings.TrimPrefix(key, PublicKeyPrefix))
+	if len(b) != 37 {
+		return nil, fmt.Errorf("invalid fio public key: %s", key)
+	}
+	if !bytes.Equal(ripemd(b[:33])[:4], b[33:]) {
+		return nil, fmt.Errorf("public key %s checksum error", key)
+	}
+	return btcec.ParsePubKey(b[:33], curve)
+}
+
+/*
+ActorFromPublicKey 由公钥推导账户名
+	与合约中key_to_account一致：跳过压缩公钥的首字节，依次取非0字节的低5位拼成name，
+	合约只保留前12个字符，所以第13个字符不参与计算
+*/
+func ActorFromPublicKey(pub *btcec.PublicKey) (string, error) {
+	key := pub.SerializeCompressed()
+	var value uint64
+	for i, n := 1, 0; n < 12; i++ {
+		if i >= len(key) {
+			return "", errors.New("public key has not enough non-zero bytes")
+		}
+		c := uint64(key[i] & 0x1f)
+		if c == 0 {
+			continue
+		}
+		value |= c << uint(5*(12-n)-1)
+		n++
+	}
+	return NameToString(value), nil
+}
+
+/*
+SignDigest 生成eosio要求的canonical紧凑签名
+	格式为 recid+31 | r | s，r和s的最高位不能为1且不能有多余的前导0，
+	不满足时换一个随机k重新签名
+*/
+func SignDigest(priv *btcec.PrivateKey, digest []byte) ([]byte, error) {
+	n := curve.N
+	halfN := new(big.Int).Rsh(n, 1)
+	z := new(big.Int).SetBytes(digest)
+	for i := 0; i < maxSignRetry; i++ {
+		k, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(1)))
+		if err != nil {
+			return nil, err
+		}
+		k.Add(k, big.NewInt(1))
+		rx, ry := curve.ScalarBaseMult(k.Bytes())
+		r := new(big.Int).Mod(rx, n)
+		if r.Sign() == 0 || rx.Cmp(n) >= 0 {
+			continue
+		}
+		s := new(big.Int).Mul(r, priv.D)
+		s.Add(s, z)
+		s.Mul(s, new(big.Int).ModInverse(k, n))
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+		recId := byte(ry.Bit(0))
+		if s.Cmp(halfN) > 0 {
+			s.Sub(n, s)
+			recId ^= 1
+		}
+		sig := make([]byte, 65)
+		sig[0] = 27 + 4 + recId
+		r.FillBytes(sig[1:33])
+		s.FillBytes(sig[33:65])
+		if isCanonical(sig) {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("can not generate canonical signature")
+}
+
+// SignatureToString SIG_K1_ + base58(签名 + ripemd160(签名 + "K1")[:4])
+func SignatureToString(sig []byte) string {
+	check := ripemd(append(append([]byte{}, sig...), 'K', '1'))
+	return SignaturePrefix + base58.Encode(append(append([]byte{}, sig...), check[:4]...))
+}
+
+func ParseSignature(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, SignaturePrefix) {
+		return nil, fmt.Errorf("signature must start with %s", SignaturePrefix)
+	}
+	b := base58.Decode(strings.TrimPrefix(s, SignaturePrefix))
+	if len(b) != 69 {
+		return nil, errors.New("invalid signature length")
+	}
+	sig := b[:65]
+	if !bytes.Equal(ripemd(append(append([]byte{}, sig...), 'K', '1'))[:4], b[65:]) {
+		return nil, errors.New("signature checksum error")
+	}
+	return sig, nil
+}
+
+func isCanonical(c []byte) bool {
+	return c[1]&0x80 == 0 && !(c[1] == 0 && c[2]&0x80 == 0) &&
+		c[33]&0x80 == 0 && !(c[33] == 0 && c[34]&0x80 == 0)
+}
+
+func paddedKey(priv *btcec.PrivateKey) []byte {
+	b := make([]byte, 32)
+	priv.D.FillBytes(b)
+	return b
+}
+
+func doubleSha256(b []byte) []byte {
+	h := sha256.Sum256(b)
+	h = sha256.Sum256(h[:])
+	return h[:]
+}
+
+func ripemd(b []byte) []byte {
+	h := ripemd160.New()
+	h.Write(b)
+	return h.Sum(nil)
+}
@@ -0,0 +1,61 @@
+package fio
+
+import (
+	"fmt"
+	"strings"
+)
+
+const nameCharmap = ".12345abcdefghijklmnopqrstuvwxyz"
+
+/*
+StringToName eosio name转为uint64
+	前12个字符每个占5位，第13个字符占低4位
+*/
+func StringToName(s string) (uint64, error) {
+	if len(s) > 13 {
+		return 0, fmt.Errorf("name %s is longer than 13 characters", s)
+	}
+	var value uint64
+	for i := 0; i <= 12; i++ {
+		var c uint64
+		if i < len(s) {
+			idx := strings.IndexByte(nameCharmap, s[i])
+			if idx < 0 {
+				return 0, fmt.Errorf("name %s contains invalid character %q", s, s[i])
+			}
+			c = uint64(idx)
+		}
+		if i < 12 {
+			value |= (c & 0x1f) << uint(64-5*(i+1))
+		} else {
+			if c > 0x0f {
+				return 0, fmt.Errorf("the 13th character of name %s is invalid", s)
+			}
+			value |= c
+		}
+	}
+	return value, nil
+}
+
+func NameToString(value uint64) string {
+	str := make([]byte, 13)
+	tmp := value
+	for i := 0; i <= 12; i++ {
+		if i == 0 {
+			str[12-i] = nameCharmap[tmp&0x0f]
+			tmp >>= 4
+		} else {
+			str[12-i] = nameCharmap[tmp&0x1f]
+			tmp >>= 5
+		}
+	}
+	return strings.TrimRight(string(str), ".")
+}
+
+func mustName(s string) uint64 {
+	v, err := StringToName(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,203 @@
+package fio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"time"
+)
+
+const (
+	TokenContract         = "fio.token"
+	ActionTransferPubKey  = "trnsfiopubky"
+	EndpointTransferToken = "transfer_tokens_pub_key"
+	PermissionActive      = "active"
+)
+
+type PermissionLevel struct {
+	Actor      string
+	Permission string
+}
+
+type Action struct {
+	Account       string
+	Name          string
+	Authorization []PermissionLevel
+	Data          []byte
+}
+
+/*
+Transaction eosio交易
+	fio没有使用context_free_actions和transaction_extensions，资源限制字段均为0
+*/
+type Transaction struct {
+	Expiration     time.Time
+	RefBlockNum    uint16
+	RefBlockPrefix uint32
+	Actions        []*Action
+}
+
+// PackedTransaction push_transaction接口的请求体
+type PackedTransaction struct {
+	Signatures            []string `json:"signatures"`
+	Compression           string   `json:"compression"`
+	PackedContextFreeData string   `json:"packed_context_free_data"`
+	PackedTrx             string   `json:"packed_trx"`
+}
+
+/*
+NewTransferPubKeyAction 构造trnsfiopubky转账
+	amount和maxFee单位为SUF，1 FIO = 1000000000 SUF
+	actor为转出账户，由转出公钥推导
+*/
+func NewTransferPubKeyAction(payeePublicKey string, amount, maxFee int64, actor, tpid string) (*Action, error) {
+	if _, err := ParsePublicKey(payeePublicKey); err != nil {
+		return nil, err
+	}
+	actorName, err := StringToName(actor)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	writeString(buf, payeePublicKey)
+	binary.Write(buf, binary.LittleEndian, amount)
+	binary.Write(buf, binary.LittleEndian, maxFee)
+	binary.Write(buf, binary.LittleEndian, actorName)
+	writeString(buf, tpid)
+	return &Action{
+		Account:       TokenContract,
+		Name:          ActionTransferPubKey,
+		Authorization: []PermissionLevel{{Actor: actor, Permission: PermissionActive}},
+		Data:          buf.Bytes(),
+	}, nil
+}
+
+/*
+SetTapos 根据区块id设置ref_block_num和ref_block_prefix
+	ref_block_num为区块高度(id前4字节，大端)的低16位，ref_block_prefix为id第8~12字节(小端)
+*/
+func (tx *Transaction) SetTapos(blockId string) error {
+	id, err := hex.DecodeString(blockId)
+	if err != nil || len(id) != 32 {
+		return fmt.Errorf("invalid block id: %s", blockId)
+	}
+	tx.RefBlockNum = uint16(binary.BigEndian.Uint32(id[:4]))
+	tx.RefBlockPrefix = binary.LittleEndian.Uint32(id[8:12])
+	return nil
+}
+
+func (tx *Transaction) Pack() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint32(tx.Expiration.Unix()))
+	binary.Write(buf, binary.LittleEndian, tx.RefBlockNum)
+	binary.Write(buf, binary.LittleEndian, tx.RefBlockPrefix)
+	writeVarUint32(buf, 0) // max_net_usage_words
+	buf.WriteByte(0)       // max_cpu_usage_ms
+	writeVarUint32(buf, 0) // delay_sec
+	writeVarUint32(buf, 0) // context_free_actions
+	writeVarUint32(buf, uint32(len(tx.Actions)))
+	for _, action := range tx.Actions {
+		if err := action.pack(buf); err != nil {
+			return nil, err
+		}
+	}
+	writeVarUint32(buf, 0) // transaction_extensions
+	return buf.Bytes(), nil
+}
+
+func (a *Action) pack(buf *bytes.Buffer) error {
+	account, err := StringToName(a.Account)
+	if err != nil {
+		return err
+	}
+	name, err := StringToName(a.Name)
+	if err != nil {
+		return err
+	}
+	binary.Write(buf, binary.LittleEndian, account)
+	binary.Write(buf, binary.LittleEndian, name)
+	writeVarUint32(buf, uint32(len(a.Authorization)))
+	for _, auth := range a.Authorization {
+		actor, err := StringToName(auth.Actor)
+		if err != nil {
+			return err
+		}
+		permission, err := StringToName(auth.Permission)
+		if err != nil {
+			return err
+		}
+		binary.Write(buf, binary.LittleEndian, actor)
+		binary.Write(buf, binary.LittleEndian, permission)
+	}
+	writeVarUint32(buf, uint32(len(a.Data)))
+	buf.Write(a.Data)
+	return nil
+}
+
+// Id 交易id为sha256(打包后的交易)
+func (tx *Transaction) Id() (string, error) {
+	packed, err := tx.Pack()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(packed)
+	return hex.EncodeToString(h[:]), nil
+}
+
+/*
+SigDigest 待签名数据
+	sha256(chainId + 打包后的交易 + context_free_data的hash)，没有context_free_data时为32字节0
+*/
+func SigDigest(chainId string, packed []byte) ([]byte, error) {
+	cid, err := hex.DecodeString(chainId)
+	if err != nil || len(cid) != 32 {
+		return nil, fmt.Errorf("invalid chain id: %s", chainId)
+	}
+	data := append(cid, packed...)
+	data = append(data, make([]byte, 32)...)
+	h := sha256.Sum256(data)
+	return h[:], nil
+}
+
+func (tx *Transaction) Sign(priv *btcec.PrivateKey, chainId string) (*PackedTransaction, error) {
+	packed, err := tx.Pack()
+	if err != nil {
+		return nil, err
+	}
+	digest, err := SigDigest(chainId, packed)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := SignDigest(priv, digest)
+	if err != nil {
+		return nil, err
+	}
+	return &PackedTransaction{
+		Signatures:            []string{SignatureToString(sig)},
+		Compression:           "none",
+		PackedContextFreeData: "",
+		PackedTrx:             hex.EncodeToString(packed),
+	}, nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	writeVarUint32(buf, uint32(len(s)))
+	buf.WriteString(s)
+}
+
+func writeVarUint32(buf *bytes.Buffer, v uint32) {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		buf.WriteByte(b)
+		if v == 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package gxc
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const maxAccountNameLength = 63
+
+// 每一段以小写字母开头，以小写字母或数字结尾，中间可包含数字和-
+var accountNameRegexp = regexp.MustCompile(`^[a-z]([a-z0-9-]*[a-z0-9])?(\.[a-z]([a-z0-9-]*[a-z0-9])?)*$`)
+
+func ValidAccountName(name string) error {
+	if len(name) == 0 || len(name) > maxAccountNameLength || !accountNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid gxc account name: %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package gxc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiDatabase  = "database"
+	apiBroadcast = "network_broadcast"
+)
+
+type Client struct {
+	rpc *util.RpcClient
+}
+
+type Account struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Options struct {
+		MemoKey string `json:"memo_key"`
+	} `json:"options"`
+}
+
+type DynamicGlobalProperties struct {
+	HeadBlockNumber uint32 `json:"head_block_number"`
+	HeadBlockId     string `json:"head_block_id"`
+	Time            string `json:"time"`
+}
+
+// HeadTime 最新区块时间，节点返回的是不带时区的UTC时间
+func (p *DynamicGlobalProperties) HeadTime() (time.Time, error) {
+	return time.Parse(timeLayout, p.Time)
+}
+
+// amount 节点返回的金额可能是数字也可能是字符串
+type amount int64
+
+func (a *amount) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*a = amount(v)
+	return nil
+}
+
+type assetAmount struct {
+	Amount  amount `json:"amount"`
+	AssetId string `json:"asset_id"`
+}
+
+func NewClient(url, user, password string) *Client {
+	return &Client{rpc: util.New(url, user, password)}
+}
+
+func (c *Client) call(api, method string, args []interface{}, resp interface{}) error {
+	data, err := c.rpc.SendRequest("call", []interface{}{api, method, args})
+	if err != nil {
+		return fmt.Errorf("call %s error: %v", method, err)
+	}
+	if resp == nil {
+		return nil
+	}
+	if data == nil {
+		return fmt.Errorf("call %s result is null", method)
+	}
+	if err = json.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("json unmarshal %s result error: %v", method, err)
+	}
+	return nil
+}
+
+func (c *Client) GetAccount(name string) (*Account, error) {
+	var account Account
+	if err := c.call(apiDatabase, "get_account_by_name", []interface{}{name}, &account); err != nil {
+		return nil, err
+	}
+	if account.Id == "" {
+		return nil, fmt.Errorf("account %s is not exist", name)
+	}
+	return &account, nil
+}
+
+func (c *Client) GetDynamicGlobalProperties() (*DynamicGlobalProperties, error) {
+	var p DynamicGlobalProperties
+	if err := c.call(apiDatabase, "get_dynamic_global_properties", []interface{}{}, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetRequiredFee 获取转账操作需要的手续费
+func (c *Client) GetRequiredFee(op *TransferOperation) (int64, error) {
+	var fees []assetAmount
+	err := c.call(apiDatabase, "get_required_fees", []interface{}{[]interface{}{[]interface{}{opTransfer, op}}, op.Fee.AssetId}, &fees)
+	if err != nil {
+		return 0, err
+	}
+	if len(fees) != 1 {
+		return 0, errors.New("get required fees result is empty")
+	}
+	return int64(fees[0].Amount), nil
+}
+
+func (c *Client) GetBalance(name, assetId string) (int64, error) {
+	var balances []assetAmount
+	if err := c.call(apiDatabase, "get_named_account_balances", []interface{}{name, []string{assetId}}, &balances); err != nil {
+		return 0, err
+	}
+	for _, b := range balances {
+		if b.AssetId == assetId {
+			return int64(b.Amount), nil
+		}
+	}
+	return 0, nil
+}
+
+func (c *Client) BroadcastTransaction(tx *Transaction) error {
+	return c.call(apiBroadcast, "broadcast_transaction", []interface{}{tx}, nil)
+}
@@ -0,0 +1,100 @@
+package gxc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/btcsuite/btcd/btcec"
+	"testing"
+	"time"
+)
+
+const (
+	testWif    = "5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3"
+	testPubKey = "GXC6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"
+)
+
+func TestKeys(t *testing.T) {
+	priv, err := WifToPrivateKey(testWif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PrivateKeyToWif(priv) != testWif {
+		t.Fatal("wif round trip mismatch")
+	}
+	if pub := PublicKeyToString(priv.PubKey()); pub != testPubKey {
+		t.Fatalf("public key %s,want %s", pub, testPubKey)
+	}
+	if _, err = ParsePublicKey(testPubKey[:len(testPubKey)-1] + "D"); err == nil {
+		t.Fatal("expected checksum error")
+	}
+	if ValidAccountName("gxc-wallet.test1") != nil || ValidAccountName("1abc") == nil || ValidAccountName("abc-") == nil {
+		t.Fatal("unexpected account name validation result")
+	}
+}
+
+func TestSignDigest(t *testing.T) {
+	priv, _ := WifToPrivateKey(testWif)
+	for i := 0; i < 20; i++ {
+		digest := sha256.Sum256([]byte{byte(i)})
+		sig, err := SignDigest(priv, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isCanonical(sig) {
+			t.Fatalf("signature is not canonical: %x", sig)
+		}
+		pub, compressed, err := btcec.RecoverCompact(curve, sig, digest[:])
+		if err != nil || !compressed || !pub.IsEqual(priv.PubKey()) {
+			t.Fatalf("recover public key error: %v", err)
+		}
+	}
+}
+
+func TestMemo(t *testing.T) {
+	alice, _ := WifToPrivateKey(testWif)
+	bob, _ := btcec.NewPrivateKey(curve)
+	memo, err := EncryptMemo(alice, bob.PubKey(), "hello gxchain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if memo.From != testPubKey {
+		t.Fatalf("unexpected memo from %s", memo.From)
+	}
+	for _, m := range []struct {
+		priv *btcec.PrivateKey
+		pub  *btcec.PublicKey
+	}{{bob, alice.PubKey()}, {alice, bob.PubKey()}} {
+		plain, err := DecryptMemo(m.priv, m.pub, memo)
+		if err != nil || plain != "hello gxchain" {
+			t.Fatalf("decrypt memo got %q,err=%v", plain, err)
+		}
+	}
+}
+
+func TestSerializeTransfer(t *testing.T) {
+	op := &TransferOperation{
+		Fee:    Asset{Amount: 1000, AssetId: "1.3.1"},
+		From:   "1.2.17",
+		To:     "1.2.300",
+		Amount: Asset{Amount: 100000, AssetId: "1.3.1"},
+	}
+	headBlockId := "00001234aabbccdd000000000000000000000000"
+	expiration := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	tx, err := NewTransferTransaction(op, 0x101234, headBlockId, expiration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := tx.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "3412" + "aabbccdd" + "0066ee5f" + "01" + "00" +
+		"e803000000000000" + "01" + "11" + "ac02" + "a086010000000000" + "01" + "00" + "00" + "00"
+	if hex.EncodeToString(data) != want {
+		t.Fatalf("serialize got %x,want %s", data, want)
+	}
+	if tx.Expiration != "2021-01-01T00:00:00" || !bytes.Equal(data[2:6], []byte{0xaa, 0xbb, 0xcc, 0xdd}) {
+		t.Fatalf("unexpected tapos %+v", tx)
+	}
+}
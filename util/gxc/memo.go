@@ -0,0 +1,93 @@
+package gxc
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"github.com/btcsuite/btcd/btcec"
+	"strconv"
+)
+
+type Memo struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Nonce   uint64 `json:"nonce,string"`
+	Message string `json:"message"` //hex编码的密文
+}
+
+/*
+EncryptMemo graphene memo加密
+	key/iv = sha512(nonce十进制字符串 + hex(sha512(ECDH共享点x)))，前32字节为key，后16字节为iv
+	明文为 sha256(memo)[:4] + memo，AES-256-CBC加密
+*/
+func EncryptMemo(priv *btcec.PrivateKey, to *btcec.PublicKey, memo string) (*Memo, error) {
+	var nb [8]byte
+	if _, err := rand.Read(nb[:]); err != nil {
+		return nil, err
+	}
+	nonce := binary.LittleEndian.Uint64(nb[:])
+	block, iv := memoCipher(priv, to, nonce)
+	checksum := sha256.Sum256([]byte(memo))
+	plain := pkcs7Pad(append(checksum[:4], memo...), aes.BlockSize)
+	ciphertext := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plain)
+	return &Memo{
+		From:    PublicKeyToString(priv.PubKey()),
+		To:      PublicKeyToString(to),
+		Nonce:   nonce,
+		Message: hex.EncodeToString(ciphertext),
+	}, nil
+}
+
+// DecryptMemo 解密memo，priv为memo收发任意一方的私钥，pub为另一方公钥
+func DecryptMemo(priv *btcec.PrivateKey, pub *btcec.PublicKey, m *Memo) (string, error) {
+	ciphertext, err := hex.DecodeString(m.Message)
+	if err != nil {
+		return "", err
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return "", errors.New("invalid memo ciphertext length")
+	}
+	block, iv := memoCipher(priv, pub, m.Nonce)
+	plain := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, ciphertext)
+	plain, err = pkcs7Unpad(plain, aes.BlockSize)
+	if err != nil || len(plain) < 4 {
+		return "", errors.New("decrypt memo error")
+	}
+	checksum := sha256.Sum256(plain[4:])
+	if !bytes.Equal(checksum[:4], plain[:4]) {
+		return "", errors.New("memo checksum error")
+	}
+	return string(plain[4:]), nil
+}
+
+func memoCipher(priv *btcec.PrivateKey, pub *btcec.PublicKey, nonce uint64) (cipher.Block, []byte) {
+	secret := sha512.Sum512([]byte(strconv.FormatUint(nonce, 10) + hex.EncodeToString(sharedSecret(priv, pub))))
+	block, _ := aes.NewCipher(secret[:32])
+	return block, secret[32:48]
+}
+
+func pkcs7Pad(b []byte, blockSize int) []byte {
+	n := blockSize - len(b)%blockSize
+	return append(b, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func pkcs7Unpad(b []byte, blockSize int) ([]byte, error) {
+	n := int(b[len(b)-1])
+	if n == 0 || n > blockSize || n > len(b) {
+		return nil, errors.New("invalid padding")
+	}
+	for _, v := range b[len(b)-n:] {
+		if int(v) != n {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return b[:len(b)-n], nil
+}
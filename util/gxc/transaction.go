@@ -0,0 +1,166 @@
+package gxc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	opTransfer = 0
+	timeLayout = "2006-01-02T15:04:05"
+)
+
+type Asset struct {
+	Amount  int64  `json:"amount"`
+	AssetId string `json:"asset_id"`
+}
+
+type TransferOperation struct {
+	Fee        Asset         `json:"fee"`
+	From       string        `json:"from"`
+	To         string        `json:"to"`
+	Amount     Asset         `json:"amount"`
+	Memo       *Memo         `json:"memo,omitempty"`
+	Extensions []interface{} `json:"extensions"`
+}
+
+// Transaction 只包含一个transfer操作的交易，json格式与broadcast_transaction参数一致
+type Transaction struct {
+	RefBlockNum    uint16          `json:"ref_block_num"`
+	RefBlockPrefix uint32          `json:"ref_block_prefix"`
+	Expiration     string          `json:"expiration"`
+	Operations     [][]interface{} `json:"operations"`
+	Extensions     []interface{}   `json:"extensions"`
+	Signatures     []string        `json:"signatures"`
+
+	transfer *TransferOperation
+}
+
+/*
+NewTransferTransaction 构造转账交易
+	headBlockNumber、headBlockId用于TAPOS，expiration为过期时间
+*/
+func NewTransferTransaction(op *TransferOperation, headBlockNumber uint32, headBlockId string, expiration time.Time) (*Transaction, error) {
+	id, err := hex.DecodeString(headBlockId)
+	if err != nil || len(id) < 8 {
+		return nil, fmt.Errorf("invalid head block id: %s", headBlockId)
+	}
+	if op.Extensions == nil {
+		op.Extensions = []interface{}{}
+	}
+	return &Transaction{
+		RefBlockNum:    uint16(headBlockNumber & 0xffff),
+		RefBlockPrefix: binary.LittleEndian.Uint32(id[4:8]),
+		Expiration:     expiration.UTC().Format(timeLayout),
+		Operations:     [][]interface{}{{opTransfer, op}},
+		Extensions:     []interface{}{},
+		Signatures:     []string{},
+		transfer:       op,
+	}, nil
+}
+
+// Serialize 序列化交易（不含签名）
+func (tx *Transaction) Serialize() ([]byte, error) {
+	expiration, err := time.Parse(timeLayout, tx.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("parse expiration error: %v", err)
+	}
+	op := tx.transfer
+	if op == nil {
+		return nil, errors.New("transaction has no transfer operation")
+	}
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, tx.RefBlockNum)
+	binary.Write(buf, binary.LittleEndian, tx.RefBlockPrefix)
+	binary.Write(buf, binary.LittleEndian, uint32(expiration.Unix()))
+	writeVarint(buf, 1)
+	writeVarint(buf, opTransfer)
+	if err = writeAsset(buf, op.Fee); err != nil {
+		return nil, err
+	}
+	for _, id := range []string{op.From, op.To} {
+		if err = writeObjectId(buf, id, "1.2."); err != nil {
+			return nil, err
+		}
+	}
+	if err = writeAsset(buf, op.Amount); err != nil {
+		return nil, err
+	}
+	if op.Memo == nil {
+		buf.WriteByte(0)
+	} else {
+		buf.WriteByte(1)
+		for _, key := range []string{op.Memo.From, op.Memo.To} {
+			pub, err := ParsePublicKey(key)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(pub.SerializeCompressed())
+		}
+		binary.Write(buf, binary.LittleEndian, op.Memo.Nonce)
+		message, err := hex.DecodeString(op.Memo.Message)
+		if err != nil {
+			return nil, fmt.Errorf("decode memo message error: %v", err)
+		}
+		writeVarint(buf, uint64(len(message)))
+		buf.Write(message)
+	}
+	// operation extensions
+	writeVarint(buf, 0)
+	// transaction extensions
+	writeVarint(buf, 0)
+	return buf.Bytes(), nil
+}
+
+// Id 交易id为sha256(序列化交易)的前20字节
+func (tx *Transaction) Id() (string, error) {
+	data, err := tx.Serialize()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:20]), nil
+}
+
+func (tx *Transaction) AddSignature(sig []byte) {
+	tx.Signatures = append(tx.Signatures, hex.EncodeToString(sig))
+}
+
+func writeVarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	buf.Write(b[:n])
+}
+
+func writeAsset(buf *bytes.Buffer, a Asset) error {
+	binary.Write(buf, binary.LittleEndian, a.Amount)
+	return writeObjectId(buf, a.AssetId, "1.3.")
+}
+
+// writeObjectId 对象id只序列化instance部分，如1.2.17只写17
+func writeObjectId(buf *bytes.Buffer, id, prefix string) error {
+	if !strings.HasPrefix(id, prefix) {
+		return fmt.Errorf("object id %s must start with %s", id, prefix)
+	}
+	instance, err := strconv.ParseUint(strings.TrimPrefix(id, prefix), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid object id %s", id)
+	}
+	writeVarint(buf, instance)
+	return nil
+}
+
+func decodeChainId(chainId string) ([]byte, error) {
+	cid, err := hex.DecodeString(chainId)
+	if err != nil || len(cid) != 32 {
+		return nil, fmt.Errorf("invalid chain id: %s", chainId)
+	}
+	return cid, nil
+}
@@ -0,0 +1,80 @@
+package hnt
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"net/http"
+	"strings"
+)
+
+type Client struct {
+	url string
+}
+
+type Account struct {
+	Address          string `json:"address"`
+	Balance          uint64 `json:"balance"`
+	DcBalance        uint64 `json:"dc_balance"`
+	Nonce            uint64 `json:"nonce"`
+	SpeculativeNonce uint64 `json:"speculative_nonce"` //包含pending交易的nonce
+}
+
+type response struct {
+	Data json.RawMessage `json:"data"`
+}
+
+func NewClient(url string) *Client {
+	return &Client{url: strings.TrimRight(url, "/")}
+}
+
+func (c *Client) do(req *util.HTTPRequest, result interface{}) error {
+	resp, err := req.Response()
+	if err != nil {
+		return err
+	}
+	body, err := req.Bytes()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http status %d: %s", resp.StatusCode, string(body))
+	}
+	var r response
+	if err = json.Unmarshal(body, &r); err != nil {
+		return fmt.Errorf("json unmarshal response error: %v", err)
+	}
+	return json.Unmarshal(r.Data, result)
+}
+
+func (c *Client) GetAccount(address string) (*Account, error) {
+	var account Account
+	if err := c.do(util.HttpGet(c.url+"/v1/accounts/"+address), &account); err != nil {
+		return nil, fmt.Errorf("get account %s error: %v", address, err)
+	}
+	return &account, nil
+}
+
+// GetTxnFeeMultiplier 获取链上变量txn_fee_multiplier
+func (c *Client) GetTxnFeeMultiplier() (uint64, error) {
+	var multiplier uint64
+	if err := c.do(util.HttpGet(c.url+"/v1/vars/txn_fee_multiplier"), &multiplier); err != nil {
+		return 0, fmt.Errorf("get txn_fee_multiplier error: %v", err)
+	}
+	return multiplier, nil
+}
+
+// SubmitTransaction 提交base64编码的交易，返回交易hash
+func (c *Client) SubmitTransaction(txn string) (string, error) {
+	req, err := util.HttpPost(c.url + "/v1/pending_transactions").JSONBody(map[string]string{"txn": txn})
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Hash string `json:"hash"`
+	}
+	if err = c.do(req, &result); err != nil {
+		return "", fmt.Errorf("submit transaction error: %v", err)
+	}
+	return result.Hash, nil
+}
@@ -0,0 +1,99 @@
+package hnt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+)
+
+func testKey() ed25519.PrivateKey {
+	seed := sha256.Sum256([]byte("hnt test seed"))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+func TestAddress(t *testing.T) {
+	priv := testKey()
+	pub := priv.Public().(ed25519.PublicKey)
+	address := EncodeAddress(Mainnet, pub)
+	bin, err := DecodeAddress(Mainnet, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bin[0] != keyTypeEd25519 || !bytes.Equal(bin[1:], pub) {
+		t.Fatalf("decode address mismatch: %x", bin)
+	}
+	if _, err = DecodeAddress(Testnet, address); err == nil {
+		t.Fatal("mainnet address should be invalid on testnet")
+	}
+	testnet := EncodeAddress(Testnet, pub)
+	if bin, err = DecodeAddress(Testnet, testnet); err != nil || bin[0] != netTypeTestnet|keyTypeEd25519 {
+		t.Fatalf("decode testnet address error: %v", err)
+	}
+	b := []byte(address)
+	if b[len(b)-1] == '1' {
+		b[len(b)-1] = '2'
+	} else {
+		b[len(b)-1] = '1'
+	}
+	if _, err = DecodeAddress(Mainnet, string(b)); err == nil {
+		t.Fatal("address with bad checksum should be invalid")
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	key, address, err := GenerateKey(Mainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := ParsePrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if EncodeAddress(Mainnet, priv.Public().(ed25519.PublicKey)) != address {
+		t.Fatal("private key is not match address")
+	}
+}
+
+func TestPaymentV2(t *testing.T) {
+	priv := testKey()
+	payer := binaryAddress(Mainnet, priv.Public().(ed25519.PublicKey))
+	payee := binaryAddress(Mainnet, make([]byte, ed25519.PublicKeySize))
+	txn := &PaymentV2{
+		Payer:    payer,
+		Payments: []Payment{{Payee: payee, Amount: 100000000}},
+		Nonce:    3,
+	}
+	// 34+2 payer, 34+2+5 payment, 2 nonce, 64+2 signature, 3 外层tag和长度
+	fee := txn.CalculateFee(0)
+	if fee != 7*DefaultTxnFeeMultiplier {
+		t.Fatalf("fee is %d", fee)
+	}
+	txn.Fee = fee
+	if err := txn.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+	raw := txn.Serialize()
+	// field 21, wire type 2
+	if raw[0] != 0xaa || raw[1] != 0x01 {
+		t.Fatalf("unexpected txn prefix: %x", raw[:2])
+	}
+	if !ed25519.Verify(priv.Public().(ed25519.PublicKey), txn.signingBytes(), txn.Signature) {
+		t.Fatal("verify signature failed")
+	}
+	if bytes.Contains(txn.signingBytes(), txn.Signature) {
+		t.Fatal("signing bytes should not contain signature")
+	}
+	if _, err := base64.StdEncoding.DecodeString(txn.ToBase64()); err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.Hash()) != 43 {
+		t.Fatalf("unexpected hash: %s", txn.Hash())
+	}
+
+	other := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	if err := txn.Sign(other); err == nil {
+		t.Fatal("sign with other key should fail")
+	}
+}
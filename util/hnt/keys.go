@@ -0,0 +1,88 @@
+package hnt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcutil/base58"
+)
+
+const (
+	addressVersion = 0x00
+	keyTypeEd25519 = 0x01
+	netTypeMainnet = 0x00
+	netTypeTestnet = 0x10
+)
+
+// Network 地址网络类型，决定地址中key type字节的高4位
+type Network byte
+
+const (
+	Mainnet Network = netTypeMainnet
+	Testnet Network = netTypeTestnet
+)
+
+/*
+GenerateKey 生成ed25519密钥
+	私钥返回base58编码的64字节私钥
+*/
+func GenerateKey(net Network) (string, string, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base58.Encode(priv), EncodeAddress(net, pub), nil
+}
+
+func ParsePrivateKey(key string) (ed25519.PrivateKey, error) {
+	b := base58.Decode(key)
+	if len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("hnt private key length is not %d", ed25519.PrivateKeySize)
+	}
+	priv := ed25519.PrivateKey(b)
+	if !ed25519.NewKeyFromSeed(priv.Seed()).Equal(priv) {
+		return nil, errors.New("hnt private key is corrupted")
+	}
+	return priv, nil
+}
+
+// EncodeAddress base58check(version + key type + 公钥)
+func EncodeAddress(net Network, pub ed25519.PublicKey) string {
+	payload := append([]byte{addressVersion}, binaryAddress(net, pub)...)
+	return base58.Encode(append(payload, checksum(payload)...))
+}
+
+/*
+DecodeAddress 解析地址，返回protobuf中使用的二进制地址（key type + 公钥）
+	只支持ed25519地址
+*/
+func DecodeAddress(net Network, address string) ([]byte, error) {
+	b := base58.Decode(address)
+	if len(b) != 1+1+ed25519.PublicKeySize+4 {
+		return nil, fmt.Errorf("invalid hnt address: %s", address)
+	}
+	payload := b[:len(b)-4]
+	if !bytes.Equal(checksum(payload), b[len(b)-4:]) {
+		return nil, fmt.Errorf("hnt address %s checksum error", address)
+	}
+	if payload[0] != addressVersion {
+		return nil, fmt.Errorf("unsupported hnt address version: %d", payload[0])
+	}
+	if payload[1] != byte(net)|keyTypeEd25519 {
+		return nil, fmt.Errorf("hnt address %s is not an ed25519 address of current network", address)
+	}
+	return payload[1:], nil
+}
+
+func binaryAddress(net Network, pub ed25519.PublicKey) []byte {
+	return append([]byte{byte(net) | keyTypeEd25519}, pub...)
+}
+
+func checksum(payload []byte) []byte {
+	h := sha256.Sum256(payload)
+	h = sha256.Sum256(h[:])
+	return h[:4]
+}
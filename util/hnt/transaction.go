@@ -0,0 +1,125 @@
+package hnt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// blockchain_txn中payment_v2的字段号
+	txnFieldPaymentV2 = 21
+	// 计算手续费时每24字节为一个DC单位
+	dcPayloadSize = 24
+	// 链上变量txn_fee_multiplier的默认值
+	DefaultTxnFeeMultiplier = 5000
+
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+type Payment struct {
+	Payee  []byte
+	Amount uint64 // bones，1 HNT = 100000000 bones
+}
+
+/*
+PaymentV2 blockchain_txn_payment_v2
+	payer = 1; payments = 2; fee = 3; nonce = 4; signature = 5
+*/
+type PaymentV2 struct {
+	Payer     []byte
+	Payments  []Payment
+	Fee       uint64 // DC
+	Nonce     uint64
+	Signature []byte
+}
+
+func (p *PaymentV2) encode() []byte {
+	buf := new(bytes.Buffer)
+	writeBytes(buf, 1, p.Payer)
+	for _, payment := range p.Payments {
+		pb := new(bytes.Buffer)
+		writeBytes(pb, 1, payment.Payee)
+		writeUint64(pb, 2, payment.Amount)
+		writeBytes(buf, 2, pb.Bytes())
+	}
+	writeUint64(buf, 3, p.Fee)
+	writeUint64(buf, 4, p.Nonce)
+	writeBytes(buf, 5, p.Signature)
+	return buf.Bytes()
+}
+
+// Serialize 序列化为blockchain_txn，即提交到api的交易
+func (p *PaymentV2) Serialize() []byte {
+	buf := new(bytes.Buffer)
+	writeBytes(buf, txnFieldPaymentV2, p.encode())
+	return buf.Bytes()
+}
+
+func (p *PaymentV2) ToBase64() string {
+	return base64.StdEncoding.EncodeToString(p.Serialize())
+}
+
+// signingBytes 待签名数据为去掉签名的payment_v2
+func (p *PaymentV2) signingBytes() []byte {
+	unsigned := *p
+	unsigned.Signature = nil
+	return unsigned.encode()
+}
+
+/*
+CalculateFee 计算手续费(DC)
+	与链上一致：fee为0、签名为64字节0时blockchain_txn的长度，每24字节乘以txn_fee_multiplier
+*/
+func (p *PaymentV2) CalculateFee(multiplier uint64) uint64 {
+	if multiplier == 0 {
+		multiplier = DefaultTxnFeeMultiplier
+	}
+	tmp := *p
+	tmp.Fee = 0
+	tmp.Signature = make([]byte, ed25519.SignatureSize)
+	size := uint64(len(tmp.Serialize()))
+	return (size + dcPayloadSize - 1) / dcPayloadSize * multiplier
+}
+
+func (p *PaymentV2) Sign(priv ed25519.PrivateKey) error {
+	if !bytes.Equal(p.Payer[1:], priv.Public().(ed25519.PublicKey)) {
+		return errors.New("private key is not match payer")
+	}
+	p.Signature = ed25519.Sign(priv, p.signingBytes())
+	return nil
+}
+
+// Hash 交易hash为sha256(去掉签名的payment_v2)，base64url编码
+func (p *PaymentV2) Hash() string {
+	h := sha256.Sum256(p.signingBytes())
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// protobuf中值为默认值的字段不序列化
+func writeUint64(buf *bytes.Buffer, field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	writeVarint(buf, uint64(field<<3|wireVarint))
+	writeVarint(buf, v)
+}
+
+func writeBytes(buf *bytes.Buffer, field int, v []byte) {
+	if len(v) == 0 {
+		return
+	}
+	writeVarint(buf, uint64(field<<3|wireBytes))
+	writeVarint(buf, uint64(len(v)))
+	buf.Write(v)
+}
+
+func writeVarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	buf.Write(b[:n])
+}
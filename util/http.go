@@ -31,6 +31,10 @@ func HttpPost(url string) *HTTPRequest {
 	return newRequest(url, http.MethodPost)
 }
 
+func HttpDelete(url string) *HTTPRequest {
+	return newRequest(url, http.MethodDelete)
+}
+
 func newRequest(rawurl, method string) *HTTPRequest {
 	var resp http.Response
 	u, err := url.Parse(rawurl)
@@ -0,0 +1,427 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/*
+私钥文件格式
+	v1: 无文件头，a文件为AES-CFB(iv为密钥前16字节)加密后的base64，b文件为截断的32位base64文本密钥
+	v2: 首行为文件头，a文件为base64(nonce + AES-256-GCM密文)，附加数据为地址；
+		b文件为base64(32字节随机数)，经hkdf-sha256派生出GCM密钥
+	v3: a文件与v2相同，b文件中的数据密钥再由主密钥加密(信封加密)
+*/
+const (
+	KeyFileVersion1 = 1
+	KeyFileVersion2 = 2
+	KeyFileVersion3 = 3
+
+	KeyFileCipherGcm = "aes-256-gcm"
+	KeyFileKdfHkdf   = "hkdf-sha256"
+	KeyFileWrapKek   = "scrypt-kek"
+
+	keyFileMagic   = "#trxsign-keyfile"
+	keyFileHkdfTag = "trxsign keyfile v2"
+)
+
+type KeyFileHeader struct {
+	Version int
+	Cipher  string
+	Kdf     string
+	Wrap    string
+}
+
+// CurrentKeyFileHeader 未启用主密钥时新生成的私钥文件使用的格式
+func CurrentKeyFileHeader() KeyFileHeader {
+	return KeyFileHeader{Version: KeyFileVersion2, Cipher: KeyFileCipherGcm, Kdf: KeyFileKdfHkdf}
+}
+
+// WrappedKeyFileHeader 启用主密钥时新生成的私钥文件使用的格式
+func WrappedKeyFileHeader() KeyFileHeader {
+	return KeyFileHeader{Version: KeyFileVersion3, Cipher: KeyFileCipherGcm, Kdf: KeyFileKdfHkdf, Wrap: KeyFileWrapKek}
+}
+
+// Record 文件头也是两列，与数据行保持一致
+func (h KeyFileHeader) Record() []string {
+	meta := fmt.Sprintf("version=%d;cipher=%s;kdf=%s", h.Version, h.Cipher, h.Kdf)
+	if h.Wrap != "" {
+		meta += ";wrap=" + h.Wrap
+	}
+	return []string{keyFileMagic, meta}
+}
+
+// ParseKeyFileHeader 解析文件首行，不是文件头时返回false
+func ParseKeyFileHeader(record []string) (KeyFileHeader, bool, error) {
+	if len(record) < 2 || record[0] != keyFileMagic {
+		return KeyFileHeader{}, false, nil
+	}
+	var h KeyFileHeader
+	for _, item := range strings.Split(record[1], ";") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "version":
+			v, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return h, true, fmt.Errorf("invalid key file version: %s", kv[1])
+			}
+			h.Version = v
+		case "cipher":
+			h.Cipher = kv[1]
+		case "kdf":
+			h.Kdf = kv[1]
+		case "wrap":
+			h.Wrap = kv[1]
+		}
+	}
+	if h != CurrentKeyFileHeader() && h != WrappedKeyFileHeader() {
+		return h, true, fmt.Errorf("unsupported key file header: %s", record[1])
+	}
+	return h, true, nil
+}
+
+/*
+EncryptPrivateKey 使用v2格式加密私钥
+	返回b文件的密钥和a文件的密文
+*/
+func EncryptPrivateKey(address string, privKey []byte) (string, string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", "", err
+	}
+	aead, err := keyFileAead(key)
+	if err != nil {
+		return "", "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", "", err
+	}
+	sealed := aead.Seal(nonce, nonce, privKey, []byte(address))
+	return base64.StdEncoding.EncodeToString(key), base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+/*
+DecryptPrivateKey 按文件版本解密私钥
+	v3的aesKey为主密钥加密后的数据密钥，mk为空时无法解密；v1、v2不使用mk
+*/
+func DecryptPrivateKey(version int, address, aesKey, ciphertext string, mk *MasterKey) ([]byte, error) {
+	switch version {
+	case KeyFileVersion1:
+		key := []byte(aesKey)
+		defer secmem.Wipe(key)
+		return AesBase64Crypt([]byte(ciphertext), key, false)
+	case KeyFileVersion3:
+		if mk == nil {
+			return nil, errors.New("master key is locked")
+		}
+		dataKey, err := mk.unwrapDataKey(address, aesKey)
+		if err != nil {
+			return nil, err
+		}
+		defer secmem.Wipe(dataKey)
+		return DecryptPrivateKey(KeyFileVersion2, address, secmem.String(dataKey), ciphertext, nil)
+	case KeyFileVersion2:
+		key, err := base64.StdEncoding.DecodeString(aesKey)
+		defer secmem.Wipe(key)
+		if err != nil || len(key) != 32 {
+			return nil, errors.New("invalid v2 aes key")
+		}
+		sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("decode ciphertext error: %v", err)
+		}
+		aead, err := keyFileAead(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(sealed) < aead.NonceSize()+aead.Overhead() {
+			return nil, errors.New("ciphertext is too short")
+		}
+		plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(address))
+		if err != nil {
+			return nil, fmt.Errorf("decrypt private key of %s error: %v", address, err)
+		}
+		return plain, nil
+	default:
+		return nil, fmt.Errorf("unsupported key file version: %d", version)
+	}
+}
+
+func keyFileAead(key []byte) (cipher.AEAD, error) {
+	derived := make([]byte, 32)
+	// aes.NewCipher会展开轮密钥，derived使用后即可清零
+	defer secmem.Wipe(derived)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(keyFileHkdfTag)), derived); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+/*
+ReadKeyFile 读取a或b文件
+	返回文件版本和地址对应的值，没有文件头的为v1
+*/
+func ReadKeyFile(path string) (int, [][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return 0, nil, fmt.Errorf("read %s error: %v", path, err)
+	}
+	if len(records) == 0 {
+		return KeyFileVersion1, nil, nil
+	}
+	h, ok, err := ParseKeyFileHeader(records[0])
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if !ok {
+		return KeyFileVersion1, records, nil
+	}
+	return h.Version, records[1:], nil
+}
+
+/*
+MigrateKeyFilePair 将一对a、b文件重新加密为新格式
+	mk为空时v1迁移为v2；mk不为空时v1、v2迁移为v3，已是目标版本的返回0
+	先写临时文件并逐行校验能解密出相同的私钥，再替换原文件
+	原文件先改名为.bak，全部替换成功后如不保留备份则删除；中途失败时可以从.bak恢复
+	返回迁移的行数
+*/
+func MigrateKeyFilePair(aPath, bPath string, mk *MasterKey, keepBackup bool) (int, error) {
+	aVersion, aRecords, err := ReadKeyFile(aPath)
+	if err != nil {
+		return 0, err
+	}
+	bVersion, bRecords, err := ReadKeyFile(bPath)
+	if err != nil {
+		return 0, err
+	}
+	if aVersion != bVersion {
+		return 0, fmt.Errorf("key file version mismatch: %s=v%d,%s=v%d", aPath, aVersion, bPath, bVersion)
+	}
+	header := CurrentKeyFileHeader()
+	if mk != nil {
+		header = WrappedKeyFileHeader()
+	}
+	if aVersion >= header.Version {
+		return 0, nil
+	}
+	aesKeys := make(map[string]string, len(bRecords))
+	for _, r := range bRecords {
+		aesKeys[r[0]] = r[1]
+	}
+	plains := make(map[string][]byte, len(aRecords))
+	newA := [][]string{header.Record()}
+	newB := [][]string{header.Record()}
+	for _, r := range aRecords {
+		address := r[0]
+		aesKey, ok := aesKeys[address]
+		if !ok {
+			return 0, fmt.Errorf("address %s has no aes key in %s", address, bPath)
+		}
+		plain, err := DecryptPrivateKey(aVersion, address, aesKey, r[1], mk)
+		if err != nil {
+			return 0, err
+		}
+		key, ciphertext, err := EncryptPrivateKey(address, plain)
+		if err != nil {
+			return 0, err
+		}
+		if mk != nil {
+			if key, err = mk.WrapDataKey(address, key); err != nil {
+				return 0, err
+			}
+		}
+		plains[address] = plain
+		newA = append(newA, []string{address, ciphertext})
+		newB = append(newB, []string{address, key})
+	}
+	aTmp, bTmp := aPath+".tmp", bPath+".tmp"
+	defer os.Remove(aTmp)
+	defer os.Remove(bTmp)
+	if err = writeCsv(aTmp, newA); err != nil {
+		return 0, err
+	}
+	if err = writeCsv(bTmp, newB); err != nil {
+		return 0, err
+	}
+	if err = verifyKeyFilePair(aTmp, bTmp, plains, mk); err != nil {
+		return 0, fmt.Errorf("verify migrated key file error: %v", err)
+	}
+	for _, p := range [][2]string{{aPath, aTmp}, {bPath, bTmp}} {
+		if err = os.Rename(p[0], p[0]+".bak"); err != nil {
+			return 0, err
+		}
+		if err = os.Rename(p[1], p[0]); err != nil {
+			return 0, err
+		}
+	}
+	if !keepBackup {
+		os.Remove(aPath + ".bak")
+		os.Remove(bPath + ".bak")
+	}
+	return len(aRecords), nil
+}
+
+// verifyKeyFilePair 重新读取文件，确认每个地址都能解密出原私钥
+func verifyKeyFilePair(aPath, bPath string, plains map[string][]byte, mk *MasterKey) error {
+	aVersion, aRecords, err := ReadKeyFile(aPath)
+	if err != nil {
+		return err
+	}
+	bVersion, bRecords, err := ReadKeyFile(bPath)
+	if err != nil {
+		return err
+	}
+	if aVersion != bVersion {
+		return fmt.Errorf("version mismatch: v%d,v%d", aVersion, bVersion)
+	}
+	if len(aRecords) != len(plains) || len(bRecords) != len(plains) {
+		return fmt.Errorf("row count mismatch: a=%d,b=%d,expect=%d", len(aRecords), len(bRecords), len(plains))
+	}
+	aesKeys := make(map[string]string, len(bRecords))
+	for _, r := range bRecords {
+		aesKeys[r[0]] = r[1]
+	}
+	for _, r := range aRecords {
+		plain, err := DecryptPrivateKey(aVersion, r[0], aesKeys[r[0]], r[1], mk)
+		if err != nil {
+			return err
+		}
+		if string(plain) != string(plains[r[0]]) {
+			return fmt.Errorf("private key of %s mismatch", r[0])
+		}
+	}
+	return nil
+}
+
+/*
+WriteKeyFilePair 只生成a、b文件，用于从备份恢复
+	mk不为空时为v3格式，否则为v2；文件已存在时返回错误，写入后重新读取校验
+*/
+func WriteKeyFilePair(aPath string, infos []AddrInfo, mk *MasterKey) error {
+	bPath := KeyFilePairPath(aPath)
+	for _, path := range []string{aPath, bPath} {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists", path)
+		}
+	}
+	header := CurrentKeyFileHeader()
+	if mk != nil {
+		header = WrappedKeyFileHeader()
+	}
+	aRecords := [][]string{header.Record()}
+	bRecords := [][]string{header.Record()}
+	plains := make(map[string][]byte, len(infos))
+	for _, info := range infos {
+		aesKey, ciphertext, err := EncryptPrivateKey(info.Address, []byte(info.PrivKey))
+		if err != nil {
+			return err
+		}
+		if mk != nil {
+			if aesKey, err = mk.WrapDataKey(info.Address, aesKey); err != nil {
+				return err
+			}
+		}
+		aRecords = append(aRecords, []string{info.Address, ciphertext})
+		bRecords = append(bRecords, []string{info.Address, aesKey})
+		plains[info.Address] = []byte(info.PrivKey)
+	}
+	if err := writeCsv(aPath, aRecords); err != nil {
+		return err
+	}
+	if err := writeCsv(bPath, bRecords); err != nil {
+		os.Remove(aPath)
+		return err
+	}
+	if err := verifyKeyFilePair(aPath, bPath, plains, mk); err != nil {
+		os.Remove(aPath)
+		os.Remove(bPath)
+		return fmt.Errorf("verify %s error: %v", aPath, err)
+	}
+	return nil
+}
+
+// RemoveKeyFileRows 从a文件及对应的b文件中删除指定地址，保留文件头
+func RemoveKeyFileRows(aPath string, addresses ...string) error {
+	remove := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		remove[address] = true
+	}
+	for _, path := range []string{aPath, KeyFilePairPath(aPath)} {
+		version, records, err := ReadKeyFile(path)
+		if err != nil {
+			return err
+		}
+		var rows [][]string
+		if version > KeyFileVersion1 {
+			header := CurrentKeyFileHeader()
+			if version == KeyFileVersion3 {
+				header = WrappedKeyFileHeader()
+			}
+			rows = append(rows, header.Record())
+		}
+		for _, r := range records {
+			if !remove[r[0]] {
+				rows = append(rows, r)
+			}
+		}
+		tmp := path + ".tmp"
+		if err = writeCsv(tmp, rows); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		if err = os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KeyFilePairPath a文件对应的b文件
+func KeyFilePairPath(aPath string) string {
+	dir, name := filepath.Split(aPath)
+	return filepath.Join(dir, strings.Replace(name, "_a_", "_b_", 1))
+}
+
+func writeCsv(path string, records [][]string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	if err = w.WriteAll(records); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,117 @@
+package util
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptPrivateKey(t *testing.T) {
+	key, ciphertext, err := EncryptPrivateKey("addr1", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := DecryptPrivateKey(KeyFileVersion2, "addr1", key, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plain) != "secret" {
+		t.Fatalf("decrypt result error: %s", plain)
+	}
+	// 密文绑定地址，不能换到其他行使用
+	if _, err = DecryptPrivateKey(KeyFileVersion2, "addr2", key, ciphertext, nil); err == nil {
+		t.Fatal("decrypt with other address should fail")
+	}
+	_, ciphertext2, _ := EncryptPrivateKey("addr1", []byte("secret"))
+	if ciphertext2 == ciphertext {
+		t.Fatal("nonce should be random")
+	}
+}
+
+func TestMigrateKeyFilePair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	aPath := filepath.Join(dir, "dot_a_usb_1.csv")
+	bPath := KeyFilePairPath(aPath)
+	if filepath.Base(bPath) != "dot_b_usb_1.csv" {
+		t.Fatalf("pair path error: %s", bPath)
+	}
+	// 按v1格式生成
+	keys := map[string]string{"addr1": "priv1", "addr2": "priv2"}
+	var aRecords, bRecords [][]string
+	for address, priv := range keys {
+		aesKey := RandBase64Key()
+		ciphertext, _ := AesBase64Crypt([]byte(priv), aesKey, true)
+		aRecords = append(aRecords, []string{address, string(ciphertext)})
+		bRecords = append(bRecords, []string{address, string(aesKey)})
+	}
+	if err = writeCsv(aPath, aRecords); err != nil {
+		t.Fatal(err)
+	}
+	if err = writeCsv(bPath, bRecords); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := MigrateKeyFilePair(aPath, bPath, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(keys) {
+		t.Fatalf("migrated %d keys,expect %d", n, len(keys))
+	}
+	aVersion, aRecords, err := ReadKeyFile(aPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, bRecords, err = ReadKeyFile(bPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aVersion != KeyFileVersion2 {
+		t.Fatalf("version is %d after migration", aVersion)
+	}
+	for i, r := range aRecords {
+		plain, err := DecryptPrivateKey(aVersion, r[0], bRecords[i][1], r[1], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(plain) != keys[r[0]] {
+			t.Fatalf("private key of %s mismatch", r[0])
+		}
+	}
+	if _, err = os.Stat(aPath + ".bak"); !os.IsNotExist(err) {
+		t.Fatal("backup should be removed")
+	}
+	// 再次迁移时跳过
+	if n, err = MigrateKeyFilePair(aPath, bPath, nil, false); err != nil || n != 0 {
+		t.Fatalf("migrate v2 file again: n=%d,err=%v", n, err)
+	}
+}
+
+func TestCreateAddrCsvHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err = CreateAddrCsv(dir, "hoo", "1", "dot", []AddrInfo{{Address: "addr1", PrivKey: "priv1"}}); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(filepath.Join(dir, "hoo", "dot_a_usb_1.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	first, err := csv.NewReader(file).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, ok, err := ParseKeyFileHeader(first); !ok || err != nil || h.Version != KeyFileVersion2 {
+		t.Fatalf("key file header error: %v %v %+v", ok, err, h)
+	}
+}
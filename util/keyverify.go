@@ -0,0 +1,288 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 私钥文件名 币种_a_usb_批次号.csv
+var keyFileNameRe = regexp.MustCompile(`^(.+)_([abcd])_usb_(.+)\.csv$`)
+
+type VerifyOptions struct {
+	CoinType  string //为空时核对所有币种
+	MchId     string //为空时核对所有商户
+	MasterKey *MasterKey
+	// Derive 由私钥推导地址，为空时只校验能否解密
+	Derive func(key []byte) (string, error)
+}
+
+type VerifyFailure struct {
+	Address string `json:"address,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+type BatchReport struct {
+	MchId     string          `json:"mchId"`
+	CoinType  string          `json:"coinType"`
+	BatchNo   string          `json:"batchNo"`
+	Version   int             `json:"version"`
+	Addresses int             `json:"addresses"` //d文件中的地址数，没有d文件时为a文件中的地址数
+	Verified  int             `json:"verified"`
+	Failures  []VerifyFailure `json:"failures"`
+}
+
+func (b *BatchReport) fail(address, format string, args ...interface{}) {
+	b.Failures = append(b.Failures, VerifyFailure{Address: address, Reason: fmt.Sprintf(format, args...)})
+}
+
+type DuplicateAddress struct {
+	Address string   `json:"address"`
+	Batches []string `json:"batches"` //商户/币种/批次号
+}
+
+/*
+VerifyReport 私钥文件核对报告
+	Sign为hmac-sha256签名，与请求签名的计算方式一致，接收方使用VerifyRequestSign校验
+*/
+type VerifyReport struct {
+	Path       string             `json:"path"`
+	CoinType   string             `json:"coinType,omitempty"`
+	MchId      string             `json:"mchId,omitempty"`
+	Derived    bool               `json:"derived"` //是否核对了私钥推导出的地址
+	Batches    []*BatchReport     `json:"batches"`
+	Duplicates []DuplicateAddress `json:"duplicates"`
+	Addresses  int                `json:"addresses"`
+	Verified   int                `json:"verified"`
+	Failed     int                `json:"failed"`
+	CreatedAt  int64              `json:"createdAt"`
+	Sign       string             `json:"sign,omitempty"`
+}
+
+// OK 所有地址都通过核对且没有重复地址
+func (r *VerifyReport) OK() bool {
+	return r.Failed == 0 && len(r.Duplicates) == 0
+}
+
+// SignReport 使用secret签名报告
+func (r *VerifyReport) SignReport(secret string) error {
+	r.Sign = ""
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	r.Sign, err = SignRequestBody(secret, body)
+	return err
+}
+
+type keyFileBatch struct {
+	mchId, coinType, batchNo string
+	files                    map[string]string //a、b、c、d对应的文件
+}
+
+/*
+VerifyKeyFiles 核对path/商户/下每个批次的私钥文件
+	d文件中的每个地址都要有能解密的a、b记录，且解密出的私钥推导出相同的地址
+	a、b中多出的地址、同一地址出现在多个批次中也记为失败
+*/
+func VerifyKeyFiles(path string, opts VerifyOptions) (*VerifyReport, error) {
+	report := &VerifyReport{
+		Path:       path,
+		CoinType:   opts.CoinType,
+		MchId:      opts.MchId,
+		Derived:    opts.Derive != nil,
+		Batches:    []*BatchReport{},
+		Duplicates: []DuplicateAddress{},
+		CreatedAt:  time.Now().Unix(),
+	}
+	batches, err := findKeyFileBatches(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[string][]string)
+	for _, batch := range batches {
+		b := &BatchReport{MchId: batch.mchId, CoinType: batch.coinType, BatchNo: batch.batchNo, Failures: []VerifyFailure{}}
+		addresses := verifyBatch(b, batch, opts)
+		name := strings.Join([]string{batch.mchId, batch.coinType, batch.batchNo}, "/")
+		for _, address := range addresses {
+			owners[address] = append(owners[address], name)
+		}
+		report.Batches = append(report.Batches, b)
+		report.Addresses += b.Addresses
+		report.Verified += b.Verified
+		report.Failed += len(b.Failures)
+	}
+	for address, names := range owners {
+		if len(names) > 1 {
+			report.Duplicates = append(report.Duplicates, DuplicateAddress{Address: address, Batches: names})
+		}
+	}
+	sort.Slice(report.Duplicates, func(i, j int) bool {
+		return report.Duplicates[i].Address < report.Duplicates[j].Address
+	})
+	return report, nil
+}
+
+// findKeyFileBatches 按商户、币种、批次号分组，只查找商户目录的第一层
+func findKeyFileBatches(path string, opts VerifyOptions) ([]*keyFileBatch, error) {
+	dirs, err := ioutil.ReadDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var batches []*keyFileBatch
+	for _, dir := range dirs {
+		if !dir.IsDir() || (opts.MchId != "" && dir.Name() != opts.MchId) {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(path, dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		found := make(map[string]*keyFileBatch)
+		for _, f := range files {
+			m := keyFileNameRe.FindStringSubmatch(f.Name())
+			if f.IsDir() || m == nil || (opts.CoinType != "" && !strings.EqualFold(m[1], opts.CoinType)) {
+				continue
+			}
+			key := m[1] + "_usb_" + m[3]
+			batch, ok := found[key]
+			if !ok {
+				batch = &keyFileBatch{mchId: dir.Name(), coinType: m[1], batchNo: m[3], files: make(map[string]string)}
+				found[key] = batch
+				batches = append(batches, batch)
+			}
+			batch.files[m[2]] = filepath.Join(path, dir.Name(), f.Name())
+		}
+	}
+	sort.Slice(batches, func(i, j int) bool {
+		a, b := batches[i], batches[j]
+		if a.mchId != b.mchId {
+			return a.mchId < b.mchId
+		}
+		if a.coinType != b.coinType {
+			return a.coinType < b.coinType
+		}
+		return a.batchNo < b.batchNo
+	})
+	return batches, nil
+}
+
+// verifyBatch 核对一个批次，返回批次中的地址
+func verifyBatch(b *BatchReport, batch *keyFileBatch, opts VerifyOptions) []string {
+	aVersion, aRecords, aErr := readBatchFile(b, batch, "a")
+	bVersion, bRecords, bErr := readBatchFile(b, batch, "b")
+	_, dRecords, dErr := readBatchFile(b, batch, "d")
+	usable := aErr == nil && bErr == nil
+	if usable && aVersion != bVersion {
+		b.fail("", "key file version mismatch,a=v%d,b=v%d", aVersion, bVersion)
+		usable = false
+	}
+	b.Version = aVersion
+
+	ciphertexts := recordMap(aRecords)
+	aesKeys := recordMap(bRecords)
+	// 没有d文件时以a文件为准，仍然核对能否解密
+	source := dRecords
+	if dErr != nil {
+		source = aRecords
+	}
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, r := range source {
+		address := r[0]
+		if seen[address] {
+			b.fail(address, "duplicate address in batch")
+			continue
+		}
+		seen[address] = true
+		addresses = append(addresses, address)
+		if !usable {
+			continue
+		}
+		ciphertext, ok := ciphertexts[address]
+		if !ok {
+			b.fail(address, "address not found in a file")
+			continue
+		}
+		aesKey, ok := aesKeys[address]
+		if !ok {
+			b.fail(address, "address not found in b file")
+			continue
+		}
+		if err := verifyKey(address, aVersion, aesKey, ciphertext, opts); err != nil {
+			b.fail(address, "%v", err)
+			continue
+		}
+		b.Verified++
+	}
+	b.Addresses = len(addresses)
+	if dErr == nil {
+		for _, records := range [][][]string{aRecords, bRecords} {
+			for _, r := range records {
+				if !seen[r[0]] {
+					b.fail(r[0], "address not found in d file")
+					seen[r[0]] = true
+				}
+			}
+		}
+	}
+	return addresses
+}
+
+func readBatchFile(b *BatchReport, batch *keyFileBatch, kind string) (int, [][]string, error) {
+	path, ok := batch.files[kind]
+	if !ok {
+		b.fail("", "%s file not found", kind)
+		return 0, nil, fmt.Errorf("%s file not found", kind)
+	}
+	version, records, err := ReadKeyFile(path)
+	if err != nil {
+		b.fail("", "%v", err)
+		return 0, nil, err
+	}
+	for i, r := range records {
+		if len(r) < 2 || r[0] == "" {
+			err = fmt.Errorf("%s record %d is invalid", filepath.Base(path), i+1)
+			b.fail("", "%v", err)
+			return 0, nil, err
+		}
+	}
+	return version, records, nil
+}
+
+func recordMap(records [][]string) map[string]string {
+	m := make(map[string]string, len(records))
+	for _, r := range records {
+		m[r[0]] = r[1]
+	}
+	return m
+}
+
+func verifyKey(address string, version int, aesKey, ciphertext string, opts VerifyOptions) error {
+	plain, err := DecryptPrivateKey(version, address, aesKey, ciphertext, opts.MasterKey)
+	if err != nil {
+		return fmt.Errorf("decrypt error: %v", err)
+	}
+	defer secmem.Wipe(plain)
+	if opts.Derive == nil {
+		return nil
+	}
+	derived, err := opts.Derive(plain)
+	if err != nil {
+		return fmt.Errorf("derive address error: %v", err)
+	}
+	if derived != address {
+		return fmt.Errorf("private key derives a different address %s", derived)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyKeyFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	infos := []AddrInfo{{Address: "addr1", PrivKey: "priv1"}, {Address: "addr2", PrivKey: "priv2"}}
+	if _, err = CreateAddrCsv(dir, "mch1", "1", "dot", infos); err != nil {
+		t.Fatal(err)
+	}
+	// 私钥与地址不匹配，且addr2与批次1重复
+	bad := []AddrInfo{{Address: "addr3", PrivKey: "priv4"}, {Address: "addr2", PrivKey: "priv2"}, {Address: "addr5", PrivKey: "priv5"}}
+	if _, err = CreateAddrCsv(dir, "mch1", "2", "dot", bad); err != nil {
+		t.Fatal(err)
+	}
+	// b文件缺少addr5
+	bPath := filepath.Join(dir, "mch1", "dot_b_usb_2.csv")
+	_, records, err := ReadKeyFile(bPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writeCsv(bPath, append([][]string{CurrentKeyFileHeader().Record()}, records[:2]...)); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := VerifyKeyFiles(dir, VerifyOptions{
+		CoinType: "dot",
+		Derive: func(key []byte) (string, error) {
+			return strings.Replace(string(key), "priv", "addr", 1), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Batches) != 2 || report.Addresses != 5 || report.Verified != 3 {
+		t.Fatalf("report counts error: %+v", report)
+	}
+	if b := report.Batches[0]; b.Verified != 2 || len(b.Failures) != 0 {
+		t.Fatalf("batch 1 should pass: %+v", b)
+	}
+	reasons := make(map[string]string)
+	for _, f := range report.Batches[1].Failures {
+		reasons[f.Address] = f.Reason
+	}
+	if !strings.Contains(reasons["addr3"], "different address") || !strings.Contains(reasons["addr5"], "not found") {
+		t.Fatalf("batch 2 failures error: %+v", report.Batches[1].Failures)
+	}
+	if len(report.Duplicates) != 1 || report.Duplicates[0].Address != "addr2" || report.OK() {
+		t.Fatalf("duplicates error: %+v", report.Duplicates)
+	}
+
+	if err = report.SignReport("secret"); err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(report)
+	if err = VerifyRequestSign("secret", body, report.Sign); err != nil {
+		t.Fatalf("report sign error: %v", err)
+	}
+	report.Failed = 0
+	body, _ = json.Marshal(report)
+	if err = VerifyRequestSign("secret", body, report.Sign); err == nil {
+		t.Fatal("modified report should not pass verification")
+	}
+}
@@ -0,0 +1,219 @@
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+/*
+信封加密的主密钥(KEK)
+	主密钥由运维提供的口令经scrypt派生，不落盘；磁盘上只保存派生参数和校验值
+	b文件中的数据密钥使用主密钥AES-256-GCM加密，附加数据为地址
+*/
+const (
+	MasterKeyKdfScrypt = "scrypt"
+
+	PassphraseSourcePrompt = "prompt"
+	PassphraseSourceEnv    = "env"
+	PassphraseSourceFd     = "fd"
+
+	masterKeyCheckData = "trxsign master key check"
+	scryptDefaultN     = 1 << 15
+	scryptDefaultR     = 8
+	scryptDefaultP     = 1
+)
+
+// MasterKeyParams 主密钥的派生参数，保存在json文件中
+type MasterKeyParams struct {
+	Kdf   string `json:"kdf"`
+	Salt  string `json:"salt"`
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	Check string `json:"check"` //主密钥加密的校验数据，用于判断口令是否正确
+}
+
+type MasterKey struct {
+	aead cipher.AEAD
+}
+
+/*
+InitMasterKey 首次使用时生成派生参数
+	参数文件已存在时返回错误，避免覆盖后已加密的数据密钥无法解密
+*/
+func InitMasterKey(path string, passphrase []byte) (*MasterKey, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("master key file %s already exists", path)
+	}
+	salt := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	params := &MasterKeyParams{
+		Kdf:  MasterKeyKdfScrypt,
+		Salt: base64.StdEncoding.EncodeToString(salt),
+		N:    scryptDefaultN,
+		R:    scryptDefaultR,
+		P:    scryptDefaultP,
+	}
+	mk, err := deriveMasterKey(params, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if params.Check, err = mk.seal([]byte(masterKeyCheckData), []byte(masterKeyCheckData)); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(path, data, 0600); err != nil {
+		return nil, err
+	}
+	return mk, nil
+}
+
+// UnlockMasterKey 使用口令解锁主密钥，口令错误时返回错误
+func UnlockMasterKey(path string, passphrase []byte) (*MasterKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("master key file %s does not exist,please init it by keymigrate -wrap", path)
+		}
+		return nil, err
+	}
+	var params MasterKeyParams
+	if err = json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("parse master key file error: %v", err)
+	}
+	mk, err := deriveMasterKey(&params, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	check, err := mk.open(params.Check, []byte(masterKeyCheckData))
+	if err != nil || !bytes.Equal(check, []byte(masterKeyCheckData)) {
+		return nil, errors.New("master key passphrase is wrong")
+	}
+	return mk, nil
+}
+
+func deriveMasterKey(params *MasterKeyParams, passphrase []byte) (*MasterKey, error) {
+	if params.Kdf != MasterKeyKdfScrypt {
+		return nil, fmt.Errorf("unsupported master key kdf: %s", params.Kdf)
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("master key passphrase is empty")
+	}
+	salt, err := base64.StdEncoding.DecodeString(params.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("decode master key salt error: %v", err)
+	}
+	key, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &MasterKey{aead: aead}, nil
+}
+
+// WrapDataKey 加密b文件中的数据密钥(base64)
+func (m *MasterKey) WrapDataKey(address, dataKey string) (string, error) {
+	return m.seal([]byte(dataKey), []byte(address))
+}
+
+// UnwrapDataKey 解密b文件中的数据密钥，返回base64的数据密钥
+func (m *MasterKey) UnwrapDataKey(address, wrapped string) (string, error) {
+	dataKey, err := m.unwrapDataKey(address, wrapped)
+	if err != nil {
+		return "", err
+	}
+	defer secmem.Wipe(dataKey)
+	return string(dataKey), nil
+}
+
+// unwrapDataKey 返回[]byte，使用后由调用方清零
+func (m *MasterKey) unwrapDataKey(address, wrapped string) ([]byte, error) {
+	dataKey, err := m.open(wrapped, []byte(address))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key of %s error: %v", address, err)
+	}
+	return dataKey, nil
+}
+
+func (m *MasterKey) seal(plain, ad []byte) (string, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(m.aead.Seal(nonce, nonce, plain, ad)), nil
+}
+
+func (m *MasterKey) open(sealed string, ad []byte) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < m.aead.NonceSize()+m.aead.Overhead() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	return m.aead.Open(nil, data[:m.aead.NonceSize()], data[m.aead.NonceSize():], ad)
+}
+
+/*
+ReadPassphrase 读取主密钥口令
+	prompt: 从终端输入，不回显
+	env: 从环境变量读取，读取后清除该环境变量
+	fd: 从文件描述符读取(如systemd传入的凭据)，去掉末尾换行
+*/
+func ReadPassphrase(source, env string, fd int) ([]byte, error) {
+	switch source {
+	case PassphraseSourcePrompt, "":
+		fmt.Fprint(os.Stderr, "Enter master key passphrase: ")
+		passphrase, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return passphrase, err
+	case PassphraseSourceEnv:
+		passphrase, ok := os.LookupEnv(env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", env)
+		}
+		os.Unsetenv(env)
+		return []byte(passphrase), nil
+	case PassphraseSourceFd:
+		file := os.NewFile(uintptr(fd), "passphrase")
+		if file == nil {
+			return nil, fmt.Errorf("invalid passphrase fd: %d", fd)
+		}
+		defer file.Close()
+		passphrase, err := ioutil.ReadAll(file)
+		if err != nil {
+			return nil, fmt.Errorf("read passphrase from fd %d error: %v", fd, err)
+		}
+		return bytes.TrimRight(passphrase, "\r\n"), nil
+	default:
+		return nil, fmt.Errorf("unsupported passphrase source: %s", source)
+	}
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMasterKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "masterkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "masterkey.json")
+	mk, err := InitMasterKey(file, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = InitMasterKey(file, []byte("other")); err == nil {
+		t.Fatal("init master key twice should fail")
+	}
+	if _, err = UnlockMasterKey(file, []byte("wrong")); err == nil {
+		t.Fatal("unlock with wrong passphrase should fail")
+	}
+	unlocked, err := UnlockMasterKey(file, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := mk.WrapDataKey("addr1", "datakey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataKey, err := unlocked.UnwrapDataKey("addr1", wrapped)
+	if err != nil || dataKey != "datakey" {
+		t.Fatalf("unwrap data key error: %s %v", dataKey, err)
+	}
+	if _, err = unlocked.UnwrapDataKey("addr2", wrapped); err == nil {
+		t.Fatal("unwrap with other address should fail")
+	}
+}
+
+func TestWrapKeyFilePair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "masterkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mk, err := InitMasterKey(filepath.Join(dir, "masterkey.json"), []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = CreateAddrCsv(dir, "hoo", "1", "dot", []AddrInfo{{Address: "addr1", PrivKey: "priv1"}}); err != nil {
+		t.Fatal(err)
+	}
+	aPath := filepath.Join(dir, "hoo", "dot_a_usb_1.csv")
+	bPath := KeyFilePairPath(aPath)
+	if n, err := MigrateKeyFilePair(aPath, bPath, mk, false); err != nil || n != 1 {
+		t.Fatalf("wrap key file error: n=%d,err=%v", n, err)
+	}
+	version, aRecords, _ := ReadKeyFile(aPath)
+	_, bRecords, _ := ReadKeyFile(bPath)
+	if version != KeyFileVersion3 {
+		t.Fatalf("version is %d after wrap", version)
+	}
+	if _, err = DecryptPrivateKey(version, "addr1", bRecords[0][1], aRecords[0][1], nil); err == nil {
+		t.Fatal("decrypt v3 without master key should fail")
+	}
+	plain, err := DecryptPrivateKey(version, "addr1", bRecords[0][1], aRecords[0][1], mk)
+	if err != nil || string(plain) != "priv1" {
+		t.Fatalf("decrypt v3 error: %s %v", plain, err)
+	}
+}
+
+func TestReadPassphraseEnv(t *testing.T) {
+	os.Setenv("TRXSIGN_TEST_PASSPHRASE", "secret")
+	passphrase, err := ReadPassphrase(PassphraseSourceEnv, "TRXSIGN_TEST_PASSPHRASE", 0)
+	if err != nil || string(passphrase) != "secret" {
+		t.Fatalf("read passphrase error: %s %v", passphrase, err)
+	}
+	if _, ok := os.LookupEnv("TRXSIGN_TEST_PASSPHRASE"); ok {
+		t.Fatal("environment variable should be unset")
+	}
+}
@@ -0,0 +1,75 @@
+package near
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"github.com/btcsuite/btcutil/base58"
+	"regexp"
+	"strings"
+)
+
+const (
+	keyTypeEd25519   = 0
+	publicKeyPrefix  = "ed25519:"
+	minAccountLength = 2
+	maxAccountLength = 64
+)
+
+var (
+	accountIdRegexp       = regexp.MustCompile(`^(([a-z\d]+[\-_])*[a-z\d]+\.)*([a-z\d]+[\-_])*[a-z\d]+$`)
+	implicitAccountRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// ImplicitAccountId 隐式账户id为公钥的小写hex
+func ImplicitAccountId(pub ed25519.PublicKey) string {
+	return hex.EncodeToString(pub)
+}
+
+func IsImplicitAccount(accountId string) bool {
+	return implicitAccountRegexp.MatchString(accountId)
+}
+
+/*
+ValidAccountId 校验账户
+	支持64位hex的隐式账户，以及以suffix结尾的命名账户，如alice.near
+	suffix为空时不检查后缀，用于token合约等不属于本网络命名空间的账户
+*/
+func ValidAccountId(accountId, suffix string) error {
+	if IsImplicitAccount(accountId) {
+		return nil
+	}
+	if len(accountId) < minAccountLength || len(accountId) > maxAccountLength || !accountIdRegexp.MatchString(accountId) {
+		return fmt.Errorf("invalid near account id: %s", accountId)
+	}
+	if suffix != "" && !strings.HasSuffix(accountId, "."+suffix) {
+		return fmt.Errorf("near account id %s is not a .%s account", accountId, suffix)
+	}
+	return nil
+}
+
+// PublicKeyString 公钥格式为ed25519:base58
+func PublicKeyString(pub ed25519.PublicKey) string {
+	return publicKeyPrefix + base58.Encode(pub)
+}
+
+// PrivateKeyString 私钥格式与near-cli的keyfile一致，ed25519:base58(64字节私钥)
+func PrivateKeyString(priv ed25519.PrivateKey) string {
+	return publicKeyPrefix + base58.Encode(priv)
+}
+
+func ParsePrivateKey(key string) (ed25519.PrivateKey, error) {
+	if !strings.HasPrefix(key, publicKeyPrefix) {
+		return nil, fmt.Errorf("unsupported near private key type")
+	}
+	b := base58.Decode(strings.TrimPrefix(key, publicKeyPrefix))
+	if len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("near private key length is not %d", ed25519.PrivateKeySize)
+	}
+	priv := ed25519.PrivateKey(b)
+	// 校验公钥部分与seed一致
+	if !ed25519.NewKeyFromSeed(priv.Seed()).Equal(priv) {
+		return nil, fmt.Errorf("near private key is corrupted")
+	}
+	return priv, nil
+}
@@ -0,0 +1,57 @@
+package near
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+var maxU128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// borsh 只实现交易序列化需要的类型
+type borsh struct {
+	bytes.Buffer
+}
+
+func (b *borsh) u8(v uint8) {
+	b.WriteByte(v)
+}
+
+func (b *borsh) u32(v uint32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	b.Write(buf[:])
+}
+
+func (b *borsh) u64(v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	b.Write(buf[:])
+}
+
+// u128 小端序16字节
+func (b *borsh) u128(v *big.Int) {
+	var buf [16]byte
+	be := v.Bytes()
+	for i := 0; i < len(be); i++ {
+		buf[i] = be[len(be)-1-i]
+	}
+	b.Write(buf[:])
+}
+
+func (b *borsh) bytes(v []byte) {
+	b.u32(uint32(len(v)))
+	b.Write(v)
+}
+
+func (b *borsh) string(v string) {
+	b.bytes([]byte(v))
+}
+
+func checkU128(v *big.Int) error {
+	if v == nil || v.Sign() < 0 || v.Cmp(maxU128) > 0 {
+		return errors.New("amount is out of u128 range")
+	}
+	return nil
+}
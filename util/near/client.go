@@ -0,0 +1,146 @@
+package near
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"math/big"
+)
+
+const finalityFinal = "final"
+
+type Client struct {
+	rpc *util.RpcClient
+}
+
+type AccessKey struct {
+	Nonce     uint64 `json:"nonce"`
+	BlockHash string `json:"block_hash"`
+	Error     string `json:"error"`
+}
+
+type account struct {
+	Amount string `json:"amount"`
+	Locked string `json:"locked"`
+}
+
+type callResult struct {
+	Result []byte `json:"result"`
+	Error  string `json:"error"`
+}
+
+type storageBalanceBounds struct {
+	Min string `json:"min"`
+}
+
+func NewClient(url, user, password string) *Client {
+	return &Client{rpc: util.New(url, user, password)}
+}
+
+func (c *Client) query(params map[string]interface{}, resp interface{}) error {
+	params["finality"] = finalityFinal
+	data, err := c.rpc.SendRequestWithParams("query", params)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return errors.New("query result is null")
+	}
+	return json.Unmarshal(data, resp)
+}
+
+// ViewAccessKey 获取access key的nonce，以及查询时的最新确认区块hash
+func (c *Client) ViewAccessKey(accountId, publicKey string) (*AccessKey, error) {
+	var ak AccessKey
+	err := c.query(map[string]interface{}{
+		"request_type": "view_access_key",
+		"account_id":   accountId,
+		"public_key":   publicKey,
+	}, &ak)
+	if err != nil {
+		return nil, fmt.Errorf("view access key error: %v", err)
+	}
+	if ak.Error != "" {
+		return nil, fmt.Errorf("view access key error: %s", ak.Error)
+	}
+	return &ak, nil
+}
+
+// GetBalance 可用余额，单位yoctoNEAR
+func (c *Client) GetBalance(accountId string) (*big.Int, error) {
+	var a account
+	err := c.query(map[string]interface{}{
+		"request_type": "view_account",
+		"account_id":   accountId,
+	}, &a)
+	if err != nil {
+		return nil, fmt.Errorf("view account error: %v", err)
+	}
+	return parseU128(a.Amount)
+}
+
+func (c *Client) callFunction(contract, method string, args interface{}, resp interface{}) error {
+	argsData, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	var r callResult
+	err = c.query(map[string]interface{}{
+		"request_type": "call_function",
+		"account_id":   contract,
+		"method_name":  method,
+		"args_base64":  base64.StdEncoding.EncodeToString(argsData),
+	}, &r)
+	if err != nil {
+		return fmt.Errorf("call %s.%s error: %v", contract, method, err)
+	}
+	if r.Error != "" {
+		return fmt.Errorf("call %s.%s error: %s", contract, method, r.Error)
+	}
+	return json.Unmarshal(r.Result, resp)
+}
+
+func (c *Client) FtBalanceOf(contract, accountId string) (*big.Int, error) {
+	var balance string
+	if err := c.callFunction(contract, "ft_balance_of", map[string]string{"account_id": accountId}, &balance); err != nil {
+		return nil, err
+	}
+	return parseU128(balance)
+}
+
+// IsStorageRegistered 账户是否已在token合约中注册存储
+func (c *Client) IsStorageRegistered(contract, accountId string) (bool, error) {
+	var balance *json.RawMessage
+	if err := c.callFunction(contract, "storage_balance_of", map[string]string{"account_id": accountId}, &balance); err != nil {
+		return false, err
+	}
+	return balance != nil, nil
+}
+
+// StorageDepositMin 注册存储所需的最少押金
+func (c *Client) StorageDepositMin(contract string) (*big.Int, error) {
+	var bounds storageBalanceBounds
+	if err := c.callFunction(contract, "storage_balance_bounds", map[string]string{}, &bounds); err != nil {
+		return nil, err
+	}
+	return parseU128(bounds.Min)
+}
+
+// BroadcastTxAsync 广播交易，返回交易hash
+func (c *Client) BroadcastTxAsync(signedTx string) (string, error) {
+	data, err := c.rpc.SendRequest("broadcast_tx_async", []interface{}{signedTx})
+	if err != nil {
+		return "", fmt.Errorf("broadcast tx error: %v", err)
+	}
+	return string(data), nil
+}
+
+func parseU128(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("parse amount %s error", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,146 @@
+package near
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcutil/base58"
+	"math/big"
+)
+
+const (
+	actionFunctionCall = 2
+	actionTransfer     = 3
+
+	DefaultFunctionCallGas = 30000000000000 // 30 Tgas
+)
+
+var oneYocto = big.NewInt(1)
+
+type Action interface {
+	encode(b *borsh)
+}
+
+type TransferAction struct {
+	Deposit *big.Int
+}
+
+func (a *TransferAction) encode(b *borsh) {
+	b.u8(actionTransfer)
+	b.u128(a.Deposit)
+}
+
+type FunctionCallAction struct {
+	MethodName string
+	Args       []byte
+	Gas        uint64
+	Deposit    *big.Int
+}
+
+func (a *FunctionCallAction) encode(b *borsh) {
+	b.u8(actionFunctionCall)
+	b.string(a.MethodName)
+	b.bytes(a.Args)
+	b.u64(a.Gas)
+	b.u128(a.Deposit)
+}
+
+func NewTransferAction(deposit *big.Int) (Action, error) {
+	if err := checkU128(deposit); err != nil {
+		return nil, err
+	}
+	return &TransferAction{Deposit: deposit}, nil
+}
+
+// NewFtTransferAction NEP-141 ft_transfer，需附带1 yoctoNEAR
+func NewFtTransferAction(receiverId string, amount *big.Int, gas uint64) (Action, error) {
+	if err := checkU128(amount); err != nil {
+		return nil, err
+	}
+	args, err := json.Marshal(map[string]string{
+		"receiver_id": receiverId,
+		"amount":      amount.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &FunctionCallAction{MethodName: "ft_transfer", Args: args, Gas: gas, Deposit: oneYocto}, nil
+}
+
+// NewStorageDepositAction NEP-145 storage_deposit，为接收账户在token合约中注册存储
+func NewStorageDepositAction(accountId string, deposit *big.Int, gas uint64) (Action, error) {
+	if err := checkU128(deposit); err != nil {
+		return nil, err
+	}
+	args, err := json.Marshal(map[string]interface{}{
+		"account_id":        accountId,
+		"registration_only": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &FunctionCallAction{MethodName: "storage_deposit", Args: args, Gas: gas, Deposit: deposit}, nil
+}
+
+/*
+Transaction near交易
+	Nonce: access key当前nonce+1
+	BlockHash: base58编码的近期区块hash，超过约24小时交易失效
+*/
+type Transaction struct {
+	SignerId   string
+	PublicKey  ed25519.PublicKey
+	Nonce      uint64
+	ReceiverId string
+	BlockHash  string
+	Actions    []Action
+}
+
+func (tx *Transaction) Serialize() ([]byte, error) {
+	if len(tx.PublicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key length")
+	}
+	blockHash := base58.Decode(tx.BlockHash)
+	if len(blockHash) != sha256.Size {
+		return nil, fmt.Errorf("invalid block hash: %s", tx.BlockHash)
+	}
+	if len(tx.Actions) == 0 {
+		return nil, errors.New("transaction has no action")
+	}
+	b := new(borsh)
+	b.string(tx.SignerId)
+	b.u8(keyTypeEd25519)
+	b.Write(tx.PublicKey)
+	b.u64(tx.Nonce)
+	b.string(tx.ReceiverId)
+	b.Write(blockHash)
+	b.u32(uint32(len(tx.Actions)))
+	for _, a := range tx.Actions {
+		a.encode(b)
+	}
+	return b.Bytes(), nil
+}
+
+/*
+Sign 对sha256(borsh(tx))签名
+	返回base64编码的SignedTransaction以及base58编码的交易hash
+*/
+func (tx *Transaction) Sign(priv ed25519.PrivateKey) (string, string, error) {
+	if !priv.Public().(ed25519.PublicKey).Equal(tx.PublicKey) {
+		return "", "", errors.New("private key is not match transaction public key")
+	}
+	data, err := tx.Serialize()
+	if err != nil {
+		return "", "", err
+	}
+	hash := sha256.Sum256(data)
+	sig := ed25519.Sign(priv, hash[:])
+	b := new(borsh)
+	b.Write(data)
+	b.u8(keyTypeEd25519)
+	b.Write(sig)
+	return base64.StdEncoding.EncodeToString(b.Bytes()), base58.Encode(hash[:]), nil
+}
@@ -0,0 +1,109 @@
+package near
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/btcsuite/btcutil/base58"
+	"math/big"
+	"testing"
+)
+
+func TestValidAccountId(t *testing.T) {
+	valid := []string{
+		"alice.near",
+		"app-1_x.alice.near",
+		"98793cd91a3f870fb126f66285808c7e094afcfc4eda8a970f6648cdf0dbd6de",
+	}
+	for _, id := range valid {
+		if err := ValidAccountId(id, "near"); err != nil {
+			t.Fatalf("%s should be valid: %v", id, err)
+		}
+	}
+	invalid := []string{
+		"alice.testnet",
+		"Alice.near",
+		"alice..near",
+		"-alice.near",
+		"a",
+		"98793CD91A3F870FB126F66285808C7E094AFCFC4EDA8A970F6648CDF0DBD6DE",
+	}
+	for _, id := range invalid {
+		if err := ValidAccountId(id, "near"); err == nil {
+			t.Fatalf("%s should be invalid", id)
+		}
+	}
+	// 合约账户不检查后缀
+	for _, id := range []string{"usdt", "token.testnet", "dac17f958d2ee523a2206206994597c13d831ec7.factory.bridge.near"} {
+		if err := ValidAccountId(id, ""); err != nil {
+			t.Fatalf("%s should be a valid contract id: %v", id, err)
+		}
+	}
+}
+
+func TestBorshU128(t *testing.T) {
+	b := new(borsh)
+	b.u128(big.NewInt(0x0102))
+	want := append([]byte{0x02, 0x01}, make([]byte, 14)...)
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Fatalf("u128 encode %x", b.Bytes())
+	}
+	if checkU128(new(big.Int).Lsh(big.NewInt(1), 128)) == nil {
+		t.Fatal("expected u128 overflow")
+	}
+}
+
+func TestSignTransfer(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+	key, err := ParsePrivateKey(PrivateKeyString(priv))
+	if err != nil || !key.Equal(priv) {
+		t.Fatalf("private key round trip error: %v", err)
+	}
+	pub := priv.Public().(ed25519.PublicKey)
+	transfer, _ := NewTransferAction(big.NewInt(1))
+	tx := &Transaction{
+		SignerId:   ImplicitAccountId(pub),
+		PublicKey:  pub,
+		Nonce:      1,
+		ReceiverId: "whatever.near",
+		BlockHash:  base58.Encode(bytes.Repeat([]byte{1}, 32)),
+		Actions:    []Action{transfer},
+	}
+	data, err := tx.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// signer(4+64) + key(1+32) + nonce(8) + receiver(4+13) + hash(32) + actions(4) + transfer(1+16)
+	if len(data) != 68+33+8+17+32+4+17 {
+		t.Fatalf("unexpected tx length %d: %s", len(data), hex.EncodeToString(data))
+	}
+	if data[len(data)-17] != actionTransfer || data[len(data)-16] != 1 {
+		t.Fatalf("unexpected transfer action %x", data[len(data)-17:])
+	}
+	signed, hash, err := tx.Sign(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(signed)
+	digest := sha256.Sum256(data)
+	if !bytes.Equal(raw[:len(data)], data) || raw[len(data)] != keyTypeEd25519 || hash != base58.Encode(digest[:]) {
+		t.Fatal("unexpected signed transaction")
+	}
+	if !ed25519.Verify(pub, digest[:], raw[len(data)+1:]) {
+		t.Fatal("signature verify failed")
+	}
+}
+
+func TestFtTransferActions(t *testing.T) {
+	deposit, _ := NewStorageDepositAction("bob.near", big.NewInt(1250000000000000000), DefaultFunctionCallGas)
+	ft, _ := NewFtTransferAction("bob.near", big.NewInt(100), DefaultFunctionCallGas)
+	if a := deposit.(*FunctionCallAction); a.MethodName != "storage_deposit" || string(a.Args) != `{"account_id":"bob.near","registration_only":true}` {
+		t.Fatalf("unexpected storage_deposit action %+v", a)
+	}
+	a := ft.(*FunctionCallAction)
+	if a.MethodName != "ft_transfer" || string(a.Args) != `{"amount":"100","receiver_id":"bob.near"}` || a.Deposit.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("unexpected ft_transfer action %+v", a)
+	}
+}
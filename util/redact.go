@@ -0,0 +1,117 @@
+package util
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// 字段名包含这些词时整个值替换
+	sensitiveFieldRe = regexp.MustCompile(`(?i)priv|secret|seed|mnemonic|passphrase|password|aes_?key|data_?key|wif`)
+	// 关键字后面的值，如 privKey=xxx、"private key: xxx"、{PrivKey:xxx}
+	sensitiveValueRe = regexp.MustCompile(`(?i)\b((?:priv\w*|secret\w*|seed|mnemonic|aes_?key|data_?key|wif)(?:\s*key)?["']?\s*[:=]\s*["']?)(?:0x)?[0-9A-Za-z+/_-]{16,}=*`)
+	// 比特币/石墨烯系的wif私钥
+	wifRe = regexp.MustCompile(`\b[5KL][1-9A-HJ-NP-Za-km-z]{50,51}\b`)
+	// JWK中的RSA私钥参数
+	jwkRe = regexp.MustCompile(`("(?:d|p|q|dp|dq|qi)"\s*:\s*")[A-Za-z0-9_-]+(")`)
+	pemRe = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+	// 连续的小写单词，再按bip39词表判断是否为助记词
+	wordsRe = regexp.MustCompile(`\b[a-z]+(?: [a-z]+){11,}\b`)
+
+	mnemonicWords = func() map[string]bool {
+		words := make(map[string]bool, len(wordlists.English))
+		for _, w := range wordlists.English {
+			words[w] = true
+		}
+		return words
+	}()
+)
+
+// 至少12个连续的词表单词视为助记词
+const mnemonicMinWords = 12
+
+/*
+Redact 把字符串中形似私钥的内容替换为[REDACTED]
+	不带关键字的64位hex与交易hash无法区分，不做处理
+*/
+func Redact(s string) string {
+	s = pemRe.ReplaceAllString(s, redacted)
+	s = jwkRe.ReplaceAllString(s, "${1}"+redacted+"${2}")
+	s = sensitiveValueRe.ReplaceAllString(s, "${1}"+redacted)
+	s = wifRe.ReplaceAllString(s, redacted)
+	return wordsRe.ReplaceAllStringFunc(s, redactMnemonic)
+}
+
+func redactMnemonic(s string) string {
+	words := strings.Split(s, " ")
+	var out []string
+	for i := 0; i < len(words); {
+		j := i
+		for j < len(words) && mnemonicWords[words[j]] {
+			j++
+		}
+		if j-i >= mnemonicMinWords {
+			out = append(out, redacted)
+			i = j
+			continue
+		}
+		if j == i {
+			j++
+		}
+		out = append(out, words[i:j]...)
+		i = j
+	}
+	return strings.Join(out, " ")
+}
+
+// RedactHook logrus钩子，输出前脱敏日志内容和字段
+type RedactHook struct{}
+
+func NewRedactHook() *RedactHook {
+	return &RedactHook{}
+}
+
+func (h *RedactHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+/*
+Fire 脱敏消息和字段
+	Data与调用方的Entry共用，有改动时复制一份，避免修改调用方的字段和并发写map
+*/
+func (h *RedactHook) Fire(entry *log.Entry) error {
+	entry.Message = Redact(entry.Message)
+	var data log.Fields
+	for k, v := range entry.Data {
+		var r string
+		if sensitiveFieldRe.MatchString(k) {
+			r = redacted
+		} else {
+			switch val := v.(type) {
+			case string, error, fmt.Stringer:
+				if s := fmt.Sprint(val); Redact(s) != s {
+					r = Redact(s)
+				}
+			}
+		}
+		if r == "" {
+			continue
+		}
+		if data == nil {
+			data = make(log.Fields, len(entry.Data))
+			for k, v := range entry.Data {
+				data[k] = v
+			}
+		}
+		data[k] = r
+	}
+	if data != nil {
+		entry.Data = data
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package util_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/group-coldwallet/trxsign/util/ar"
+	"github.com/group-coldwallet/trxsign/util/evm"
+	"github.com/group-coldwallet/trxsign/util/fio"
+	log "github.com/sirupsen/logrus"
+	"github.com/tyler-smith/go-bip39"
+	"strings"
+	"testing"
+)
+
+// TestRedactHookLeak 按常见的错误写法把各种私钥打到日志里，扫描输出确认没有泄露
+func TestRedactHookLeak(t *testing.T) {
+	var out bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&log.TextFormatter{DisableColors: true})
+	logger.AddHook(util.NewRedactHook())
+
+	evmKey, evmAddr, err := evm.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wif, fioPub, err := fio.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk, _, err := ar.GenerateWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, solPriv, _ := ed25519.GenerateKey(rand.Reader)
+	solKey := base58.Encode(solPriv)
+	entropy, _ := bip39.NewEntropy(128)
+	mnemonic, _ := bip39.NewMnemonic(entropy)
+	txid := strings.Repeat("ab", 32)
+
+	logger.Infof("create address %s,privKey=%s", evmAddr, evmKey)
+	logger.Infof("addrInfo %+v", util.AddrInfo{Address: evmAddr, PrivKey: evmKey, Mnemonic: mnemonic})
+	logger.WithField("privateKey", solKey).Info("load sol key")
+	logger.WithError(fmt.Errorf("invalid private key: %s", solKey)).Error("parse key error")
+	logger.Infof("fio key %s %s", fioPub, wif)
+	logger.Debugf("wallet %s", jwk)
+	logger.WithField("data", jwk).Warn("ar wallet")
+	logger.Infof("mnemonic %s", mnemonic)
+	logger.Infof("broadcast tx %s", txid)
+
+	var rsaKey map[string]string
+	if err = json.Unmarshal([]byte(jwk), &rsaKey); err != nil {
+		t.Fatal(err)
+	}
+	secrets := map[string]string{
+		"evm":      evmKey,
+		"sol":      solKey,
+		"wif":      wif,
+		"jwk.d":    rsaKey["d"],
+		"jwk.p":    rsaKey["p"],
+		"jwk.q":    rsaKey["q"],
+		"mnemonic": strings.Join(strings.Fields(mnemonic)[:4], " "),
+	}
+	logs := out.String()
+	for name, secret := range secrets {
+		if secret == "" || strings.Contains(logs, secret) {
+			t.Errorf("%s private key leaked to log", name)
+		}
+	}
+	// 地址、公钥和交易hash保持原样
+	for _, s := range []string{evmAddr, fioPub, txid} {
+		if !strings.Contains(logs, s) {
+			t.Errorf("%s should not be redacted", s)
+		}
+	}
+}
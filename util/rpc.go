@@ -18,7 +18,7 @@ type RpcClient struct {
 
 type RequestBody struct {
 	ReqNotHaveParams
-	Params []interface{} `json:"params"`
+	Params interface{} `json:"params"`
 }
 type ReqNotHaveParams struct {
 	JsonRpc string `json:"jsonrpc"`
@@ -46,6 +46,14 @@ func New(url, user, password string) *RpcClient {
 }
 
 func (rpc *RpcClient) SendRequest(method string, params []interface{}) ([]byte, error) {
+	if params == nil {
+		return rpc.SendRequestWithParams(method, nil)
+	}
+	return rpc.SendRequestWithParams(method, params)
+}
+
+//params为对象的rpc请求，例如near的query接口
+func (rpc *RpcClient) SendRequestWithParams(method string, params interface{}) ([]byte, error) {
 	id := rand.Intn(10000)
 	var (
 		reqBytes []byte
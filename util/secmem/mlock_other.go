@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package secmem
+
+// 不支持mlock的平台使用普通内存，仍会在Destroy时清零
+func alloc(size int) (mem []byte, mapped, locked bool) {
+	return make([]byte, size), false, false
+}
+
+func free(mem []byte, mapped, locked bool) {}
@@ -0,0 +1,38 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package secmem
+
+import (
+	"golang.org/x/sys/unix"
+	"os"
+	"sync"
+)
+
+var warnOnce sync.Once
+
+// alloc 使用mmap单独分配，避免与其他对象共用页；mlock失败(如RLIMIT_MEMLOCK不足)时仍可使用
+func alloc(size int) (mem []byte, mapped, locked bool) {
+	pageSize := os.Getpagesize()
+	n := (size + pageSize - 1) / pageSize * pageSize
+	mem, err := unix.Mmap(-1, 0, n, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return make([]byte, size), false, false
+	}
+	if err = unix.Mlock(mem); err != nil {
+		warnOnce.Do(func() {
+			os.Stderr.WriteString("secmem: mlock failed,keys may be swapped to disk: " + err.Error() + "\n")
+		})
+		return mem, true, false
+	}
+	return mem, true, true
+}
+
+func free(mem []byte, mapped, locked bool) {
+	if locked {
+		unix.Munlock(mem)
+	}
+	if mapped {
+		unix.Munmap(mem)
+	}
+}
@@ -0,0 +1,169 @@
+package secmem
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+	"unsafe"
+)
+
+/*
+私钥等敏感数据的内存保护
+	Buffer使用单独映射并mlock的内存，不会被换出到swap，Destroy时清零
+	Go的string无法清零，私钥只以[]byte传递；第三方库只接受string时使用String，不产生拷贝
+*/
+
+var ErrDestroyed = errors.New("secure buffer is destroyed")
+
+type Buffer struct {
+	mu     sync.Mutex
+	data   []byte
+	mem    []byte //实际分配的内存，data为其前len个字节
+	mapped bool
+	locked bool
+}
+
+// New 分配size字节的受保护内存，mlock失败时退化为普通内存
+func New(size int) (*Buffer, error) {
+	if size <= 0 {
+		return nil, errors.New("invalid secure buffer size")
+	}
+	mem, mapped, locked := alloc(size)
+	return &Buffer{data: mem[:size], mem: mem, mapped: mapped, locked: locked}, nil
+}
+
+// FromBytes 拷贝到受保护内存后清零src
+func FromBytes(src []byte) (*Buffer, error) {
+	if len(src) == 0 {
+		return nil, errors.New("secure buffer is empty")
+	}
+	b, err := New(len(src))
+	if err != nil {
+		Wipe(src)
+		return nil, err
+	}
+	copy(b.data, src)
+	Wipe(src)
+	return b, nil
+}
+
+// Bytes Destroy之后不能再使用返回值
+func (b *Buffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.data
+}
+
+func (b *Buffer) Locked() bool {
+	return b.locked
+}
+
+// Destroy 清零并释放内存，可重复调用
+func (b *Buffer) Destroy() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.mem == nil {
+		return
+	}
+	Wipe(b.mem)
+	free(b.mem, b.mapped, b.locked)
+	b.data, b.mem = nil, nil
+}
+
+// Wipe 清零，编译器不会优化掉对切片的写入
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// WipeBigInt 清零ecdsa等私钥中的大整数
+func WipeBigInt(n *big.Int) {
+	if n == nil {
+		return
+	}
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	n.SetInt64(0)
+}
+
+/*
+String 与b共用内存的string，不产生拷贝
+	只能在b清零之前使用，且不能被保存；b清零后该string的内容也随之变为0
+*/
+func String(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// 进程内的封装密钥，随机生成，只保存在受保护内存中
+var (
+	sealOnce sync.Once
+	sealAead cipher.AEAD
+	sealErr  error
+)
+
+func sealer() (cipher.AEAD, error) {
+	sealOnce.Do(func() {
+		var key *Buffer
+		if key, sealErr = New(32); sealErr != nil {
+			return
+		}
+		if _, sealErr = io.ReadFull(rand.Reader, key.Bytes()); sealErr != nil {
+			return
+		}
+		var block cipher.Block
+		if block, sealErr = aes.NewCipher(key.Bytes()); sealErr != nil {
+			return
+		}
+		sealAead, sealErr = cipher.NewGCM(block)
+	})
+	return sealAead, sealErr
+}
+
+/*
+Seal 使用进程内的随机密钥加密，用于需要长期保存在内存中的密钥(如b文件中的数据密钥)
+	内存被转储时得不到明文，进程重启后无法解密
+*/
+func Seal(plain []byte) ([]byte, error) {
+	aead, err := sealer()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+// Open 解密到受保护内存，使用后需要Destroy
+func Open(sealed []byte) (*Buffer, error) {
+	aead, err := sealer()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("sealed data is too short")
+	}
+	b, err := New(len(sealed) - aead.NonceSize() - aead.Overhead())
+	if err != nil {
+		return nil, err
+	}
+	if _, err = aead.Open(b.data[:0], sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil); err != nil {
+		b.Destroy()
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,62 @@
+package secmem
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestFromBytes(t *testing.T) {
+	src := []byte("private key")
+	b, err := FromBytes(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, make([]byte, len(src))) {
+		t.Fatal("source should be wiped")
+	}
+	if string(b.Bytes()) != "private key" {
+		t.Fatalf("buffer content error: %q", b.Bytes())
+	}
+	b.Destroy()
+	if b.Bytes() != nil {
+		t.Fatal("destroyed buffer should be empty")
+	}
+	b.Destroy()
+}
+
+func TestSeal(t *testing.T) {
+	sealed, err := Seal([]byte("aes key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("aes key")) {
+		t.Fatal("sealed data should not contain plaintext")
+	}
+	b, err := Open(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Destroy()
+	if string(b.Bytes()) != "aes key" {
+		t.Fatalf("open result error: %q", b.Bytes())
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err = Open(sealed); err == nil {
+		t.Fatal("open tampered data should fail")
+	}
+}
+
+func TestWipeBigInt(t *testing.T) {
+	n, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	words := n.Bits()
+	WipeBigInt(n)
+	if n.Sign() != 0 {
+		t.Fatal("big int should be zero")
+	}
+	for _, w := range words[:cap(words)] {
+		if w != 0 {
+			t.Fatal("big int words should be wiped")
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package shamir
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const ShareFileVersion = 1
+
+/*
+ShareFile 份额文件，每个保管人一份
+	秘密为c文件(地址,私钥[,助记词])的完整内容，Checksum为其sha256，用于恢复后校验
+*/
+type ShareFile struct {
+	Version   int    `json:"version"`
+	CoinType  string `json:"coinType"`
+	MchId     string `json:"mchId"`
+	BatchNo   string `json:"batchNo"`
+	Threshold int    `json:"threshold"`
+	Total     int    `json:"total"`
+	Index     int    `json:"index"`
+	Addresses int    `json:"addresses"`
+	Checksum  string `json:"checksum"`
+	Share     []byte `json:"share"`
+}
+
+// ShareFileName 币种_share_序号of总数_usb_批次号.json
+func ShareFileName(coinType, batchNo string, index, total int) string {
+	return fmt.Sprintf("%s_share_%dof%d_usb_%s.json", coinType, index, total, batchNo)
+}
+
+/*
+WriteShareFiles 拆分secret，第i个份额写入dirs[i]，返回文件路径
+	meta中的Threshold、Total为拆分参数，其余字段原样写入；同名文件已存在时返回错误
+	dirs的数量必须等于Total，每个目录对应一个保管人
+*/
+func WriteShareFiles(dirs []string, meta ShareFile, secret []byte) ([]string, error) {
+	if len(dirs) != meta.Total {
+		return nil, fmt.Errorf("share dirs count %d is not equal to total %d", len(dirs), meta.Total)
+	}
+	shares, err := Split(secret, meta.Total, meta.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(secret)
+	meta.Version = ShareFileVersion
+	meta.Checksum = hex.EncodeToString(sum[:])
+	var paths []string
+	for i, s := range shares {
+		if err = os.MkdirAll(dirs[i], 0700); err != nil {
+			return paths, err
+		}
+		f := meta
+		f.Index = int(s.X)
+		f.Share = s.Y
+		data, err := json.MarshalIndent(&f, "", "  ")
+		if err != nil {
+			return paths, err
+		}
+		path := filepath.Join(dirs[i], ShareFileName(meta.CoinType, meta.BatchNo, f.Index, f.Total))
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return paths, err
+		}
+		_, err = file.Write(data)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func ReadShareFile(path string) (*ShareFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f ShareFile
+	if err = json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse share file %s error: %v", path, err)
+	}
+	if f.Version != ShareFileVersion {
+		return nil, fmt.Errorf("unsupported share file version %d: %s", f.Version, path)
+	}
+	if f.Index < 1 || f.Index > MaxShares {
+		return nil, fmt.Errorf("invalid share index %d: %s", f.Index, path)
+	}
+	return &f, nil
+}
+
+/*
+CombineShareFiles 合并份额恢复秘密
+	份额必须来自同一批次且数量不少于threshold，恢复结果与Checksum不一致时返回错误
+*/
+func CombineShareFiles(files []*ShareFile) (*ShareFile, []byte, error) {
+	if len(files) == 0 {
+		return nil, nil, errors.New("no share files")
+	}
+	first := files[0]
+	if len(files) < first.Threshold {
+		return nil, nil, fmt.Errorf("need %d shares,got %d", first.Threshold, len(files))
+	}
+	shares := make([]Share, 0, len(files))
+	for _, f := range files {
+		if f.CoinType != first.CoinType || f.MchId != first.MchId || f.BatchNo != first.BatchNo ||
+			f.Threshold != first.Threshold || f.Total != first.Total || f.Checksum != first.Checksum {
+			return nil, nil, fmt.Errorf("share %d is not from the same batch as share %d", f.Index, first.Index)
+		}
+		shares = append(shares, Share{X: byte(f.Index), Y: f.Share})
+	}
+	secret, err := Combine(shares)
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(secret)
+	if hex.EncodeToString(sum[:]) != first.Checksum {
+		return nil, nil, errors.New("checksum mismatch,shares are corrupted")
+	}
+	return first, secret, nil
+}
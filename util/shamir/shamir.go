@@ -0,0 +1,129 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+/*
+Shamir门限秘密共享
+	在GF(2^8)上逐字节拆分，多项式为x^8+x^4+x^3+x+1(与AES相同)
+	份额的x坐标为1..N，任意threshold个份额可恢复，少于threshold个份额得不到秘密的任何信息
+*/
+
+const MaxShares = 255
+
+var (
+	expTable [255]byte
+	logTable [256]byte
+)
+
+func init() {
+	// 3是GF(2^8)的生成元
+	var x byte = 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		logTable[x] = byte(i)
+		x ^= xtime(x)
+	}
+}
+
+func xtime(a byte) byte {
+	if a&0x80 != 0 {
+		return a<<1 ^ 0x1b
+	}
+	return a << 1
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+int(logTable[b]))%255]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])-int(logTable[b])+255)%255]
+}
+
+type Share struct {
+	X byte
+	Y []byte
+}
+
+// Split 将secret拆分为total个份额，任意threshold个可恢复
+func Split(secret []byte, total, threshold int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("secret is empty")
+	}
+	if threshold < 2 || threshold > total || total > MaxShares {
+		return nil, fmt.Errorf("invalid threshold %d of %d shares", threshold, total)
+	}
+	shares := make([]Share, total)
+	for i := range shares {
+		shares[i] = Share{X: byte(i + 1), Y: make([]byte, len(secret))}
+	}
+	coeffs := make([]byte, threshold)
+	for j, b := range secret {
+		// 常数项为秘密，其余系数随机
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		coeffs[0] = b
+		for i := range shares {
+			shares[i].Y[j] = eval(coeffs, shares[i].X)
+		}
+	}
+	for i := range coeffs {
+		coeffs[i] = 0
+	}
+	return shares, nil
+}
+
+// eval 霍纳法计算多项式在x处的值
+func eval(coeffs []byte, x byte) byte {
+	var y byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coeffs[i]
+	}
+	return y
+}
+
+// Combine 拉格朗日插值求x=0处的值；份额不足threshold时得到的是错误的结果，需要调用方校验
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least 2 shares are required")
+	}
+	size := len(shares[0].Y)
+	seen := make(map[byte]bool, len(shares))
+	for _, s := range shares {
+		if s.X == 0 {
+			return nil, errors.New("invalid share index 0")
+		}
+		if seen[s.X] {
+			return nil, fmt.Errorf("duplicate share index %d", s.X)
+		}
+		seen[s.X] = true
+		if len(s.Y) != size {
+			return nil, errors.New("shares have different length")
+		}
+	}
+	secret := make([]byte, size)
+	for i, si := range shares {
+		// l_i(0) = prod(x_j / (x_j - x_i))，GF(2^8)中减法即异或
+		var basis byte = 1
+		for j, sj := range shares {
+			if i != j {
+				basis = mul(basis, div(sj.X, sj.X^si.X))
+			}
+		}
+		for k := range secret {
+			secret[k] ^= mul(si.Y[k], basis)
+		}
+	}
+	return secret, nil
+}
@@ -0,0 +1,80 @@
+package shamir
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("addr1,priv1\naddr2,priv2\n")
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 任意3个份额都能恢复
+	for _, idx := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var subset []Share
+		for _, i := range idx {
+			subset = append(subset, shares[i])
+		}
+		got, err := Combine(subset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Fatalf("combine %v error: %q", idx, got)
+		}
+	}
+	got, _ := Combine(shares[:2])
+	if bytes.Equal(got, secret) {
+		t.Fatal("2 shares should not recover the secret")
+	}
+	if _, err = Split(secret, 3, 4); err == nil {
+		t.Fatal("threshold greater than total should fail")
+	}
+}
+
+func TestShareFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shamir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secret := []byte("addr1,priv1\n")
+	meta := ShareFile{CoinType: "dot", MchId: "mch1", BatchNo: "1", Threshold: 2, Total: 3, Addresses: 1}
+	dirs := []string{filepath.Join(dir, "1"), filepath.Join(dir, "2"), filepath.Join(dir, "3")}
+	if _, err = WriteShareFiles(dirs[:2], meta, secret); err == nil {
+		t.Fatal("share dirs less than total should fail")
+	}
+	paths, err := WriteShareFiles(dirs, meta, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("share files count error: %d", len(paths))
+	}
+	if _, err = WriteShareFiles(dirs, meta, secret); err == nil {
+		t.Fatal("overwrite share files should fail")
+	}
+	var files []*ShareFile
+	for _, path := range []string{paths[2], paths[0]} {
+		f, err := ReadShareFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	if _, got, err := CombineShareFiles(files); err != nil || !bytes.Equal(got, secret) {
+		t.Fatalf("combine share files error: %q %v", got, err)
+	}
+	if _, _, err = CombineShareFiles(files[:1]); err == nil {
+		t.Fatal("combine less than threshold should fail")
+	}
+	files[1].Share[0] ^= 1
+	if _, _, err = CombineShareFiles(files); err == nil {
+		t.Fatal("corrupted share should fail checksum")
+	}
+}
@@ -0,0 +1,206 @@
+package sol
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/model"
+	"github.com/group-coldwallet/trxsign/util"
+	"github.com/shopspring/decimal"
+	"math/big"
+	"time"
+)
+
+const (
+	CommitmentConfirmed = "confirmed"
+	CommitmentFinalized = "finalized"
+)
+
+type Client struct {
+	rpc *util.RpcClient
+}
+
+func NewClient(url, user, password string) *Client {
+	return &Client{rpc: util.New(url, user, password)}
+}
+
+func (c *Client) GetLatestBlockHash() (string, error) {
+	data, err := c.rpc.SendRequest("getLatestBlockhash", []interface{}{
+		map[string]string{"commitment": CommitmentFinalized},
+	})
+	if err != nil {
+		return "", fmt.Errorf("get latest block hash error: %v", err)
+	}
+	return model.DecodeSolRecentBlockHash(data)
+}
+
+func (c *Client) GetBalance(address string) (uint64, error) {
+	data, err := c.rpc.SendRequest("getBalance", []interface{}{
+		address,
+		map[string]string{"commitment": CommitmentConfirmed},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get balance error: %v", err)
+	}
+	var balance model.SolBalance
+	if err = json.Unmarshal(data, &balance); err != nil {
+		return 0, fmt.Errorf("json unmarshal balance error: %v", err)
+	}
+	return balance.Value, nil
+}
+
+// GetAccountData 获取账户原始数据，账户不存在时返回nil
+func (c *Client) GetAccountData(address string) ([]byte, error) {
+	data, err := c.rpc.SendRequest("getAccountInfo", []interface{}{
+		address,
+		map[string]string{"encoding": "base64", "commitment": CommitmentConfirmed},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get account info error: %v", err)
+	}
+	var info model.SolAccountInfo
+	if err = json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("json unmarshal account info error: %v", err)
+	}
+	if info.Value == nil {
+		return nil, nil
+	}
+	if len(info.Value.Data) != 2 || info.Value.Data[1] != "base64" {
+		return nil, fmt.Errorf("unexpected account data encoding: %v", info.Value.Data)
+	}
+	return base64.StdEncoding.DecodeString(info.Value.Data[0])
+}
+
+func (c *Client) GetNonceAccount(address string) (*NonceAccount, error) {
+	data, err := c.GetAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("nonce account %s is not exist", address)
+	}
+	return DecodeNonceAccount(data)
+}
+
+func (c *Client) SendTransaction(tx *Transaction) (string, error) {
+	data, err := c.rpc.SendRequest("sendTransaction", []interface{}{
+		tx.ToBase64(),
+		map[string]string{"encoding": "base64", "preflightCommitment": CommitmentConfirmed},
+	})
+	if err != nil {
+		return "", fmt.Errorf("send transaction error: %v", err)
+	}
+	return string(data), nil
+}
+
+func (c *Client) GetSignatureStatus(txid string) (*model.SolSignatureStatus, error) {
+	data, err := c.rpc.SendRequest("getSignatureStatuses", []interface{}{
+		[]string{txid},
+		map[string]bool{"searchTransactionHistory": true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get signature status error: %v", err)
+	}
+	var statuses model.SolSignatureStatuses
+	if err = json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("json unmarshal signature status error: %v", err)
+	}
+	if len(statuses.Value) == 0 {
+		return nil, nil
+	}
+	return statuses.Value[0], nil
+}
+
+/*
+WaitForConfirmation 轮询交易状态直到confirmed/finalized或超时
+*/
+func (c *Client) WaitForConfirmation(txid string, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := c.GetSignatureStatus(txid)
+		if err != nil {
+			return err
+		}
+		if status != nil {
+			if status.Err != nil {
+				return fmt.Errorf("transaction %s failed: %v", txid, status.Err)
+			}
+			if status.ConfirmationStatus == CommitmentConfirmed || status.ConfirmationStatus == CommitmentFinalized {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return errors.New("wait for transaction confirmation timeout")
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (c *Client) GetMintDecimals(mint string) (uint8, error) {
+	data, err := c.GetAccountData(mint)
+	if err != nil {
+		return 0, err
+	}
+	if data == nil {
+		return 0, fmt.Errorf("mint account %s is not exist", mint)
+	}
+	return DecodeMintDecimals(data)
+}
+
+// GetTokenAccountBalance 获取token账户余额，账户不存在时返回错误
+func (c *Client) GetTokenAccountBalance(tokenAccount string) (*model.SolTokenAmount, error) {
+	data, err := c.rpc.SendRequest("getTokenAccountBalance", []interface{}{
+		tokenAccount,
+		map[string]string{"commitment": CommitmentConfirmed},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get token account balance error: %v", err)
+	}
+	var balance model.SolTokenAccountBalance
+	if err = json.Unmarshal(data, &balance); err != nil {
+		return nil, fmt.Errorf("json unmarshal token account balance error: %v", err)
+	}
+	return &balance.Value, nil
+}
+
+/*
+GetTokenBalances 获取地址下所有token账户余额，按mint汇总
+	mint不为空时只查询该mint
+*/
+func (c *Client) GetTokenBalances(owner, mint string) (map[string]*model.SolTokenAmount, error) {
+	filter := map[string]string{"programId": TokenProgramID.String()}
+	if mint != "" {
+		filter = map[string]string{"mint": mint}
+	}
+	data, err := c.rpc.SendRequest("getTokenAccountsByOwner", []interface{}{
+		owner,
+		filter,
+		map[string]string{"encoding": "jsonParsed", "commitment": CommitmentConfirmed},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get token accounts by owner error: %v", err)
+	}
+	var accounts model.SolTokenAccounts
+	if err = json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("json unmarshal token accounts error: %v", err)
+	}
+	balances := make(map[string]*model.SolTokenAmount)
+	for _, acc := range accounts.Value {
+		info := acc.Account.Data.Parsed.Info
+		amount, ok := new(big.Int).SetString(info.TokenAmount.Amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("token account %s amount error: %s", acc.Pubkey, info.TokenAmount.Amount)
+		}
+		if b, ok := balances[info.Mint]; ok {
+			total, _ := new(big.Int).SetString(b.Amount, 10)
+			amount.Add(amount, total)
+		}
+		balances[info.Mint] = &model.SolTokenAmount{
+			Amount:         amount.String(),
+			Decimals:       info.TokenAmount.Decimals,
+			UiAmountString: decimal.NewFromBigInt(amount, -int32(info.TokenAmount.Decimals)).String(),
+		}
+	}
+	return balances, nil
+}
@@ -0,0 +1,67 @@
+package sol
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	systemInstructionTransfer            uint32 = 2
+	systemInstructionAdvanceNonceAccount uint32 = 4
+	nonceAccountLength                          = 80
+	nonceAccountStateInitialized         uint32 = 1
+	nonceAccountAuthorityOffset                 = 8
+	nonceAccountBlockHashOffset                 = 40
+)
+
+// NewTransferInstruction SystemProgram转账指令
+func NewTransferInstruction(from, to PublicKey, lamports uint64) Instruction {
+	data := make([]byte, 12)
+	binary.LittleEndian.PutUint32(data[0:4], systemInstructionTransfer)
+	binary.LittleEndian.PutUint64(data[4:12], lamports)
+	return Instruction{
+		ProgramID: SystemProgramID,
+		Accounts: []AccountMeta{
+			{PublicKey: from, IsSigner: true, IsWritable: true},
+			{PublicKey: to, IsWritable: true},
+		},
+		Data: data,
+	}
+}
+
+/*
+NewAdvanceNonceAccountInstruction durable nonce推进指令
+	使用nonce账户时该指令必须是交易的第一条指令
+*/
+func NewAdvanceNonceAccountInstruction(nonceAccount, nonceAuthority PublicKey) Instruction {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, systemInstructionAdvanceNonceAccount)
+	return Instruction{
+		ProgramID: SystemProgramID,
+		Accounts: []AccountMeta{
+			{PublicKey: nonceAccount, IsWritable: true},
+			{PublicKey: SysvarRecentBlockHashesID},
+			{PublicKey: nonceAuthority, IsSigner: true},
+		},
+		Data: data,
+	}
+}
+
+type NonceAccount struct {
+	Authority PublicKey
+	Nonce     PublicKey
+}
+
+// DecodeNonceAccount 解析nonce账户数据：version(u32) state(u32) authority(32) blockhash(32) fee_calculator(u64)
+func DecodeNonceAccount(data []byte) (*NonceAccount, error) {
+	if len(data) < nonceAccountLength {
+		return nil, errors.New("nonce account data length is too short")
+	}
+	if binary.LittleEndian.Uint32(data[4:8]) != nonceAccountStateInitialized {
+		return nil, errors.New("nonce account is not initialized")
+	}
+	na := new(NonceAccount)
+	copy(na.Authority[:], data[nonceAccountAuthorityOffset:nonceAccountAuthorityOffset+PublicKeyLength])
+	copy(na.Nonce[:], data[nonceAccountBlockHashOffset:nonceAccountBlockHashOffset+PublicKeyLength])
+	return na, nil
+}
@@ -0,0 +1,117 @@
+package sol
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"filippo.io/edwards25519"
+)
+
+const (
+	tokenInstructionTransferChecked      byte = 12
+	associatedTokenInstructionIdempotent byte = 1
+	mintAccountLength                         = 82
+	mintDecimalsOffset                        = 44
+	maxSeedLength                             = 32
+	pdaMarker                                 = "ProgramDerivedAddress"
+)
+
+var (
+	TokenProgramID           = MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	AssociatedTokenProgramID = MustPublicKeyFromBase58("ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL")
+	ErrMaxSeedLengthExceeded = errors.New("max seed length exceeded")
+	ErrInvalidSeeds          = errors.New("provided seeds do not result in a valid address")
+)
+
+// CreateProgramAddress 计算程序派生地址，结果落在ed25519曲线上时返回错误
+func CreateProgramAddress(seeds [][]byte, programID PublicKey) (PublicKey, error) {
+	var pk PublicKey
+	h := sha256.New()
+	for _, seed := range seeds {
+		if len(seed) > maxSeedLength {
+			return pk, ErrMaxSeedLengthExceeded
+		}
+		h.Write(seed)
+	}
+	h.Write(programID[:])
+	h.Write([]byte(pdaMarker))
+	copy(pk[:], h.Sum(nil))
+	if IsOnCurve(pk) {
+		return pk, ErrInvalidSeeds
+	}
+	return pk, nil
+}
+
+// FindProgramAddress 从bump=255开始向下查找第一个有效的程序派生地址
+func FindProgramAddress(seeds [][]byte, programID PublicKey) (PublicKey, uint8, error) {
+	for bump := 255; bump >= 0; bump-- {
+		pk, err := CreateProgramAddress(append(seeds[:len(seeds):len(seeds)], []byte{byte(bump)}), programID)
+		if err == nil {
+			return pk, uint8(bump), nil
+		}
+		if err != ErrInvalidSeeds {
+			return pk, 0, err
+		}
+	}
+	return PublicKey{}, 0, errors.New("unable to find a viable program address bump seed")
+}
+
+func IsOnCurve(pk PublicKey) bool {
+	_, err := new(edwards25519.Point).SetBytes(pk[:])
+	return err == nil
+}
+
+// FindAssociatedTokenAddress 计算钱包地址对应mint的关联token账户(ATA)
+func FindAssociatedTokenAddress(wallet, mint PublicKey) (PublicKey, error) {
+	pk, _, err := FindProgramAddress([][]byte{wallet[:], TokenProgramID[:], mint[:]}, AssociatedTokenProgramID)
+	return pk, err
+}
+
+/*
+NewCreateAssociatedTokenAccountInstruction 创建关联token账户
+	使用CreateIdempotent指令，账户已存在时不会导致交易失败
+*/
+func NewCreateAssociatedTokenAccountInstruction(payer, wallet, mint PublicKey) (Instruction, error) {
+	ata, err := FindAssociatedTokenAddress(wallet, mint)
+	if err != nil {
+		return Instruction{}, err
+	}
+	return Instruction{
+		ProgramID: AssociatedTokenProgramID,
+		Accounts: []AccountMeta{
+			{PublicKey: payer, IsSigner: true, IsWritable: true},
+			{PublicKey: ata, IsWritable: true},
+			{PublicKey: wallet},
+			{PublicKey: mint},
+			{PublicKey: SystemProgramID},
+			{PublicKey: TokenProgramID},
+		},
+		Data: []byte{associatedTokenInstructionIdempotent},
+	}, nil
+}
+
+// NewTransferCheckedInstruction token转账指令，链上会校验mint和精度
+func NewTransferCheckedInstruction(source, mint, destination, owner PublicKey, amount uint64, decimals uint8) Instruction {
+	data := make([]byte, 10)
+	data[0] = tokenInstructionTransferChecked
+	binary.LittleEndian.PutUint64(data[1:9], amount)
+	data[9] = decimals
+	return Instruction{
+		ProgramID: TokenProgramID,
+		Accounts: []AccountMeta{
+			{PublicKey: source, IsWritable: true},
+			{PublicKey: mint},
+			{PublicKey: destination, IsWritable: true},
+			{PublicKey: owner, IsSigner: true},
+		},
+		Data: data,
+	}
+}
+
+// DecodeMintDecimals 从mint账户数据中读取精度
+func DecodeMintDecimals(data []byte) (uint8, error) {
+	if len(data) < mintAccountLength {
+		return 0, errors.New("mint account data length is too short")
+	}
+	return data[mintDecimalsOffset], nil
+}
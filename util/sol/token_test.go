@@ -0,0 +1,68 @@
+package sol
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"testing"
+)
+
+func TestFindAssociatedTokenAddress(t *testing.T) {
+	wallet := PublicKeyFromPrivateKey(ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, 32)))
+	mint := MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	if !IsOnCurve(wallet) {
+		t.Fatal("wallet public key must be on curve")
+	}
+	ata, err := FindAssociatedTokenAddress(wallet, mint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsOnCurve(ata) {
+		t.Fatalf("associated token address %s must be off curve", ata)
+	}
+	again, _ := FindAssociatedTokenAddress(wallet, mint)
+	if again != ata {
+		t.Fatal("associated token address is not deterministic")
+	}
+	if _, err = CreateProgramAddress([][]byte{bytes.Repeat([]byte{1}, 33)}, TokenProgramID); err != ErrMaxSeedLengthExceeded {
+		t.Fatalf("expected max seed length error,got %v", err)
+	}
+}
+
+func TestTokenTransferMessage(t *testing.T) {
+	payer := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, 32))
+	payerPk := PublicKeyFromPrivateKey(payer)
+	to := MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	mint := MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	source, _ := FindAssociatedTokenAddress(payerPk, mint)
+	dest, _ := FindAssociatedTokenAddress(to, mint)
+
+	create, err := NewCreateAssociatedTokenAccountInstruction(payerPk, to, mint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if create.Accounts[1].PublicKey != dest {
+		t.Fatal("create ata instruction uses wrong account")
+	}
+	transfer := NewTransferCheckedInstruction(source, mint, dest, payerPk, 1500000, 6)
+	if transfer.Data[0] != tokenInstructionTransferChecked ||
+		binary.LittleEndian.Uint64(transfer.Data[1:9]) != 1500000 || transfer.Data[9] != 6 {
+		t.Fatalf("unexpected transfer checked data %x", transfer.Data)
+	}
+	msg, err := NewMessage(payerPk, []Instruction{create, transfer}, "EETubP5AKHgjPAhzPAFcb8BAY1hMH639CWCFTqi3hq1k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.NumRequiredSignatures != 1 || len(msg.Signers()) != 1 || msg.Signers()[0] != payerPk {
+		t.Fatalf("unexpected signers %+v", msg.Header)
+	}
+}
+
+func TestDecodeMintDecimals(t *testing.T) {
+	data := make([]byte, mintAccountLength)
+	data[mintDecimalsOffset] = 6
+	decimals, err := DecodeMintDecimals(data)
+	if err != nil || decimals != 6 {
+		t.Fatalf("decimals=%d,err=%v", decimals, err)
+	}
+}
@@ -0,0 +1,206 @@
+package sol
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcutil/base58"
+)
+
+type AccountMeta struct {
+	PublicKey  PublicKey
+	IsSigner   bool
+	IsWritable bool
+}
+
+type Instruction struct {
+	ProgramID PublicKey
+	Accounts  []AccountMeta
+	Data      []byte
+}
+
+type MessageHeader struct {
+	NumRequiredSignatures       uint8
+	NumReadonlySignedAccounts   uint8
+	NumReadonlyUnsignedAccounts uint8
+}
+
+type CompiledInstruction struct {
+	ProgramIDIndex uint8
+	Accounts       []uint8
+	Data           []byte
+}
+
+// Message legacy格式的交易消息
+type Message struct {
+	Header          MessageHeader
+	AccountKeys     []PublicKey
+	RecentBlockHash PublicKey
+	Instructions    []CompiledInstruction
+}
+
+type Transaction struct {
+	Signatures [][SignatureLength]byte
+	Message    Message
+}
+
+/*
+NewMessage 编译指令为交易消息
+	账户排序规则：可写签名账户(手续费账户永远第一个) -> 只读签名账户 -> 可写非签名账户 -> 只读非签名账户
+	recentBlockHash: 最近区块hash，使用durable nonce时传入nonce账户中保存的nonce值
+*/
+func NewMessage(feePayer PublicKey, instructions []Instruction, recentBlockHash string) (*Message, error) {
+	if len(instructions) == 0 {
+		return nil, errors.New("instructions is empty")
+	}
+	bh := base58.Decode(recentBlockHash)
+	if len(bh) != PublicKeyLength {
+		return nil, fmt.Errorf("invalid recent block hash: %s", recentBlockHash)
+	}
+
+	metas := []AccountMeta{{PublicKey: feePayer, IsSigner: true, IsWritable: true}}
+	index := map[PublicKey]int{feePayer: 0}
+	addMeta := func(m AccountMeta) {
+		if i, ok := index[m.PublicKey]; ok {
+			metas[i].IsSigner = metas[i].IsSigner || m.IsSigner
+			metas[i].IsWritable = metas[i].IsWritable || m.IsWritable
+			return
+		}
+		index[m.PublicKey] = len(metas)
+		metas = append(metas, m)
+	}
+	for _, ins := range instructions {
+		for _, acc := range ins.Accounts {
+			addMeta(acc)
+		}
+		addMeta(AccountMeta{PublicKey: ins.ProgramID})
+	}
+
+	var groups [4][]AccountMeta
+	for _, m := range metas {
+		switch {
+		case m.IsSigner && m.IsWritable:
+			groups[0] = append(groups[0], m)
+		case m.IsSigner:
+			groups[1] = append(groups[1], m)
+		case m.IsWritable:
+			groups[2] = append(groups[2], m)
+		default:
+			groups[3] = append(groups[3], m)
+		}
+	}
+	msg := new(Message)
+	msg.Header.NumRequiredSignatures = uint8(len(groups[0]) + len(groups[1]))
+	msg.Header.NumReadonlySignedAccounts = uint8(len(groups[1]))
+	msg.Header.NumReadonlyUnsignedAccounts = uint8(len(groups[3]))
+	for _, g := range groups {
+		for _, m := range g {
+			msg.AccountKeys = append(msg.AccountKeys, m.PublicKey)
+		}
+	}
+	copy(msg.RecentBlockHash[:], bh)
+
+	keyIndex := make(map[PublicKey]uint8, len(msg.AccountKeys))
+	for i, k := range msg.AccountKeys {
+		keyIndex[k] = uint8(i)
+	}
+	for _, ins := range instructions {
+		ci := CompiledInstruction{
+			ProgramIDIndex: keyIndex[ins.ProgramID],
+			Data:           ins.Data,
+		}
+		for _, acc := range ins.Accounts {
+			ci.Accounts = append(ci.Accounts, keyIndex[acc.PublicKey])
+		}
+		msg.Instructions = append(msg.Instructions, ci)
+	}
+	return msg, nil
+}
+
+func (m *Message) Serialize() []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(m.Header.NumRequiredSignatures)
+	buf.WriteByte(m.Header.NumReadonlySignedAccounts)
+	buf.WriteByte(m.Header.NumReadonlyUnsignedAccounts)
+	buf.Write(EncodeCompactU16(len(m.AccountKeys)))
+	for _, k := range m.AccountKeys {
+		buf.Write(k[:])
+	}
+	buf.Write(m.RecentBlockHash[:])
+	buf.Write(EncodeCompactU16(len(m.Instructions)))
+	for _, ins := range m.Instructions {
+		buf.WriteByte(ins.ProgramIDIndex)
+		buf.Write(EncodeCompactU16(len(ins.Accounts)))
+		buf.Write(ins.Accounts)
+		buf.Write(EncodeCompactU16(len(ins.Data)))
+		buf.Write(ins.Data)
+	}
+	return buf.Bytes()
+}
+
+// Signers 返回需要签名的账户，顺序与签名顺序一致
+func (m *Message) Signers() []PublicKey {
+	return m.AccountKeys[:m.Header.NumRequiredSignatures]
+}
+
+/*
+NewTransaction 使用私钥对消息签名
+	keys: 所有签名账户的私钥，缺少任意一个都会返回错误
+*/
+func NewTransaction(msg *Message, keys ...ed25519.PrivateKey) (*Transaction, error) {
+	keyMap := make(map[PublicKey]ed25519.PrivateKey, len(keys))
+	for _, k := range keys {
+		keyMap[PublicKeyFromPrivateKey(k)] = k
+	}
+	data := msg.Serialize()
+	tx := &Transaction{Message: *msg}
+	for _, signer := range msg.Signers() {
+		key, ok := keyMap[signer]
+		if !ok {
+			return nil, fmt.Errorf("missing private key for signer %s", signer.String())
+		}
+		var sig [SignatureLength]byte
+		copy(sig[:], ed25519.Sign(key, data))
+		tx.Signatures = append(tx.Signatures, sig)
+	}
+	return tx, nil
+}
+
+func (tx *Transaction) Serialize() []byte {
+	buf := new(bytes.Buffer)
+	buf.Write(EncodeCompactU16(len(tx.Signatures)))
+	for _, sig := range tx.Signatures {
+		buf.Write(sig[:])
+	}
+	buf.Write(tx.Message.Serialize())
+	return buf.Bytes()
+}
+
+func (tx *Transaction) ToBase64() string {
+	return base64.StdEncoding.EncodeToString(tx.Serialize())
+}
+
+// TxId 交易id为第一个签名的base58编码
+func (tx *Transaction) TxId() string {
+	if len(tx.Signatures) == 0 {
+		return ""
+	}
+	return base58.Encode(tx.Signatures[0][:])
+}
+
+// EncodeCompactU16 solana short_vec长度编码
+func EncodeCompactU16(n int) []byte {
+	var out []byte
+	rem := uint16(n)
+	for {
+		elem := byte(rem & 0x7f)
+		rem >>= 7
+		if rem == 0 {
+			out = append(out, elem)
+			return out
+		}
+		out = append(out, elem|0x80)
+	}
+}
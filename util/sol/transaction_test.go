@@ -0,0 +1,80 @@
+package sol
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"testing"
+)
+
+func TestEncodeCompactU16(t *testing.T) {
+	cases := map[int][]byte{
+		0:      {0x00},
+		0x7f:   {0x7f},
+		0x80:   {0x80, 0x01},
+		0x3fff: {0xff, 0x7f},
+		0x4000: {0x80, 0x80, 0x01},
+	}
+	for n, want := range cases {
+		if got := EncodeCompactU16(n); !bytes.Equal(got, want) {
+			t.Fatalf("EncodeCompactU16(%d)=%x,want %x", n, got, want)
+		}
+	}
+}
+
+func TestNonceTransferMessage(t *testing.T) {
+	payer := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, 32))
+	payerPk := PublicKeyFromPrivateKey(payer)
+	to := MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	nonce := MustPublicKeyFromBase58("4Nd1mBQtrMJVYVfKf2PJy9NZUZdTAsp7D4xWLs4gDB4T")
+
+	msg, err := NewMessage(payerPk, []Instruction{
+		NewAdvanceNonceAccountInstruction(nonce, payerPk),
+		NewTransferInstruction(payerPk, to, 1000),
+	}, "EETubP5AKHgjPAhzPAFcb8BAY1hMH639CWCFTqi3hq1k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header != (MessageHeader{1, 0, 2}) {
+		t.Fatalf("unexpected header %+v", msg.Header)
+	}
+	wantKeys := []PublicKey{payerPk, nonce, to, SysvarRecentBlockHashesID, SystemProgramID}
+	for i, k := range wantKeys {
+		if msg.AccountKeys[i] != k {
+			t.Fatalf("account key %d=%s,want %s", i, msg.AccountKeys[i], k)
+		}
+	}
+	if !bytes.Equal(msg.Instructions[0].Accounts, []uint8{1, 3, 0}) || msg.Instructions[0].ProgramIDIndex != 4 {
+		t.Fatalf("unexpected advance nonce instruction %+v", msg.Instructions[0])
+	}
+	if binary.LittleEndian.Uint64(msg.Instructions[1].Data[4:]) != 1000 {
+		t.Fatalf("unexpected transfer data %x", msg.Instructions[1].Data)
+	}
+
+	tx, err := NewTransaction(msg, payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(payer.Public().(ed25519.PublicKey), msg.Serialize(), tx.Signatures[0][:]) {
+		t.Fatal("signature verify failed")
+	}
+	if _, err = NewTransaction(msg); err == nil {
+		t.Fatal("expected missing signer error")
+	}
+}
+
+func TestDecodeNonceAccount(t *testing.T) {
+	authority := MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	blockHash := MustPublicKeyFromBase58("EETubP5AKHgjPAhzPAFcb8BAY1hMH639CWCFTqi3hq1k")
+	data := make([]byte, nonceAccountLength)
+	binary.LittleEndian.PutUint32(data[4:8], nonceAccountStateInitialized)
+	copy(data[8:40], authority[:])
+	copy(data[40:72], blockHash[:])
+	na, err := DecodeNonceAccount(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if na.Authority != authority || na.Nonce != blockHash {
+		t.Fatalf("unexpected nonce account %+v", na)
+	}
+}
@@ -0,0 +1,75 @@
+package sol
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+)
+
+const (
+	PublicKeyLength = 32
+	SignatureLength = 64
+)
+
+var (
+	SystemProgramID            = MustPublicKeyFromBase58("11111111111111111111111111111111")
+	SysvarRecentBlockHashesID  = MustPublicKeyFromBase58("SysvarRecentB1ockHashes11111111111111111111")
+	SysvarRentID               = MustPublicKeyFromBase58("SysvarRent111111111111111111111111111111111")
+	ErrInvalidPublicKeyLength  = errors.New("invalid public key length")
+	ErrInvalidPrivateKeyLength = errors.New("invalid private key length")
+)
+
+// PublicKey solana账户地址，即ed25519公钥
+type PublicKey [PublicKeyLength]byte
+
+func PublicKeyFromBase58(address string) (PublicKey, error) {
+	var pk PublicKey
+	data := base58.Decode(address)
+	if len(data) != PublicKeyLength {
+		return pk, fmt.Errorf("%w: address=%s,length=%d", ErrInvalidPublicKeyLength, address, len(data))
+	}
+	copy(pk[:], data)
+	return pk, nil
+}
+
+func MustPublicKeyFromBase58(address string) PublicKey {
+	pk, err := PublicKeyFromBase58(address)
+	if err != nil {
+		panic(err)
+	}
+	return pk
+}
+
+func (pk PublicKey) String() string {
+	return base58.Encode(pk[:])
+}
+
+func (pk PublicKey) Bytes() []byte {
+	return pk[:]
+}
+
+func (pk PublicKey) IsZero() bool {
+	return pk == PublicKey{}
+}
+
+// PrivateKeyFromBase58 解析base58编码的64字节私钥（与solana-keygen/钱包导出格式一致）
+func PrivateKeyFromBase58(key string) (ed25519.PrivateKey, error) {
+	data := base58.Decode(key)
+	defer secmem.Wipe(data)
+	if len(data) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidPrivateKeyLength
+	}
+	priv := ed25519.NewKeyFromSeed(data[:ed25519.SeedSize])
+	if !priv.Equal(ed25519.PrivateKey(data)) {
+		return nil, errors.New("private key does not match its public key")
+	}
+	return priv, nil
+}
+
+func PublicKeyFromPrivateKey(priv ed25519.PrivateKey) PublicKey {
+	var pk PublicKey
+	copy(pk[:], priv.Public().(ed25519.PublicKey))
+	return pk
+}
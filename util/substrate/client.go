@@ -0,0 +1,113 @@
+package substrate
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/group-coldwallet/trxsign/util"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type Client struct {
+	rpc *util.RpcClient
+}
+
+type RuntimeVersion struct {
+	SpecVersion        uint32 `json:"specVersion"`
+	TransactionVersion uint32 `json:"transactionVersion"`
+}
+
+type header struct {
+	Number string `json:"number"`
+}
+
+func NewClient(url, user, password string) *Client {
+	return &Client{rpc: util.New(url, user, password)}
+}
+
+// GetNonce 获取账户下一个可用nonce（包含交易池中的交易）
+func (c *Client) GetNonce(address string) (uint64, error) {
+	data, err := c.rpc.SendRequest("system_accountNextIndex", []interface{}{address})
+	if err != nil {
+		return 0, fmt.Errorf("get account next index error: %v", err)
+	}
+	return strconv.ParseUint(string(data), 10, 64)
+}
+
+func (c *Client) GetRuntimeVersion() (*RuntimeVersion, error) {
+	data, err := c.rpc.SendRequest("state_getRuntimeVersion", []interface{}{})
+	if err != nil {
+		return nil, fmt.Errorf("get runtime version error: %v", err)
+	}
+	var rv RuntimeVersion
+	if err = json.Unmarshal(data, &rv); err != nil {
+		return nil, fmt.Errorf("json unmarshal runtime version error: %v", err)
+	}
+	return &rv, nil
+}
+
+func (c *Client) GetGenesisHash() (string, error) {
+	data, err := c.rpc.SendRequest("chain_getBlockHash", []interface{}{0})
+	if err != nil {
+		return "", fmt.Errorf("get genesis hash error: %v", err)
+	}
+	return string(data), nil
+}
+
+// GetFinalizedHead 获取最新确认区块的hash和高度
+func (c *Client) GetFinalizedHead() (string, uint64, error) {
+	data, err := c.rpc.SendRequest("chain_getFinalizedHead", []interface{}{})
+	if err != nil {
+		return "", 0, fmt.Errorf("get finalized head error: %v", err)
+	}
+	hash := string(data)
+	data, err = c.rpc.SendRequest("chain_getHeader", []interface{}{hash})
+	if err != nil {
+		return "", 0, fmt.Errorf("get header error: %v", err)
+	}
+	var h header
+	if err = json.Unmarshal(data, &h); err != nil {
+		return "", 0, fmt.Errorf("json unmarshal header error: %v", err)
+	}
+	number, err := strconv.ParseUint(strings.TrimPrefix(h.Number, "0x"), 16, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("parse block number %s error: %v", h.Number, err)
+	}
+	return hash, number, nil
+}
+
+/*
+GetFreeBalance 读取System.Account存储中的free余额
+	refCounts: AccountInfo中nonce之后u32引用计数的个数（consumers/providers/sufficients，旧版链可能不同）
+*/
+func (c *Client) GetFreeBalance(accountId []byte, refCounts int) (*big.Int, error) {
+	key := append(Twox128([]byte("System")), Twox128([]byte("Account"))...)
+	key = append(key, Blake2b128Concat(accountId)...)
+	data, err := c.rpc.SendRequest("state_getStorage", []interface{}{"0x" + hex.EncodeToString(key)})
+	if err != nil {
+		return nil, fmt.Errorf("get account storage error: %v", err)
+	}
+	if data == nil {
+		// 账户不存在
+		return new(big.Int), nil
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(string(data), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("decode account storage error: %v", err)
+	}
+	offset := 4 + 4*refCounts
+	if len(raw) < offset+16 {
+		return nil, fmt.Errorf("account storage length %d is too short", len(raw))
+	}
+	return DecodeU128(raw[offset:])
+}
+
+func (c *Client) SubmitExtrinsic(extrinsic string) (string, error) {
+	data, err := c.rpc.SendRequest("author_submitExtrinsic", []interface{}{extrinsic})
+	if err != nil {
+		return "", fmt.Errorf("submit extrinsic error: %v", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,64 @@
+package substrate
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	minEraPeriod = 4
+	// 超过4096时phase会被量化，出生区块不再等于当前区块，签名时需要额外查询出生区块hash，这里直接限制上限
+	MaxEraPeriod = 4096
+)
+
+// Era 交易有效期，Period为0时为永久有效(immortal)
+type Era struct {
+	Period uint64
+	Phase  uint64
+}
+
+// NewMortalEra 以current区块为出生区块，构造有效期为period个区块的mortal era
+func NewMortalEra(period, current uint64) Era {
+	if period == 0 {
+		return Era{}
+	}
+	if period < minEraPeriod {
+		period = minEraPeriod
+	}
+	if period > MaxEraPeriod {
+		period = MaxEraPeriod
+	}
+	// 向上取2的幂
+	if period&(period-1) != 0 {
+		period = 1 << (64 - bits.LeadingZeros64(period))
+		if period > MaxEraPeriod {
+			period = MaxEraPeriod
+		}
+	}
+	return Era{Period: period, Phase: current % period}
+}
+
+func (e Era) IsImmortal() bool {
+	return e.Period == 0
+}
+
+func (e Era) Encode() []byte {
+	if e.IsImmortal() {
+		return []byte{0x00}
+	}
+	quantizeFactor := e.Period >> 12
+	if quantizeFactor < 1 {
+		quantizeFactor = 1
+	}
+	low := uint64(bits.TrailingZeros64(e.Period)) - 1
+	if low < 1 {
+		low = 1
+	}
+	if low > 15 {
+		low = 15
+	}
+	encoded := low | (e.Phase/quantizeFactor)<<4
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, uint16(encoded))
+	return b
+}
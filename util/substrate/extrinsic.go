@@ -0,0 +1,193 @@
+package substrate
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	extrinsicVersionSigned byte = 0x84
+	maxPayloadLength            = 256
+
+	AddressTypeMultiAddress = "multiAddress" // MultiAddress::Id，目前绝大部分链
+	AddressTypeAccountId    = "accountId"    // 直接使用AccountId32
+	AddressTypeLookup       = "lookup"       // 旧版GenericAddress，0xff + AccountId32
+)
+
+// Chain 链相关的编码参数，不同的substrate链只需配置这些参数即可共用签名逻辑
+type Chain struct {
+	Prefix            uint16
+	KeyType           string
+	CallIndex         [2]byte // balances.transferKeepAlive的pallet下标和call下标
+	AddressType       string
+	CheckMetadataHash bool // runtime是否包含CheckMetadataHash签名扩展
+}
+
+func NewChain(prefix uint16, keyType, callIndex, addressType string, checkMetadataHash bool) (*Chain, error) {
+	if err := CheckKeyType(keyType); err != nil {
+		return nil, err
+	}
+	ci, err := hex.DecodeString(strings.TrimPrefix(callIndex, "0x"))
+	if err != nil || len(ci) != 2 {
+		return nil, fmt.Errorf("invalid call index: %s", callIndex)
+	}
+	if addressType == "" {
+		addressType = AddressTypeMultiAddress
+	}
+	if _, err = encodeAddress(addressType, make([]byte, AccountIdLength)); err != nil {
+		return nil, err
+	}
+	if _, err = encodeSS58Prefix(prefix); err != nil {
+		return nil, err
+	}
+	c := &Chain{
+		Prefix:            prefix,
+		KeyType:           keyType,
+		AddressType:       addressType,
+		CheckMetadataHash: checkMetadataHash,
+	}
+	copy(c.CallIndex[:], ci)
+	return c, nil
+}
+
+// TransferArgs 签名所需的全部链上参数，冷钱包由调用方传入，热钱包从节点获取
+type TransferArgs struct {
+	From               string
+	To                 string
+	Amount             *big.Int
+	Tip                *big.Int
+	Nonce              uint64
+	SpecVersion        uint32
+	TransactionVersion uint32
+	GenesisHash        []byte
+	BlockHash          []byte // mortal era的出生区块hash，immortal时等于GenesisHash
+	Era                Era
+}
+
+func encodeAddress(addressType string, accountId []byte) ([]byte, error) {
+	switch addressType {
+	case AddressTypeMultiAddress:
+		return append([]byte{0x00}, accountId...), nil
+	case AddressTypeAccountId:
+		return append([]byte{}, accountId...), nil
+	case AddressTypeLookup:
+		return append([]byte{0xff}, accountId...), nil
+	}
+	return nil, fmt.Errorf("unsupported address type: %s", addressType)
+}
+
+func (c *Chain) Address(pub []byte) (string, error) {
+	return EncodeSS58(pub, c.Prefix)
+}
+
+func (c *Chain) DecodeAddress(address string) ([]byte, error) {
+	return DecodeSS58WithPrefix(address, c.Prefix)
+}
+
+// TransferKeepAliveCall balances.transferKeepAlive(dest, value)
+func (c *Chain) TransferKeepAliveCall(to string, amount *big.Int) ([]byte, error) {
+	dest, err := c.DecodeAddress(to)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkU128(amount); err != nil {
+		return nil, err
+	}
+	addr, err := encodeAddress(c.AddressType, dest)
+	if err != nil {
+		return nil, err
+	}
+	call := append(append([]byte{}, c.CallIndex[:]...), addr...)
+	return append(call, EncodeCompactBig(amount)...), nil
+}
+
+func (c *Chain) signedExtra(args *TransferArgs) []byte {
+	buf := new(bytes.Buffer)
+	buf.Write(args.Era.Encode())
+	buf.Write(EncodeCompact(args.Nonce))
+	buf.Write(EncodeCompactBig(args.Tip))
+	if c.CheckMetadataHash {
+		// mode = Disabled
+		buf.WriteByte(0x00)
+	}
+	return buf.Bytes()
+}
+
+func (c *Chain) additionalSigned(args *TransferArgs) []byte {
+	buf := new(bytes.Buffer)
+	buf.Write(EncodeU32(args.SpecVersion))
+	buf.Write(EncodeU32(args.TransactionVersion))
+	buf.Write(args.GenesisHash)
+	buf.Write(args.BlockHash)
+	if c.CheckMetadataHash {
+		// Option<H256>::None
+		buf.WriteByte(0x00)
+	}
+	return buf.Bytes()
+}
+
+/*
+SignTransfer 构造并签名balances.transferKeepAlive交易
+	返回0x开头的hex编码extrinsic以及交易hash
+*/
+func (c *Chain) SignTransfer(kp *KeyPair, args *TransferArgs) (string, string, error) {
+	if len(args.GenesisHash) != 32 || len(args.BlockHash) != 32 {
+		return "", "", errors.New("genesis hash or block hash length must be 32")
+	}
+	if args.Tip == nil {
+		args.Tip = new(big.Int)
+	}
+	if err := checkU128(args.Tip); err != nil {
+		return "", "", err
+	}
+	fromAddress, err := c.Address(kp.Public())
+	if err != nil {
+		return "", "", err
+	}
+	if args.From != "" && args.From != fromAddress {
+		return "", "", fmt.Errorf("private key is not match address %s", args.From)
+	}
+	call, err := c.TransferKeepAliveCall(args.To, args.Amount)
+	if err != nil {
+		return "", "", err
+	}
+	extra := c.signedExtra(args)
+
+	payload := append(append(append([]byte{}, call...), extra...), c.additionalSigned(args)...)
+	if len(payload) > maxPayloadLength {
+		payload = Blake2b256(payload)
+	}
+	signature, err := kp.MultiSignature(payload)
+	if err != nil {
+		return "", "", err
+	}
+	signer, err := encodeAddress(c.AddressType, kp.Public())
+	if err != nil {
+		return "", "", err
+	}
+	body := new(bytes.Buffer)
+	body.WriteByte(extrinsicVersionSigned)
+	body.Write(signer)
+	body.Write(signature)
+	body.Write(extra)
+	body.Write(call)
+
+	extrinsic := append(EncodeCompact(uint64(body.Len())), body.Bytes()...)
+	return "0x" + hex.EncodeToString(extrinsic), "0x" + hex.EncodeToString(Blake2b256(extrinsic)), nil
+}
+
+// DecodeHash 解析0x开头的32字节hash
+func DecodeHash(h string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(h, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("decode hash %s error: %v", h, err)
+	}
+	if len(b) != 32 {
+		return nil, fmt.Errorf("hash %s length must be 32", h)
+	}
+	return b, nil
+}
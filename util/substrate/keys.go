@@ -0,0 +1,146 @@
+package substrate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ChainSafe/go-schnorrkel"
+	"github.com/group-coldwallet/trxsign/util/secmem"
+	"strings"
+)
+
+const (
+	KeyTypeSr25519 = "sr25519"
+	KeyTypeEd25519 = "ed25519"
+
+	SeedLength = 32
+)
+
+// MultiSignature枚举下标
+var signatureTypes = map[string]byte{
+	KeyTypeEd25519: 0x00,
+	KeyTypeSr25519: 0x01,
+}
+
+var signingContext = []byte("substrate")
+
+/*
+KeyPair 由32字节seed派生的密钥对
+	sr25519与subkey一致，使用ed25519方式扩展mini secret key
+*/
+type KeyPair struct {
+	keyType string
+	public  []byte
+	ed      ed25519.PrivateKey
+	sr      *schnorrkel.SecretKey
+}
+
+func CheckKeyType(keyType string) error {
+	if _, ok := signatureTypes[keyType]; !ok {
+		return fmt.Errorf("unsupported key type: %s", keyType)
+	}
+	return nil
+}
+
+func GenerateSeed() ([]byte, error) {
+	seed := make([]byte, SeedLength)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+func NewKeyPairFromSeed(keyType string, seed []byte) (*KeyPair, error) {
+	if len(seed) != SeedLength {
+		return nil, errors.New("invalid seed length")
+	}
+	kp := &KeyPair{keyType: keyType}
+	switch keyType {
+	case KeyTypeEd25519:
+		kp.ed = ed25519.NewKeyFromSeed(seed)
+		kp.public = []byte(kp.ed.Public().(ed25519.PublicKey))
+	case KeyTypeSr25519:
+		var raw [SeedLength]byte
+		copy(raw[:], seed)
+		defer secmem.Wipe(raw[:])
+		mini, err := schnorrkel.NewMiniSecretKeyFromRaw(raw)
+		if err != nil {
+			return nil, err
+		}
+		kp.sr = mini.ExpandEd25519()
+		pub, err := kp.sr.Public()
+		if err != nil {
+			return nil, err
+		}
+		p := pub.Encode()
+		kp.public = p[:]
+	default:
+		return nil, CheckKeyType(keyType)
+	}
+	return kp, nil
+}
+
+// NewKeyPairFromHex 从hex编码的seed(可带0x)派生密钥对
+func NewKeyPairFromHex(keyType, seedHex string) (*KeyPair, error) {
+	seed, err := hex.DecodeString(strings.TrimPrefix(seedHex, "0x"))
+	defer secmem.Wipe(seed)
+	if err != nil {
+		return nil, fmt.Errorf("decode seed error: %v", err)
+	}
+	return NewKeyPairFromSeed(keyType, seed)
+}
+
+// Wipe 签名后清零ed25519私钥，sr25519的私钥在schnorrkel内部，无法清零
+func (kp *KeyPair) Wipe() {
+	secmem.Wipe(kp.ed)
+}
+
+func (kp *KeyPair) Public() []byte {
+	return kp.public
+}
+
+func (kp *KeyPair) Sign(msg []byte) ([]byte, error) {
+	if kp.ed != nil {
+		return ed25519.Sign(kp.ed, msg), nil
+	}
+	sig, err := kp.sr.Sign(schnorrkel.NewSigningContext(signingContext, msg))
+	if err != nil {
+		return nil, err
+	}
+	s := sig.Encode()
+	return s[:], nil
+}
+
+// MultiSignature 编码为MultiSignature枚举
+func (kp *KeyPair) MultiSignature(msg []byte) ([]byte, error) {
+	sig, err := kp.Sign(msg)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{signatureTypes[kp.keyType]}, sig...), nil
+}
+
+func Verify(keyType string, public, msg, sig []byte) bool {
+	switch keyType {
+	case KeyTypeEd25519:
+		return ed25519.Verify(public, msg, sig)
+	case KeyTypeSr25519:
+		var p [32]byte
+		var s [64]byte
+		copy(p[:], public)
+		copy(s[:], sig)
+		pub, err := schnorrkel.NewPublicKey(p)
+		if err != nil {
+			return false
+		}
+		signature := new(schnorrkel.Signature)
+		if err = signature.Decode(s); err != nil {
+			return false
+		}
+		ok, err := pub.Verify(signature, schnorrkel.NewSigningContext(signingContext, msg))
+		return err == nil && ok
+	}
+	return false
+}
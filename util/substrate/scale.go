@@ -0,0 +1,67 @@
+package substrate
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+var maxU128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// EncodeCompact SCALE compact编码
+func EncodeCompact(v uint64) []byte {
+	switch {
+	case v < 1<<6:
+		return []byte{byte(v << 2)}
+	case v < 1<<14:
+		b := make([]byte, 2)
+		binary.LittleEndian.PutUint16(b, uint16(v<<2|0x01))
+		return b
+	case v < 1<<30:
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, uint32(v<<2|0x02))
+		return b
+	}
+	return EncodeCompactBig(new(big.Int).SetUint64(v))
+}
+
+// EncodeCompactBig SCALE compact编码，用于u128金额
+func EncodeCompactBig(v *big.Int) []byte {
+	if v.IsUint64() && v.Uint64() < 1<<30 {
+		return EncodeCompact(v.Uint64())
+	}
+	be := v.Bytes()
+	le := make([]byte, len(be))
+	for i := range be {
+		le[i] = be[len(be)-1-i]
+	}
+	if len(le) < 4 {
+		le = append(le, make([]byte, 4-len(le))...)
+	}
+	return append([]byte{byte((len(le)-4)<<2 | 0x03)}, le...)
+}
+
+func EncodeU32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+// DecodeU128 解析小端u128
+func DecodeU128(data []byte) (*big.Int, error) {
+	if len(data) < 16 {
+		return nil, errors.New("u128 data length is too short")
+	}
+	be := make([]byte, 16)
+	for i := 0; i < 16; i++ {
+		be[i] = data[15-i]
+	}
+	return new(big.Int).SetBytes(be), nil
+}
+
+func checkU128(v *big.Int) error {
+	if v.Sign() < 0 || v.Cmp(maxU128) > 0 {
+		return errors.New("value is out of u128 range")
+	}
+	return nil
+}
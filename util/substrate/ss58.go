@@ -0,0 +1,92 @@
+package substrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	AccountIdLength = 32
+	ss58ChecksumLen = 2
+	maxSS58Prefix   = 16383
+)
+
+var ss58Pre = []byte("SS58PRE")
+
+func ss58Checksum(data []byte) []byte {
+	h := blake2b.Sum512(append(append([]byte{}, ss58Pre...), data...))
+	return h[:ss58ChecksumLen]
+}
+
+func encodeSS58Prefix(prefix uint16) ([]byte, error) {
+	if prefix > maxSS58Prefix {
+		return nil, fmt.Errorf("ss58 prefix %d is out of range", prefix)
+	}
+	if prefix < 64 {
+		return []byte{byte(prefix)}, nil
+	}
+	first := byte((prefix&0x00fc)>>2) | 0x40
+	second := byte(prefix>>8) | byte((prefix&0x0003)<<6)
+	return []byte{first, second}, nil
+}
+
+// EncodeSS58 公钥编码为指定网络前缀的SS58地址
+func EncodeSS58(pub []byte, prefix uint16) (string, error) {
+	if len(pub) != AccountIdLength {
+		return "", errors.New("invalid public key length")
+	}
+	p, err := encodeSS58Prefix(prefix)
+	if err != nil {
+		return "", err
+	}
+	data := append(p, pub...)
+	return base58.Encode(append(data, ss58Checksum(data)...)), nil
+}
+
+// DecodeSS58 解析SS58地址，返回公钥和网络前缀
+func DecodeSS58(address string) ([]byte, uint16, error) {
+	data := base58.Decode(address)
+	if len(data) < 1 {
+		return nil, 0, fmt.Errorf("base58 decode address %s error", address)
+	}
+	var (
+		prefix    uint16
+		prefixLen int
+	)
+	switch {
+	case data[0] < 64:
+		prefix, prefixLen = uint16(data[0]), 1
+	case data[0] < 128:
+		if len(data) < 2 {
+			return nil, 0, fmt.Errorf("address %s length error", address)
+		}
+		lower := (data[0] << 2) | (data[1] >> 6)
+		upper := data[1] & 0x3f
+		prefix, prefixLen = uint16(lower)|uint16(upper)<<8, 2
+	default:
+		return nil, 0, fmt.Errorf("address %s has invalid ss58 prefix", address)
+	}
+	if len(data) != prefixLen+AccountIdLength+ss58ChecksumLen {
+		return nil, 0, fmt.Errorf("address %s length error", address)
+	}
+	body := data[:prefixLen+AccountIdLength]
+	if !bytes.Equal(ss58Checksum(body), data[prefixLen+AccountIdLength:]) {
+		return nil, 0, fmt.Errorf("address %s checksum error", address)
+	}
+	return body[prefixLen:], prefix, nil
+}
+
+// DecodeSS58WithPrefix 解析SS58地址并校验网络前缀
+func DecodeSS58WithPrefix(address string, prefix uint16) ([]byte, error) {
+	pub, p, err := DecodeSS58(address)
+	if err != nil {
+		return nil, err
+	}
+	if p != prefix {
+		return nil, fmt.Errorf("address %s prefix is %d,expect %d", address, p, prefix)
+	}
+	return pub, nil
+}
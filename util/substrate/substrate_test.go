@@ -0,0 +1,124 @@
+package substrate
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestSS58(t *testing.T) {
+	alice, _ := hex.DecodeString("d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d")
+	cases := map[uint16]string{
+		0:  "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5",
+		2:  "HNZata7iMYWmk5RvZRTiAsSDhV8366zq2YGb3tLH5Upf74F",
+		42: "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY",
+	}
+	for prefix, want := range cases {
+		got, err := EncodeSS58(alice, prefix)
+		if err != nil || got != want {
+			t.Fatalf("EncodeSS58 prefix=%d got %s,want %s,err=%v", prefix, got, want, err)
+		}
+		pub, err := DecodeSS58WithPrefix(want, prefix)
+		if err != nil || !bytes.Equal(pub, alice) {
+			t.Fatalf("DecodeSS58 %s error: %v", want, err)
+		}
+	}
+	// 两字节前缀
+	addr, err := EncodeSS58(alice, 1284)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DecodeSS58WithPrefix(addr, 1284); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DecodeSS58WithPrefix(cases[0], 2); err == nil {
+		t.Fatal("expected prefix mismatch error")
+	}
+}
+
+func TestEncodeCompact(t *testing.T) {
+	cases := map[uint64]string{
+		0:          "00",
+		1:          "04",
+		63:         "fc",
+		64:         "0101",
+		16383:      "fdff",
+		16384:      "02000100",
+		1073741823: "feffffff",
+		1073741824: "0300000040",
+	}
+	for v, want := range cases {
+		if got := hex.EncodeToString(EncodeCompact(v)); got != want {
+			t.Fatalf("EncodeCompact(%d)=%s,want %s", v, got, want)
+		}
+	}
+	big18, _ := new(big.Int).SetString("1000000000000000000", 10)
+	if got := hex.EncodeToString(EncodeCompactBig(big18)); got != "13000064a7b3b6e00d" {
+		t.Fatalf("EncodeCompactBig(1e18)=%s", got)
+	}
+}
+
+func TestEra(t *testing.T) {
+	if got := NewMortalEra(64, 42).Encode(); !bytes.Equal(got, []byte{0xa5, 0x02}) {
+		t.Fatalf("mortal era encode %x", got)
+	}
+	if got := (Era{}).Encode(); !bytes.Equal(got, []byte{0x00}) {
+		t.Fatalf("immortal era encode %x", got)
+	}
+	if e := NewMortalEra(100, 1000); e.Period != 128 || e.Phase != 1000%128 {
+		t.Fatalf("unexpected era %+v", e)
+	}
+}
+
+func TestTwox128(t *testing.T) {
+	if got := hex.EncodeToString(Twox128([]byte("System"))); got != "26aa394eea5630e07c48ae0c9558cef7" {
+		t.Fatalf("twox128(System)=%s", got)
+	}
+	if got := hex.EncodeToString(Twox128([]byte("Account"))); got != "b99d880ec681799c0cf30e8886371da9" {
+		t.Fatalf("twox128(Account)=%s", got)
+	}
+}
+
+func TestSignTransfer(t *testing.T) {
+	seed := bytes.Repeat([]byte{9}, SeedLength)
+	genesis := bytes.Repeat([]byte{1}, 32)
+	for _, keyType := range []string{KeyTypeSr25519, KeyTypeEd25519} {
+		chain, err := NewChain(0, keyType, "0503", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kp, err := NewKeyPairFromSeed(keyType, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from, _ := chain.Address(kp.Public())
+		args := &TransferArgs{
+			From:               from,
+			To:                 "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5",
+			Amount:             big.NewInt(12345),
+			Nonce:              7,
+			SpecVersion:        1002000,
+			TransactionVersion: 26,
+			GenesisHash:        genesis,
+			BlockHash:          genesis,
+		}
+		extrinsic, txid, err := chain.SignTransfer(kp, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := hex.DecodeString(extrinsic[2:])
+		// 长度前缀(2) + 版本(1) + 签名者(33) + 签名(65) + era(1) + nonce(1) + tip(1) + mode(1) + call(2+33+2)
+		if len(raw) != 2+1+33+65+4+37 || raw[2] != extrinsicVersionSigned || raw[36] != signatureTypes[keyType] {
+			t.Fatalf("%s unexpected extrinsic %s", keyType, extrinsic)
+		}
+		if txid != "0x"+hex.EncodeToString(Blake2b256(raw)) {
+			t.Fatalf("%s unexpected txid %s", keyType, txid)
+		}
+		call, _ := chain.TransferKeepAliveCall(args.To, args.Amount)
+		payload := append(append(call, chain.signedExtra(args)...), chain.additionalSigned(args)...)
+		if !Verify(keyType, kp.Public(), payload, raw[37:101]) {
+			t.Fatalf("%s signature verify failed", keyType)
+		}
+	}
+}
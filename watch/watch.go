@@ -0,0 +1,345 @@
+package watch
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrWatchOnly = errors.New("watch-only address can not sign")
+
+var fileHeader = []string{"address", "label", "tags", "createdAt", "updatedAt"}
+
+/*
+Address 只观察的地址，不保存私钥，只用于查询余额和充值扫描
+	Tags为自定义标签，如customerId、purpose
+*/
+type Address struct {
+	Address   string            `json:"address"`
+	MchId     string            `json:"mchId"`
+	Label     string            `json:"label,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	CreatedAt int64             `json:"createdAt"`
+	UpdatedAt int64             `json:"updatedAt"`
+}
+
+// Match tags中的标签都相同时返回true
+func (a *Address) Match(tags map[string]string) bool {
+	for k, v := range tags {
+		if a.Tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+type ImportFailure struct {
+	Address string `json:"address"`
+	Reason  string `json:"reason"`
+}
+
+type ImportResult struct {
+	Imported int             `json:"imported"`
+	Updated  int             `json:"updated"` //已存在的地址更新label和tags
+	Failures []ImportFailure `json:"failures"`
+}
+
+func (r *ImportResult) Fail(address, format string, args ...interface{}) {
+	r.Failures = append(r.Failures, ImportFailure{Address: address, Reason: fmt.Sprintf(format, args...)})
+}
+
+/*
+Store 观察地址，与私钥文件放在一起
+	每个商户一个文件 path/商户/币种_watch.csv，列为address,label,tags(json),createdAt,updatedAt
+*/
+type Store struct {
+	mu       sync.RWMutex
+	path     string
+	coinType string
+	addrs    map[string]*Address
+}
+
+// Open 加载path下所有商户的观察地址，目录不存在时为空
+func Open(path, coinType string) (*Store, error) {
+	s := &Store{path: path, coinType: strings.ToLower(coinType), addrs: make(map[string]*Address)}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) fileName() string {
+	return s.coinType + "_watch.csv"
+}
+
+func (s *Store) filePath(mchId string) string {
+	return filepath.Join(s.path, mchId, s.fileName())
+}
+
+// Reload 重新读取所有商户的文件，命令行导入后调用
+func (s *Store) Reload() error {
+	dirs, err := ioutil.ReadDir(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	addrs := make(map[string]*Address)
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		list, err := readFile(s.filePath(dir.Name()), dir.Name())
+		if err != nil {
+			return err
+		}
+		for _, a := range list {
+			if old, ok := addrs[a.Address]; ok {
+				return fmt.Errorf("watch address %s exists in both %s and %s", a.Address, old.MchId, a.MchId)
+			}
+			addrs[a.Address] = a
+		}
+	}
+	s.mu.Lock()
+	s.addrs = addrs
+	s.mu.Unlock()
+	return nil
+}
+
+func readFile(path, mchId string) ([]*Address, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read %s error: %v", path, err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	if strings.Join(records[0], ",") != strings.Join(fileHeader, ",") {
+		return nil, fmt.Errorf("%s: unknown header %v", path, records[0])
+	}
+	var list []*Address
+	for i, r := range records[1:] {
+		if len(r) != len(fileHeader) {
+			return nil, fmt.Errorf("%s: record %d is invalid", path, i+1)
+		}
+		a := &Address{Address: r[0], MchId: mchId, Label: r[1]}
+		if r[2] != "" {
+			if err = json.Unmarshal([]byte(r[2]), &a.Tags); err != nil {
+				return nil, fmt.Errorf("%s: parse tags of %s error: %v", path, r[0], err)
+			}
+		}
+		a.CreatedAt, _ = strconv.ParseInt(r[3], 10, 64)
+		a.UpdatedAt, _ = strconv.ParseInt(r[4], 10, 64)
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+// writeFile 先写临时文件再重命名，避免服务端读到写了一半的文件
+func writeFile(path string, list []*Address) error {
+	records := [][]string{fileHeader}
+	for _, a := range list {
+		tags := ""
+		if len(a.Tags) > 0 {
+			data, err := json.Marshal(a.Tags)
+			if err != nil {
+				return err
+			}
+			tags = string(data)
+		}
+		records = append(records, []string{a.Address, a.Label, tags,
+			strconv.FormatInt(a.CreatedAt, 10), strconv.FormatInt(a.UpdatedAt, 10)})
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	w.WriteAll(records)
+	if err = w.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+/*
+Import 导入商户的观察地址
+	已存在的地址更新label和tags；属于其他商户的地址记为失败
+	地址格式由调用方按币种校验
+*/
+func (s *Store) Import(mchId string, addrs []*Address) (*ImportResult, error) {
+	if mchId == "" {
+		return nil, errors.New("mchId is empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 以文件为准，命令行导入的地址可能还没有重新加载
+	current, err := readFile(s.filePath(mchId), mchId)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*Address, len(current))
+	for _, a := range current {
+		existing[a.Address] = a
+	}
+	result := &ImportResult{Failures: []ImportFailure{}}
+	now := time.Now().Unix()
+	changed := make(map[string]*Address)
+	for _, in := range addrs {
+		if other, ok := s.addrs[in.Address]; ok && other.MchId != mchId {
+			result.Fail(in.Address, "address belongs to another mchId")
+			continue
+		}
+		a := &Address{Address: in.Address, MchId: mchId, Label: in.Label, Tags: in.Tags, CreatedAt: now, UpdatedAt: now}
+		if prev, dup := changed[in.Address]; dup {
+			// 同一批中重复的地址以最后一条为准
+			a.CreatedAt = prev.CreatedAt
+		} else if old, ok := existing[in.Address]; ok {
+			a.CreatedAt = old.CreatedAt
+			result.Updated++
+		} else {
+			result.Imported++
+		}
+		changed[in.Address] = a
+	}
+	if len(changed) == 0 {
+		return result, nil
+	}
+	var list []*Address
+	for _, a := range current {
+		if changed[a.Address] == nil {
+			list = append(list, a)
+		}
+	}
+	for _, a := range changed {
+		list = append(list, a)
+	}
+	sortAddresses(list)
+	if err = writeFile(s.filePath(mchId), list); err != nil {
+		return nil, fmt.Errorf("write watch file error: %v", err)
+	}
+	for address, a := range s.addrs {
+		if a.MchId == mchId {
+			delete(s.addrs, address)
+		}
+	}
+	for _, a := range list {
+		s.addrs[a.Address] = a
+	}
+	return result, nil
+}
+
+func (s *Store) Get(address string) (*Address, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.addrs[address]
+	return a, ok
+}
+
+func (s *Store) Has(address string) bool {
+	_, ok := s.Get(address)
+	return ok
+}
+
+// List mchId为空时返回所有商户，tags不为空时只返回标签都相同的地址
+func (s *Store) List(mchId string, tags map[string]string) []*Address {
+	s.mu.RLock()
+	var list []*Address
+	for _, a := range s.addrs {
+		if (mchId == "" || a.MchId == mchId) && a.Match(tags) {
+			list = append(list, a)
+		}
+	}
+	s.mu.RUnlock()
+	sortAddresses(list)
+	return list
+}
+
+func sortAddresses(list []*Address) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].MchId != list[j].MchId {
+			return list[i].MchId < list[j].MchId
+		}
+		return list[i].Address < list[j].Address
+	})
+}
+
+/*
+ReadImportFile 读取命令行导入的csv文件
+	第一行为表头，必须有address列，label列可选，其他列作为标签，如customerId、purpose
+*/
+func ReadImportFile(path string) ([]*Address, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read %s error: %v", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	header := records[0]
+	addrCol, labelCol := -1, -1
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		switch strings.ToLower(header[i]) {
+		case "address":
+			addrCol = i
+		case "label":
+			labelCol = i
+		}
+	}
+	if addrCol < 0 {
+		return nil, fmt.Errorf("%s: address column not found", path)
+	}
+	var list []*Address
+	for _, record := range records[1:] {
+		a := &Address{}
+		for i, v := range record {
+			if i >= len(header) {
+				break
+			}
+			v = strings.TrimSpace(v)
+			switch {
+			case i == addrCol:
+				a.Address = v
+			case i == labelCol:
+				a.Label = v
+			case v != "" && header[i] != "":
+				if a.Tags == nil {
+					a.Tags = make(map[string]string)
+				}
+				a.Tags[header[i]] = v
+			}
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
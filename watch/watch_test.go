@@ -0,0 +1,83 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := Open(dir, "DOT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.Import("mch1", []*Address{
+		{Address: "addr1", Label: "cold", Tags: map[string]string{"customerId": "c1", "purpose": "deposit"}},
+		{Address: "addr2", Tags: map[string]string{"customerId": "c2"}},
+		{Address: "addr2", Label: "last", Tags: map[string]string{"customerId": "c2"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 2 || result.Updated != 0 || len(result.Failures) != 0 {
+		t.Fatalf("import result error: %+v", result)
+	}
+	if a, _ := s.Get("addr2"); a.Label != "last" {
+		t.Fatalf("duplicate address should use the last record: %+v", a)
+	}
+
+	// 更新已存在的地址，属于其他商户的地址失败
+	if result, err = s.Import("mch1", []*Address{{Address: "addr1", Label: "hot"}}); err != nil || result.Updated != 1 {
+		t.Fatalf("update result error: %+v %v", result, err)
+	}
+	if result, err = s.Import("mch2", []*Address{{Address: "addr2"}, {Address: "addr3"}}); err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 || len(result.Failures) != 1 || result.Failures[0].Address != "addr2" {
+		t.Fatalf("cross mch import result error: %+v", result)
+	}
+
+	if list := s.List("mch1", map[string]string{"customerId": "c2"}); len(list) != 1 || list[0].Address != "addr2" {
+		t.Fatalf("list by tags error: %+v", list)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "mch1", "dot_watch.csv")); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(dir, "dot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := reopened.List("", nil); len(list) != 3 {
+		t.Fatalf("reload error: %+v", list)
+	}
+	if a, ok := reopened.Get("addr1"); !ok || a.Label != "hot" || a.Tags != nil || a.MchId != "mch1" {
+		t.Fatalf("reloaded address error: %+v", a)
+	}
+}
+
+func TestReadImportFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "watch-import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("Address,label,customerId,purpose\naddr1,cold,c1,deposit\naddr2,,c2,\n")
+	file.Close()
+	list, err := ReadImportFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Label != "cold" || list[0].Tags["purpose"] != "deposit" {
+		t.Fatalf("read import file error: %+v", list[0])
+	}
+	if len(list[1].Tags) != 1 || list[1].Tags["customerId"] != "c2" {
+		t.Fatalf("empty tag should be skipped: %+v", list[1])
+	}
+}